@@ -0,0 +1,221 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// loadtest submits a configurable mix of entry types to a Rekor instance at a
+// target QPS and reports end-to-end latency percentiles. Because the server
+// waits for integration before responding 201, the measured latency covers
+// the full write path including Trillian sequencing; use it to size Trillian
+// and quota settings before a production rollout.
+//
+// Example:
+//
+//	loadtest --rekor_server http://localhost:3000 --qps 25 --duration 1m \
+//	    --mix hashedrekord=80,rekord=20
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/rekor/pkg/client"
+	gclient "github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/types"
+
+	// the entry kinds the mix flag can reference
+	_ "github.com/sigstore/rekor/pkg/types/hashedrekord/v0.0.1"
+	_ "github.com/sigstore/rekor/pkg/types/rekord/v0.0.1"
+)
+
+var (
+	rekorServer = flag.String("rekor_server", "http://localhost:3000", "address of the Rekor instance under test")
+	qps         = flag.Float64("qps", 10, "target entry submissions per second")
+	duration    = flag.Duration("duration", 30*time.Second, "how long to generate load")
+	concurrency = flag.Int("concurrency", 10, "maximum in-flight submissions")
+	mix         = flag.String("mix", "hashedrekord=100", "comma-separated entry type weights, e.g. hashedrekord=80,rekord=20")
+	timeout     = flag.Duration("timeout", 60*time.Second, "per-request timeout")
+)
+
+type result struct {
+	latency time.Duration
+	err     error
+}
+
+func main() {
+	flag.Parse()
+
+	kinds, weights, err := parseMix(*mix)
+	if err != nil {
+		log.Fatalf("parsing --mix: %v", err)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatalf("generating signing key: %v", err)
+	}
+	pubPEM, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		log.Fatalf("encoding public key: %v", err)
+	}
+
+	rc, err := client.GetRekorClient(*rekorServer)
+	if err != nil {
+		log.Fatalf("creating rekor client: %v", err)
+	}
+
+	total := int(*qps * duration.Seconds())
+	if total == 0 {
+		log.Fatal("qps and duration must produce at least one submission")
+	}
+	interval := time.Duration(float64(time.Second) / *qps)
+
+	fmt.Printf("submitting %d entries to %s over %v (%.1f qps, mix %s)\n", total, *rekorServer, *duration, *qps, *mix)
+
+	jobs := make(chan string)
+	results := make(chan result, total)
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for kind := range jobs {
+				results <- submit(rc, kind, priv, pubPEM)
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	for i := 0; i < total; i++ {
+		<-ticker.C
+		jobs <- pick(kinds, weights, i)
+	}
+	ticker.Stop()
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+
+	report(results, total, elapsed)
+}
+
+// parseMix converts "kind=weight,..." into parallel slices of kinds and
+// cumulative weights.
+func parseMix(s string) ([]string, []int, error) {
+	var kinds []string
+	var cumulative []int
+	sum := 0
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("%q is not of the form kind=weight", part)
+		}
+		weight, err := strconv.Atoi(kv[1])
+		if err != nil || weight <= 0 {
+			return nil, nil, fmt.Errorf("invalid weight %q for kind %q", kv[1], kv[0])
+		}
+		sum += weight
+		kinds = append(kinds, kv[0])
+		cumulative = append(cumulative, sum)
+	}
+	return kinds, cumulative, nil
+}
+
+// pick deterministically spreads submissions across the mix in proportion to
+// the configured weights.
+func pick(kinds []string, cumulative []int, i int) string {
+	total := cumulative[len(cumulative)-1]
+	n := i % total
+	for j, c := range cumulative {
+		if n < c {
+			return kinds[j]
+		}
+	}
+	return kinds[len(kinds)-1]
+}
+
+// submit signs a unique random artifact, builds a proposed entry of the given
+// kind, and measures how long the server takes to accept and integrate it.
+func submit(rc *gclient.Rekor, kind string, priv *ecdsa.PrivateKey, pubPEM []byte) result {
+	artifact := make([]byte, 32)
+	if _, err := rand.Read(artifact); err != nil {
+		return result{err: err}
+	}
+	digest := sha256.Sum256(artifact)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		return result{err: err}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	pe, err := types.NewProposedEntry(ctx, kind, "0.0.1", types.ArtifactProperties{
+		ArtifactBytes:  artifact,
+		SignatureBytes: sig,
+		PublicKeyBytes: pubPEM,
+		PKIFormat:      "x509",
+	})
+	if err != nil {
+		return result{err: err}
+	}
+
+	params := entries.NewCreateLogEntryParamsWithTimeout(*timeout)
+	params.SetProposedEntry(pe)
+	start := time.Now()
+	_, err = rc.Entries.CreateLogEntry(params)
+	return result{latency: time.Since(start), err: err}
+}
+
+func report(results chan result, total int, elapsed time.Duration) {
+	var latencies []time.Duration
+	errs := 0
+	for r := range results {
+		if r.err != nil {
+			errs++
+			continue
+		}
+		latencies = append(latencies, r.latency)
+	}
+	fmt.Printf("completed %d/%d entries in %v (%.1f/s effective), %d errors\n",
+		len(latencies), total, elapsed.Round(time.Millisecond), float64(len(latencies))/elapsed.Seconds(), errs)
+	if len(latencies) == 0 {
+		os.Exit(1)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	fmt.Println("end-to-end latency (including integration):")
+	for _, p := range []float64{50, 90, 95, 99} {
+		fmt.Printf("  p%.0f: %v\n", p, percentile(latencies, p).Round(time.Millisecond))
+	}
+	fmt.Printf("  max: %v\n", latencies[len(latencies)-1].Round(time.Millisecond))
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(p / 100 * float64(len(sorted)-1))
+	return sorted[idx]
+}
@@ -133,7 +133,13 @@ var getCmd = &cobra.Command{
 			}
 
 			for k, entry := range resp.Payload {
-				if k != u {
+				// the server may key the entry by a tree ID-qualified entry ID;
+				// compare on the UUID portion so both forms match
+				ku, err := sharding.GetUUIDFromIDString(k)
+				if err != nil {
+					return nil, err
+				}
+				if ku != u {
 					continue
 				}
 
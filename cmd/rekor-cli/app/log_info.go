@@ -30,15 +30,16 @@ import (
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/transparency-dev/merkle/proof"
 	"github.com/transparency-dev/merkle/rfc6962"
 
 	"github.com/sigstore/rekor/cmd/rekor-cli/app/format"
 	"github.com/sigstore/rekor/cmd/rekor-cli/app/state"
 	"github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/rekor/pkg/generated/client/pubkey"
 	"github.com/sigstore/rekor/pkg/generated/client/tlog"
 	"github.com/sigstore/rekor/pkg/log"
 	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
 	"github.com/sigstore/sigstore/pkg/signature"
 )
 
@@ -82,8 +83,15 @@ var logInfoCmd = &cobra.Command{
 
 		logInfo := result.GetPayload()
 
+		// use the tree hasher the server advertises; older servers omit the
+		// field, which selects the sha256 default
+		hasher, err := util.LogHasher(logInfo.HashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+
 		// Verify inactive shards
-		if err := verifyInactiveTrees(rekorClient, serverURL, logInfo.InactiveShards); err != nil {
+		if err := verifyInactiveTrees(rekorClient, serverURL, logInfo.InactiveShards, hasher); err != nil {
 			return nil, err
 		}
 
@@ -95,7 +103,7 @@ var logInfoCmd = &cobra.Command{
 		}
 		treeID := swag.StringValue(logInfo.TreeID)
 
-		if err := verifyTree(rekorClient, signedTreeHead, serverURL, treeID); err != nil {
+		if err := verifyTree(rekorClient, signedTreeHead, serverURL, treeID, hasher); err != nil {
 			return nil, err
 		}
 
@@ -109,7 +117,7 @@ var logInfoCmd = &cobra.Command{
 	}),
 }
 
-func verifyInactiveTrees(rekorClient *rclient.Rekor, serverURL string, inactiveShards []*models.InactiveShardLogInfo) error {
+func verifyInactiveTrees(rekorClient *rclient.Rekor, serverURL string, inactiveShards []*models.InactiveShardLogInfo, hasher *rfc6962.Hasher) error {
 	if inactiveShards == nil {
 		return nil
 	}
@@ -117,7 +125,7 @@ func verifyInactiveTrees(rekorClient *rclient.Rekor, serverURL string, inactiveS
 	for _, shard := range inactiveShards {
 		signedTreeHead := swag.StringValue(shard.SignedTreeHead)
 		treeID := swag.StringValue(shard.TreeID)
-		if err := verifyTree(rekorClient, signedTreeHead, serverURL, treeID); err != nil {
+		if err := verifyTree(rekorClient, signedTreeHead, serverURL, treeID, hasher); err != nil {
 			return fmt.Errorf("verifying inactive shard with ID %s: %w", treeID, err)
 		}
 	}
@@ -125,7 +133,7 @@ func verifyInactiveTrees(rekorClient *rclient.Rekor, serverURL string, inactiveS
 	return nil
 }
 
-func verifyTree(rekorClient *rclient.Rekor, signedTreeHead, serverURL, treeID string) error {
+func verifyTree(rekorClient *rclient.Rekor, signedTreeHead, serverURL, treeID string, hasher *rfc6962.Hasher) error {
 	oldState := state.Load(serverURL)
 	if treeID != "" {
 		oldState = state.Load(treeID)
@@ -134,7 +142,7 @@ func verifyTree(rekorClient *rclient.Rekor, signedTreeHead, serverURL, treeID st
 	if err := sth.UnmarshalText([]byte(signedTreeHead)); err != nil {
 		return err
 	}
-	verifier, err := loadVerifier(rekorClient)
+	verifier, err := loadVerifier(rekorClient, treeID)
 	if err != nil {
 		return err
 	}
@@ -142,7 +150,7 @@ func verifyTree(rekorClient *rclient.Rekor, signedTreeHead, serverURL, treeID st
 		return errors.New("signature on tree head did not verify")
 	}
 
-	if err := proveConsistency(rekorClient, oldState, sth, treeID); err != nil {
+	if err := proveConsistency(rekorClient, oldState, sth, treeID, hasher); err != nil {
 		return err
 	}
 
@@ -159,7 +167,7 @@ func verifyTree(rekorClient *rclient.Rekor, signedTreeHead, serverURL, treeID st
 	return nil
 }
 
-func proveConsistency(rekorClient *rclient.Rekor, oldState *util.SignedCheckpoint, sth util.SignedCheckpoint, treeID string) error {
+func proveConsistency(rekorClient *rclient.Rekor, oldState *util.SignedCheckpoint, sth util.SignedCheckpoint, treeID string, hasher *rfc6962.Hasher) error {
 	if oldState == nil {
 		log.CliLogger.Infof("No previous log state stored, unable to prove consistency")
 		return nil
@@ -182,7 +190,7 @@ func proveConsistency(rekorClient *rclient.Rekor, oldState *util.SignedCheckpoin
 			b, _ := hex.DecodeString(h)
 			hashes = append(hashes, b)
 		}
-		if err := proof.VerifyConsistency(rfc6962.DefaultHasher, persistedSize, sth.Size, hashes, oldState.Hash,
+		if err := verify.VerifyConsistency(hasher, persistedSize, sth.Size, hashes, oldState.Hash,
 			sth.Hash); err != nil {
 			return err
 		}
@@ -198,11 +206,35 @@ func proveConsistency(rekorClient *rclient.Rekor, oldState *util.SignedCheckpoin
 	return nil
 }
 
-func loadVerifier(rekorClient *rclient.Rekor) (signature.Verifier, error) {
+func loadVerifier(rekorClient *rclient.Rekor, treeID string) (signature.Verifier, error) {
+	// an operator-supplied trust root takes precedence over both a locally
+	// configured key and anything fetched from the server, and can carry a
+	// distinct key per shard
+	trustRoot, err := resolveTrustedRoot()
+	if err != nil {
+		return nil, err
+	}
+	if trustRoot != nil {
+		shard, err := trustRoot.LogByTreeID(treeID)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := shard.ParsedPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		return signature.LoadVerifier(pub, crypto.SHA256)
+	}
+
 	publicKey := viper.GetString("rekor_server_public_key")
 	if publicKey == "" {
-		// fetch key from server
-		keyResp, err := rekorClient.Pubkey.GetPublicKey(nil)
+		// fetch the key that was active for this shard from the server; after
+		// a key rotation this may differ from the current key
+		keyParams := pubkey.NewGetPublicKeyParams()
+		if treeID != "" {
+			keyParams.SetTreeID(&treeID)
+		}
+		keyResp, err := rekorClient.Pubkey.GetPublicKey(keyParams)
 		if err != nil {
 			return nil, err
 		}
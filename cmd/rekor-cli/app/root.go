@@ -33,6 +33,7 @@ import (
 	_ "github.com/sigstore/rekor/pkg/types/intoto/v0.0.1"
 	_ "github.com/sigstore/rekor/pkg/types/jar/v0.0.1"
 	_ "github.com/sigstore/rekor/pkg/types/rekord/v0.0.1"
+	_ "github.com/sigstore/rekor/pkg/types/rekord/v0.0.2"
 	_ "github.com/sigstore/rekor/pkg/types/rfc3161/v0.0.1"
 	_ "github.com/sigstore/rekor/pkg/types/rpm/v0.0.1"
 	_ "github.com/sigstore/rekor/pkg/types/tuf/v0.0.1"
@@ -64,6 +65,13 @@ func init() {
 	rootCmd.PersistentFlags().Var(NewFlagValue(timeoutFlag, "30s"), "timeout", "HTTP timeout")
 
 	rootCmd.PersistentFlags().String("api-key", "", "API key for rekor.sigstore.dev")
+	rootCmd.PersistentFlags().String("profile", "", "name of a server profile from the profiles section of the config file; its settings are applied unless overridden on the command line")
+	rootCmd.PersistentFlags().Var(NewFlagValue(fileFlag, ""), "trust-root", "path to a trusted root JSON document (as served at /api/v1/log/trustedRoot); when set, log keys from it are used for verification instead of keys fetched from the server")
+	rootCmd.PersistentFlags().Var(NewFlagValue(fileFlag, ""), "rekor-public-key", "path to a PEM-encoded public key pinned for verifying signed entry timestamps; when set it is used instead of a key fetched from the server")
+	rootCmd.PersistentFlags().Var(NewFlagValue(fileFlag, ""), "trust-bundle", "path to a trust bundle exported with the trust-bundle command; when set, log keys from it are used for verification instead of keys fetched from the server")
+	rootCmd.PersistentFlags().Var(NewFlagValue(urlFlag, ""), "tuf-repository", "base URL of a TUF repository to fetch the trusted root document from; takes precedence over --trust-root")
+	rootCmd.PersistentFlags().Var(NewFlagValue(fileFlag, ""), "tuf-root", "path to the initial TUF root.json used to bootstrap trust in --tuf-repository")
+	rootCmd.PersistentFlags().String("tuf-target", "", "name of the TUF target holding the trusted root document (default \"rekor.trustedRoot.json\")")
 
 	// these are bound here and not in PreRun so that all child commands can use them
 	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
@@ -115,5 +123,49 @@ func initConfig(cmd *cobra.Command) error {
 		log.CliLogger.Infof("Using config file:", viper.ConfigFileUsed())
 	}
 
+	return applyProfile(cmd)
+}
+
+// applyProfile overlays the settings of the server profile selected with
+// --profile from the profiles section of the config file, e.g.:
+//
+//	profiles:
+//	  corp:
+//	    rekor_server: https://rekor.corp.example.com
+//	    trust-root: /etc/rekor/corp-trusted-root.json
+//	    api-key: abc123
+//
+// Flags set explicitly on the command line keep their values.
+func applyProfile(cmd *cobra.Command) error {
+	profile := viper.GetString("profile")
+	if profile == "" {
+		return nil
+	}
+	settings := viper.GetStringMap("profiles." + profile)
+	if len(settings) == 0 {
+		return fmt.Errorf("profile %q is not defined in the config file", profile)
+	}
+	for key, val := range settings {
+		strVal := fmt.Sprintf("%v", val)
+		if items, ok := val.([]interface{}); ok {
+			// flag parsing expects slice values in comma-separated form
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = fmt.Sprintf("%v", item)
+			}
+			strVal = strings.Join(parts, ",")
+		}
+		if f := cmd.Flags().Lookup(key); f != nil {
+			if f.Changed {
+				continue
+			}
+			// run the profile value through the flag's validation logic
+			if err := cmd.Flags().Set(key, strVal); err != nil {
+				return fmt.Errorf("applying profile %q: %w", profile, err)
+			}
+			continue
+		}
+		viper.Set(key, val)
+	}
 	return nil
 }
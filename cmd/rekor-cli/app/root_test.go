@@ -0,0 +1,100 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestApplyProfile(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().Var(NewFlagValue(urlFlag, "https://rekor.sigstore.dev"), "rekor_server", "")
+		cmd.Flags().String("api-key", "", "")
+		return cmd
+	}
+
+	t.Run("no profile selected", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+		if err := applyProfile(newCmd()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+		viper.Set("profile", "missing")
+		if err := applyProfile(newCmd()); err == nil {
+			t.Error("expected error for undefined profile")
+		}
+	})
+
+	t.Run("profile applied", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+		viper.Set("profile", "corp")
+		viper.Set("profiles.corp", map[string]interface{}{
+			"rekor_server": "https://rekor.corp.example.com",
+			"api-key":      "abc123",
+		})
+		cmd := newCmd()
+		if err := applyProfile(cmd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, _ := cmd.Flags().GetString("api-key"); got != "abc123" {
+			t.Errorf("api-key = %q, want abc123", got)
+		}
+		if got := cmd.Flags().Lookup("rekor_server").Value.String(); got != "https://rekor.corp.example.com" {
+			t.Errorf("rekor_server = %q, want profile value", got)
+		}
+	})
+
+	t.Run("command line wins over profile", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+		viper.Set("profile", "corp")
+		viper.Set("profiles.corp", map[string]interface{}{
+			"rekor_server": "https://rekor.corp.example.com",
+		})
+		cmd := newCmd()
+		if err := cmd.Flags().Set("rekor_server", "https://rekor.other.example.com"); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyProfile(cmd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cmd.Flags().Lookup("rekor_server").Value.String(); got != "https://rekor.other.example.com" {
+			t.Errorf("rekor_server = %q, want command line value", got)
+		}
+	})
+
+	t.Run("invalid profile value rejected", func(t *testing.T) {
+		viper.Reset()
+		defer viper.Reset()
+		viper.Set("profile", "corp")
+		viper.Set("profiles.corp", map[string]interface{}{
+			"rekor_server": "not-a-url",
+		})
+		if err := applyProfile(newCmd()); err == nil {
+			t.Error("expected error for profile value failing flag validation")
+		}
+	})
+}
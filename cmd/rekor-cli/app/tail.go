@@ -0,0 +1,267 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/swag"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// tailPollInterval is how often the polling fallback checks for new entries
+// when the server does not offer the SSE tail endpoint.
+const tailPollInterval = 5 * time.Second
+
+// tailedEntry is one line of tail output.
+type tailedEntry struct {
+	UUID           string `json:"uuid"`
+	LogIndex       int64  `json:"logIndex"`
+	Kind           string `json:"kind"`
+	IntegratedTime string `json:"integratedTime"`
+}
+
+// streamedEntry mirrors the documents served by both the SSE tail and the
+// NDJSON stream endpoints.
+type streamedEntry struct {
+	UUID           string        `json:"uuid"`
+	LogIndex       int64         `json:"logIndex"`
+	Body           []byte        `json:"body"`
+	IntegratedTime int64         `json:"integratedTime"`
+	Error          *models.Error `json:"error,omitempty"`
+}
+
+// tailCmd watches the log and prints new entries as they integrate.
+var tailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Rekor tail command",
+	Long:  `Watches the transparency log and prints new entries as they are integrated, optionally filtered by kind or index key; useful for watching your own releases land in the log`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return fmt.Errorf("error initializing cmd line args: %w", err)
+		}
+		if kind := viper.GetString("kind"); kind != "" {
+			if _, ok := types.TypeMap.Load(kind); !ok {
+				return fmt.Errorf("unknown kind %q", kind)
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverURL := strings.TrimSuffix(viper.GetString("rekor_server"), "/")
+		start := viper.GetInt64("start")
+
+		if err := tailViaSSE(serverURL, start); err != nil {
+			log.CliLogger.Infof("live tail unavailable (%v), falling back to polling", err)
+			return tailViaPolling(serverURL, start)
+		}
+		return nil
+	},
+}
+
+// tailViaSSE consumes the server-sent events tail endpoint until the
+// connection drops. A non-200 response reports an error so the caller can
+// fall back to polling against older servers.
+func tailViaSSE(serverURL string, start int64) error {
+	tailURL := serverURL + "/api/v1/log/entries/tail"
+	if start >= 0 {
+		tailURL += fmt.Sprintf("?start=%d", start)
+	}
+	// no client timeout: the tail is expected to stay open indefinitely
+	resp, err := http.Get(tailURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "entry" && data != "" {
+				printStreamedEntry([]byte(data))
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	return scanner.Err()
+}
+
+// tailViaPolling repeatedly drains the NDJSON stream endpoint from the last
+// seen index; it never returns except on a fatal error.
+func tailViaPolling(serverURL string, start int64) error {
+	next := start
+	if next < 0 {
+		size, err := currentTreeSize(serverURL)
+		if err != nil {
+			return err
+		}
+		next = size
+	}
+	for {
+		n, err := drainStream(serverURL, next)
+		if err != nil {
+			return err
+		}
+		next += n
+		time.Sleep(tailPollInterval)
+	}
+}
+
+// drainStream prints all entries at index >= next and returns how many were
+// consumed; a 404 means the log has not reached the index yet.
+func drainStream(serverURL string, next int64) (int64, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/log/entries/stream?start=%d", serverURL, next))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %v from entry stream", resp.Status)
+	}
+
+	var consumed int64
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		printStreamedEntry(scanner.Bytes())
+		consumed++
+	}
+	return consumed, scanner.Err()
+}
+
+// currentTreeSize fetches the total virtual size of the log.
+func currentTreeSize(serverURL string) (int64, error) {
+	rekorClient, err := client.GetRekorClient(serverURL, client.WithUserAgent(UserAgent()))
+	if err != nil {
+		return 0, err
+	}
+	logInfo, err := rekorClient.Tlog.GetLogInfo(nil)
+	if err != nil {
+		return 0, err
+	}
+	size := swag.Int64Value(logInfo.GetPayload().TreeSize)
+	for _, shard := range logInfo.GetPayload().InactiveShards {
+		size += swag.Int64Value(shard.TreeSize)
+	}
+	return size, nil
+}
+
+// printStreamedEntry applies the kind and index-key filters to one streamed
+// entry document and prints it when it matches.
+func printStreamedEntry(data []byte) {
+	var entry streamedEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.CliLogger.Warnf("skipping malformed stream document: %v", err)
+		return
+	}
+	if entry.Error != nil {
+		log.CliLogger.Warnf("server reported an error mid-stream: %v", entry.Error.Message)
+		return
+	}
+	kind, eimpl, err := parseStreamedBody(entry.Body)
+	if err != nil {
+		log.CliLogger.Warnf("skipping entry %v with unparseable body: %v", entry.UUID, err)
+		return
+	}
+	if kindFilter := viper.GetString("kind"); kindFilter != "" && kind != kindFilter {
+		return
+	}
+	if keyFilters := viper.GetStringSlice("index-key"); len(keyFilters) > 0 {
+		indexKeys, err := eimpl.IndexKeys()
+		if err != nil {
+			log.CliLogger.Warnf("skipping entry %v: computing index keys: %v", entry.UUID, err)
+			return
+		}
+		if !anyKeyMatches(keyFilters, indexKeys) {
+			return
+		}
+	}
+
+	out, err := json.Marshal(tailedEntry{
+		UUID:           entry.UUID,
+		LogIndex:       entry.LogIndex,
+		Kind:           kind,
+		IntegratedTime: time.Unix(entry.IntegratedTime, 0).UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return
+	}
+	fmt.Println(string(out))
+}
+
+// parseStreamedBody decodes an entry body into its kind and type
+// implementation.
+func parseStreamedBody(body []byte) (string, types.EntryImpl, error) {
+	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(body), runtime.JSONConsumer())
+	if err != nil {
+		return "", nil, err
+	}
+	eimpl, err := types.NewEntry(pe)
+	if err != nil {
+		return "", nil, err
+	}
+	return pe.Kind(), eimpl, nil
+}
+
+// anyKeyMatches reports whether any requested index key appears in the
+// entry's index keys; comparisons are case-insensitive, matching how keys are
+// stored in the search index.
+func anyKeyMatches(requested, actual []string) bool {
+	for _, want := range requested {
+		for _, have := range actual {
+			if strings.EqualFold(want, have) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func init() {
+	initializePFlagMap()
+	tailCmd.Flags().Int64("start", -1, "log index to start tailing from; defaults to the current tree size, i.e. only new entries")
+	tailCmd.Flags().String("kind", "", "only print entries of this kind (e.g. rekord, intoto)")
+	tailCmd.Flags().StringSlice("index-key", nil, "only print entries carrying one of these search index keys (e.g. an email or sha256:<digest>)")
+	rootCmd.AddCommand(tailCmd)
+}
@@ -0,0 +1,64 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/tuf"
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// resolveTrustedRoot loads the trusted root document from whichever source is
+// configured, in order of precedence: an exported trust bundle
+// (--trust-bundle), a TUF repository (--tuf-repository, bootstrapped from the
+// --tuf-root metadata), or a local file named by --trust-root. Returns nil
+// when none is configured.
+func resolveTrustedRoot() (*util.TrustedRoot, error) {
+	if bundlePath := viper.GetString("trust-bundle"); bundlePath != "" {
+		bundle, err := util.LoadTrustBundle(bundlePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := bundle.Verify(); err != nil {
+			return nil, fmt.Errorf("verifying trust bundle: %w", err)
+		}
+		return &bundle.SignedTrustedRoot.TrustedRoot, nil
+	}
+	if repo := viper.GetString("tuf-repository"); repo != "" {
+		rootPath := viper.GetString("tuf-root")
+		if rootPath == "" {
+			return nil, fmt.Errorf("--tuf-root must be supplied alongside --tuf-repository")
+		}
+		rootJSON, err := os.ReadFile(filepath.Clean(rootPath))
+		if err != nil {
+			return nil, fmt.Errorf("reading initial TUF root: %w", err)
+		}
+		tufClient, err := tuf.New(repo, rootJSON)
+		if err != nil {
+			return nil, err
+		}
+		return tufClient.TrustedRoot(viper.GetString("tuf-target"))
+	}
+	if trustRootPath := viper.GetString("trust-root"); trustRootPath != "" {
+		return util.LoadTrustedRoot(trustRootPath)
+	}
+	return nil, nil
+}
@@ -0,0 +1,132 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/cmd/rekor-cli/app/format"
+	"github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/rekor/pkg/generated/client/tlog"
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+type trustBundleCmdOutput struct {
+	Path   string
+	Shards int
+}
+
+func (t *trustBundleCmdOutput) String() string {
+	return fmt.Sprintf("Exported trust bundle covering %d shard(s) to %s\n", t.Shards, t.Path)
+}
+
+// trustBundleCmd exports the log's verification material as a single file so
+// entries can be verified in disconnected environments.
+var trustBundleCmd = &cobra.Command{
+	Use:   "trust-bundle",
+	Short: "Rekor trust-bundle command",
+	Long:  `Exports the log's verification material (shard keys, shard table, and current signed checkpoints) into a single file for air-gapped verification`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return fmt.Errorf("error initializing cmd line args: %w", err)
+		}
+		if viper.GetString("output") == "" {
+			return fmt.Errorf("--output must be specified")
+		}
+		return nil
+	},
+	Run: format.WrapCmd(func(args []string) (interface{}, error) {
+		serverURL := viper.GetString("rekor_server")
+
+		// the trusted root endpoint is served outside the generated client
+		httpClient := &http.Client{Timeout: viper.GetDuration("timeout")}
+		resp, err := httpClient.Get(strings.TrimSuffix(serverURL, "/") + "/api/v1/log/trustedRoot")
+		if err != nil {
+			return nil, fmt.Errorf("fetching trusted root: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching trusted root: unexpected status %v", resp.Status)
+		}
+		var signedRoot util.SignedTrustedRoot
+		if err := json.NewDecoder(resp.Body).Decode(&signedRoot); err != nil {
+			return nil, fmt.Errorf("parsing trusted root: %w", err)
+		}
+
+		rekorClient, err := client.GetRekorClient(serverURL, client.WithUserAgent(UserAgent()))
+		if err != nil {
+			return nil, err
+		}
+		params := tlog.GetLogInfoParams{}
+		params.SetTimeout(viper.GetDuration("timeout"))
+		result, err := rekorClient.Tlog.GetLogInfo(&params)
+		if err != nil {
+			return nil, err
+		}
+		logInfo := result.GetPayload()
+
+		bundle := util.TrustBundle{
+			MediaType:         util.TrustBundleMediaType,
+			CreatedAt:         time.Now().UTC().Format(time.RFC3339),
+			SignedTrustedRoot: signedRoot,
+		}
+		for _, shard := range logInfo.InactiveShards {
+			bundle.Checkpoints = append(bundle.Checkpoints, util.TrustBundleCheckpoint{
+				TreeID:     swag.StringValue(shard.TreeID),
+				Checkpoint: swag.StringValue(shard.SignedTreeHead),
+			})
+		}
+		bundle.Checkpoints = append(bundle.Checkpoints, util.TrustBundleCheckpoint{
+			TreeID:     swag.StringValue(logInfo.TreeID),
+			Checkpoint: swag.StringValue(logInfo.SignedTreeHead),
+		})
+
+		// refuse to export material that does not verify against itself
+		if err := bundle.Verify(); err != nil {
+			return nil, fmt.Errorf("verifying exported trust bundle: %w", err)
+		}
+
+		contents, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		outputPath := viper.GetString("output")
+		if err := os.WriteFile(outputPath, contents, 0o644); err != nil {
+			return nil, fmt.Errorf("writing trust bundle: %w", err)
+		}
+
+		return &trustBundleCmdOutput{
+			Path:   outputPath,
+			Shards: len(bundle.Checkpoints),
+		}, nil
+	}),
+}
+
+func init() {
+	initializePFlagMap()
+	trustBundleCmd.Flags().String("output", "", "path to write the trust bundle to")
+	rootCmd.AddCommand(trustBundleCmd)
+}
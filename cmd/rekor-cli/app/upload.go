@@ -16,23 +16,28 @@
 package app
 
 import (
+	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/sha256"
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/swag"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/sigstore/rekor/cmd/rekor-cli/app/format"
+	"github.com/sigstore/rekor/pkg/canonical"
 	"github.com/sigstore/rekor/pkg/client"
 	genclient "github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/rekor/pkg/generated/client/entries"
@@ -40,6 +45,9 @@ import (
 	"github.com/sigstore/rekor/pkg/log"
 	"github.com/sigstore/rekor/pkg/types"
 	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
 )
 
 type uploadCmdOutput struct {
@@ -72,6 +80,9 @@ var uploadCmd = &cobra.Command{
 	Long: `This command takes the public key, signature and URL of the release artifact and uploads it to the rekor server.`,
 	Run: format.WrapCmd(func(args []string) (interface{}, error) {
 		ctx := context.Background()
+		if viper.GetBool("multipart") {
+			return uploadMultipart(viper.GetString("rekor_server"))
+		}
 		rekorClient, err := client.GetRekorClient(viper.GetString("rekor_server"), client.WithUserAgent(UserAgent()))
 		if err != nil {
 			return nil, err
@@ -117,17 +128,53 @@ var uploadCmd = &cobra.Command{
 		}
 		params.SetProposedEntry(entry)
 
-		resp, err := rekorClient.Entries.CreateLogEntry(params)
-		if err != nil {
+		// the leaf hash is computed up front so that a submission that fails
+		// mid-flight can be checked for inclusion before it is retried,
+		// rather than assuming the entry never made it to the log
+		expectedUUID := ""
+		if impl, err := types.NewEntry(entry); err == nil {
+			if leaf, err := types.CanonicalizeEntry(ctx, impl); err == nil {
+				expectedUUID = hex.EncodeToString(verify.ComputeLeafHash(nil, leaf))
+			}
+		}
+
+		attempts := int(viper.GetUint("retry")) + 1
+		var resp *entries.CreateLogEntryCreated
+		for attempt := 0; attempt < attempts; attempt++ {
+			resp, err = rekorClient.Entries.CreateLogEntry(params)
+			if err == nil {
+				break
+			}
 			switch e := err.(type) {
 			case *entries.CreateLogEntryConflict:
 				return &uploadCmdOutput{
 					Location:      e.Location.String(),
 					AlreadyExists: true,
 				}, nil
-			default:
+			case *entries.CreateLogEntryBadRequest:
+				// the request itself is invalid; retrying cannot help
 				return nil, err
 			}
+
+			// the request may have been applied even though the response was
+			// lost; look for the entry before submitting it again
+			if expectedUUID != "" {
+				if integrated, logEntry := fetchIntegratedEntry(rekorClient, expectedUUID); integrated {
+					if verified, err := verifyLogEntry(ctx, rekorClient, logEntry); err != nil || !verified {
+						return nil, fmt.Errorf("unable to verify entry was added to log: %w", err)
+					}
+					return &uploadCmdOutput{
+						Location: "/api/v1/log/entries/" + expectedUUID,
+						Index:    swag.Int64Value(logEntry.LogIndex),
+					}, nil
+				}
+			}
+
+			if attempt == attempts-1 {
+				return nil, err
+			}
+			log.CliLogger.Warnf("retrying upload after error: %v", err)
+			time.Sleep(time.Duration(attempt+1) * time.Second)
 		}
 
 		var newIndex int64
@@ -149,6 +196,106 @@ var uploadCmd = &cobra.Command{
 	}),
 }
 
+// uploadMultipart sends the artifact, signature, and public key as raw
+// multipart parts instead of a base64-encoded JSON body, avoiding the ~33%
+// inflation on large binary artifacts. Only local files are supported.
+func uploadMultipart(serverURL string) (*uploadCmdOutput, error) {
+	artifactPath := viper.GetString("artifact")
+	signaturePath := viper.GetString("signature")
+	publicKeyPath := viper.GetString("public-key")
+	if artifactPath == "" || signaturePath == "" || publicKeyPath == "" {
+		return nil, fmt.Errorf("--artifact, --signature, and --public-key files are required for multipart upload")
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	addFile := func(field, path string) error {
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		part, err := mw.CreateFormFile(field, filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, f)
+		return err
+	}
+	if err := addFile("artifact", artifactPath); err != nil {
+		return nil, fmt.Errorf("error processing artifact file: %w", err)
+	}
+	if err := addFile("signature", signaturePath); err != nil {
+		return nil, fmt.Errorf("error processing signature file: %w", err)
+	}
+	if err := addFile("publicKey", publicKeyPath); err != nil {
+		return nil, fmt.Errorf("error processing public key file: %w", err)
+	}
+	if err := mw.WriteField("pkiFormat", viper.GetString("pki-format")); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(serverURL, "/")+"/api/v1/log/entries", &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("User-Agent", UserAgent())
+	httpClient := &http.Client{Timeout: viper.GetDuration("timeout")}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		var logEntry models.LogEntry
+		if err := json.NewDecoder(resp.Body).Decode(&logEntry); err != nil {
+			return nil, fmt.Errorf("error parsing response: %w", err)
+		}
+		var index int64
+		for _, e := range logEntry {
+			index = swag.Int64Value(e.LogIndex)
+		}
+		return &uploadCmdOutput{
+			Location: resp.Header.Get("Location"),
+			Index:    index,
+		}, nil
+	case http.StatusConflict:
+		return &uploadCmdOutput{
+			Location:      resp.Header.Get("Location"),
+			AlreadyExists: true,
+		}, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+// fetchIntegratedEntry checks whether the entry with the given leaf hash has
+// already been integrated into the log, returning it if so.
+func fetchIntegratedEntry(rekorClient *genclient.Rekor, entryUUID string) (bool, models.LogEntryAnon) {
+	params := entries.NewGetLogEntryByUUIDParams()
+	params.SetTimeout(viper.GetDuration("timeout"))
+	params.EntryUUID = entryUUID
+
+	resp, err := rekorClient.Entries.GetLogEntryByUUID(params)
+	if err != nil {
+		return false, models.LogEntryAnon{}
+	}
+	for id, logEntry := range resp.Payload {
+		// the response key may carry a tree ID prefix in front of the UUID
+		if strings.HasSuffix(id, entryUUID) {
+			return true, logEntry
+		}
+	}
+	return false, models.LogEntryAnon{}
+}
+
 func verifyLogEntry(ctx context.Context, rekorClient *genclient.Rekor, logEntry models.LogEntryAnon) (bool, error) {
 	if logEntry.Verification == nil {
 		return false, nil
@@ -169,30 +316,60 @@ func verifyLogEntry(ctx context.Context, rekorClient *genclient.Rekor, logEntry
 	if err != nil {
 		return false, err
 	}
-	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	canonicalized, err := canonical.Transform(payload)
 	if err != nil {
 		return false, err
 	}
 
-	// get rekor's public key
-	rekorPubKey, err := util.PublicKey(ctx, rekorClient)
+	// prefer an operator-pinned public key over one fetched from the server,
+	// so a misbehaving or impostor server cannot vouch for its own entries
+	var rekorPubKey crypto.PublicKey
+	pinnedPath := viper.GetString("rekor-public-key")
+	if pinnedPath != "" {
+		rekorPubKey, err = loadPinnedPublicKey(pinnedPath)
+	} else {
+		rekorPubKey, err = util.PublicKey(ctx, rekorClient)
+	}
 	if err != nil {
 		return false, err
 	}
 
-	// verify the SET against the public key
-	hash := sha256.Sum256(canonicalized)
-	if !ecdsa.VerifyASN1(rekorPubKey, hash[:], []byte(logEntry.Verification.SignedEntryTimestamp)) {
-		return false, fmt.Errorf("unable to verify")
+	// verify the SET against the public key; the verifier dispatches on the
+	// key type, so both ECDSA and Ed25519 instances are supported
+	verifier, err := signature.LoadVerifier(rekorPubKey, crypto.SHA256)
+	if err != nil {
+		return false, err
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(logEntry.Verification.SignedEntryTimestamp), bytes.NewReader(canonicalized)); err != nil {
+		if pinnedPath != "" {
+			return false, fmt.Errorf("signed entry timestamp does not verify against pinned public key %v: %w", pinnedPath, err)
+		}
+		return false, fmt.Errorf("unable to verify: %w", err)
 	}
 	return true, nil
 }
 
+// loadPinnedPublicKey reads a PEM-encoded log public key from disk for use in
+// place of a key served by the log itself.
+func loadPinnedPublicKey(path string) (crypto.PublicKey, error) {
+	pemBytes, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading pinned public key: %w", err)
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing pinned public key %v: %w", path, err)
+	}
+	return pub, nil
+}
+
 func init() {
 	initializePFlagMap()
 	if err := addArtifactPFlags(uploadCmd); err != nil {
 		log.CliLogger.Fatal("Error parsing cmd line args:", err)
 	}
+	uploadCmd.Flags().Bool("multipart", false, "send the artifact, signature, and public key as multipart/form-data instead of a base64-encoded JSON body; requires local files")
+	uploadCmd.Flags().Uint("retry", 3, "number of times to retry the upload; before each retry the log is searched for the entry to avoid creating duplicates")
 
 	rootCmd.AddCommand(uploadCmd)
 }
@@ -18,23 +18,36 @@ package app
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/bits"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/transparency-dev/merkle/proof"
 	"github.com/transparency-dev/merkle/rfc6962"
 
 	"github.com/sigstore/rekor/cmd/rekor-cli/app/format"
 	"github.com/sigstore/rekor/pkg/client"
+	genclient "github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/client/index"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
 	"github.com/sigstore/rekor/pkg/types"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
 )
 
 type verifyCmdOutput struct {
@@ -107,6 +120,14 @@ var verifyCmd = &cobra.Command{
 				return nil, fmt.Errorf("error parsing --log-index: %w", err)
 			}
 			searchLogQuery.LogIndexes = []*int64{&logIndexInt}
+		} else if viper.GetString("signature") == "" && viper.GetString("entry") == "" {
+			// without a signature a proposed entry cannot be built; hash the
+			// artifact locally and find the entry through the search index
+			u, err := findMatchingEntryUUID(rekorClient)
+			if err != nil {
+				return nil, err
+			}
+			searchLogQuery.EntryUUIDs = append(searchLogQuery.EntryUUIDs, u)
 		} else {
 			typeStr, versionStr, err := ParseTypeFlag(viper.GetString("type"))
 			if err != nil {
@@ -139,10 +160,17 @@ var verifyCmd = &cobra.Command{
 
 		var o *verifyCmdOutput
 		var entryBytes []byte
+		var anonEntry models.LogEntryAnon
 		for k, v := range logEntry {
+			// the key may be a plain UUID or a tree ID-qualified entry ID;
+			// the UUID portion is the leaf hash used in the proof below
+			u, err := sharding.GetUUIDFromIDString(k)
+			if err != nil {
+				return nil, err
+			}
 			o = &verifyCmdOutput{
 				RootHash:  *v.Verification.InclusionProof.RootHash,
-				EntryUUID: k,
+				EntryUUID: u,
 				Index:     *v.LogIndex,
 				Size:      *v.Verification.InclusionProof.TreeSize,
 				Hashes:    v.Verification.InclusionProof.Hashes,
@@ -151,14 +179,21 @@ var verifyCmd = &cobra.Command{
 			if err != nil {
 				return nil, err
 			}
+			anonEntry = v
 		}
 
-		if viper.IsSet("uuid") && (viper.GetString("uuid") != o.EntryUUID) {
-			return nil, fmt.Errorf("unexpected entry returned from rekor server")
+		if viper.IsSet("uuid") {
+			u, err := sharding.GetUUIDFromIDString(viper.GetString("uuid"))
+			if err != nil {
+				return nil, err
+			}
+			if u != o.EntryUUID {
+				return nil, fmt.Errorf("unexpected entry returned from rekor server")
+			}
 		}
 
 		leafHash, _ := hex.DecodeString(o.EntryUUID)
-		if !bytes.Equal(rfc6962.DefaultHasher.HashLeaf(entryBytes), leafHash) {
+		if !bytes.Equal(verify.ComputeLeafHash(rfc6962.DefaultHasher, entryBytes), leafHash) {
 			return nil, fmt.Errorf("computed leaf hash did not match entry UUID")
 		}
 
@@ -170,13 +205,135 @@ var verifyCmd = &cobra.Command{
 
 		rootHash, _ := hex.DecodeString(o.RootHash)
 
-		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(o.Index), uint64(o.Size), leafHash, hashes, rootHash); err != nil {
+		if err := verify.VerifyInclusion(rfc6962.DefaultHasher, uint64(o.Index), uint64(o.Size), leafHash, hashes, rootHash); err != nil {
+			return nil, err
+		}
+
+		// when an operator-supplied trust root is provided, also verify the
+		// SET using the key recorded there for the shard that signed the
+		// entry, rather than any key fetched from the server
+		trustRoot, err := resolveTrustedRoot()
+		if err != nil {
 			return nil, err
 		}
+		if trustRoot != nil {
+			shard, err := trustRoot.LogByLogID(swag.StringValue(anonEntry.LogID))
+			if err != nil {
+				return nil, err
+			}
+			pub, err := shard.ParsedPublicKey()
+			if err != nil {
+				return nil, err
+			}
+			if err := client.VerifySignedEntryTimestamp(anonEntry, pub); err != nil {
+				return nil, fmt.Errorf("verifying SET against trust root: %w", err)
+			}
+		}
 		return o, err
 	}),
 }
 
+// findMatchingEntryUUID locates an entry to verify when no signature is
+// available to build a proposed entry from: the artifact is hashed locally
+// (or --artifact-hash is taken as-is) and the search index is queried by that
+// hash, narrowed to entries made with --public-key when one is supplied. If
+// several entries still match, the last one recorded in the index is used.
+func findMatchingEntryUUID(rekorClient *genclient.Rekor) (string, error) {
+	hashQuery := index.NewSearchIndexParams()
+	hashQuery.SetTimeout(viper.GetDuration("timeout"))
+	hashQuery.Query = &models.SearchIndex{}
+
+	if sha := viper.GetString("artifact-hash"); sha != "" {
+		hashQuery.Query.Hash = util.PrefixSHA(sha)
+	} else {
+		hashVal, err := hashArtifact(viper.GetString("artifact"))
+		if err != nil {
+			return "", err
+		}
+		hashQuery.Query.Hash = "sha256:" + hashVal
+	}
+
+	resp, err := rekorClient.Index.SearchIndex(hashQuery)
+	if err != nil {
+		if t, ok := err.(*index.SearchIndexDefault); ok && t.Code() == http.StatusNotImplemented {
+			return "", fmt.Errorf("search index not enabled on %v; supply --signature to locate the entry without it", viper.GetString("rekor_server"))
+		}
+		return "", err
+	}
+	matches := resp.GetPayload()
+
+	if publicKeyStr := viper.GetString("public-key"); publicKeyStr != "" {
+		keyQuery := index.NewSearchIndexParams()
+		keyQuery.SetTimeout(viper.GetDuration("timeout"))
+		keyQuery.Query = &models.SearchIndex{
+			PublicKey: &models.SearchIndexPublicKey{
+				Format: swag.String(viper.GetString("pki-format")),
+			},
+		}
+		if isURL(publicKeyStr) {
+			keyQuery.Query.PublicKey.URL = strfmt.URI(publicKeyStr)
+		} else {
+			keyBytes, err := ioutil.ReadFile(filepath.Clean(publicKeyStr))
+			if err != nil {
+				return "", fmt.Errorf("error reading public key file: %w", err)
+			}
+			keyQuery.Query.PublicKey.Content = strfmt.Base64(keyBytes)
+		}
+
+		keyResp, err := rekorClient.Index.SearchIndex(keyQuery)
+		if err != nil {
+			return "", err
+		}
+		byKey := make(map[string]struct{}, len(keyResp.GetPayload()))
+		for _, u := range keyResp.GetPayload() {
+			byKey[u] = struct{}{}
+		}
+		intersected := []string{}
+		for _, u := range matches {
+			if _, ok := byKey[u]; ok {
+				intersected = append(intersected, u)
+			}
+		}
+		matches = intersected
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no entries found in the index matching the artifact")
+	}
+	if len(matches) > 1 {
+		log.CliLogger.Warnf("%d entries match the artifact; verifying the most recent one (%v)", len(matches), matches[len(matches)-1])
+	}
+	// the index may return tree ID-qualified entry IDs, but searchLogQuery
+	// only accepts the bare UUID portion
+	return sharding.GetUUIDFromIDString(matches[len(matches)-1])
+}
+
+// hashArtifact returns the hex-encoded SHA256 digest of the file or URL.
+func hashArtifact(artifactStr string) (string, error) {
+	hasher := sha256.New()
+	var tee io.Reader
+	if isURL(artifactStr) {
+		/* #nosec G107 */
+		resp, err := http.Get(artifactStr)
+		if err != nil {
+			return "", fmt.Errorf("error fetching '%v': %w", artifactStr, err)
+		}
+		defer resp.Body.Close()
+		tee = io.TeeReader(resp.Body, hasher)
+	} else {
+		file, err := os.Open(filepath.Clean(artifactStr))
+		if err != nil {
+			return "", fmt.Errorf("error opening file '%v': %w", artifactStr, err)
+		}
+		defer file.Close()
+		tee = io.TeeReader(file, hasher)
+	}
+	if _, err := ioutil.ReadAll(tee); err != nil {
+		return "", fmt.Errorf("error processing '%v': %w", artifactStr, err)
+	}
+	return strings.ToLower(hex.EncodeToString(hasher.Sum(nil))), nil
+}
+
 func init() {
 	initializePFlagMap()
 	if err := addArtifactPFlags(verifyCmd); err != nil {
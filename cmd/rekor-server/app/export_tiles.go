@@ -0,0 +1,73 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/trillian"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/tiles"
+)
+
+// exportTilesCmd represents the export-tiles command
+var exportTilesCmd = &cobra.Command{
+	Use:   "export-tiles",
+	Short: "Export the log as tiled Merkle tree data for static serving",
+	Long: `Streams every leaf from a Trillian tree and writes the log to a directory
+or object store bucket as hash tiles, entry bundles, and a checkpoint. The
+exported tile set can be served from a CDN and answers entry and proof
+requests without touching Trillian.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		bucketURL := viper.GetString("bucket")
+		if bucketURL == "" {
+			return errors.New("--bucket must be set, e.g. file:///var/rekor/tiles or gs://my-bucket")
+		}
+
+		conn, err := grpc.Dial(viper.GetString("trillian_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing log server: %w", err)
+		}
+		defer conn.Close()
+
+		return tiles.Export(ctx, trillian.NewTrillianLogClient(conn),
+			viper.GetInt64("tree_id"), viper.GetInt64("batch_size"), bucketURL)
+	},
+}
+
+func init() {
+	exportTilesCmd.Flags().String("trillian_address", "127.0.0.1:8090", "Trillian log server address:port")
+	exportTilesCmd.Flags().Int64("tree_id", 0, "Trillian tree ID to export")
+	exportTilesCmd.Flags().Int64("batch_size", 256, "Number of leaves to fetch per read from the log")
+	exportTilesCmd.Flags().String("bucket", "", "Destination bucket URL for the tile set")
+	rootCmd.AddCommand(exportTilesCmd)
+}
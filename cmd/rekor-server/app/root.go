@@ -17,9 +17,8 @@ package app
 
 import (
 	"fmt"
-	"net/http"
-	"net/http/pprof"
 	"os"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/sigstore/rekor/pkg/log"
@@ -59,25 +58,95 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.rekor-server.yaml)")
 	rootCmd.PersistentFlags().StringVar(&logType, "log_type", "dev", "logger type to use (dev/prod)")
-	rootCmd.PersistentFlags().BoolVar(&enablePprof, "enable_pprof", false, "enable pprof for profiling on port 6060")
+	rootCmd.PersistentFlags().BoolVar(&enablePprof, "enable_pprof", false, "enable pprof profiling endpoints on the ops listener")
 
 	rootCmd.PersistentFlags().String("trillian_log_server.address", "127.0.0.1", "Trillian log server address")
 	rootCmd.PersistentFlags().Uint16("trillian_log_server.port", 8090, "Trillian log server port")
+	rootCmd.PersistentFlags().String("trillian_log_server.replica_address", "", "address of a Trillian log server read replica; when set, read RPCs are routed there while writes stay on the primary")
+	rootCmd.PersistentFlags().Uint16("trillian_log_server.replica_port", 8090, "port of the Trillian log server read replica")
 	rootCmd.PersistentFlags().Uint("trillian_log_server.tlog_id", 0, "Trillian tree id")
 	rootCmd.PersistentFlags().String("trillian_log_server.sharding_config", "", "path to config file for inactive shards")
+	rootCmd.PersistentFlags().String("trillian_log_server.hash_algorithm", "sha256", "hash algorithm the Trillian tree was created with (sha256, sha384, sha512)")
+	rootCmd.PersistentFlags().Int("trillian_log_server.grpc_max_recv_msg_size", 0, "maximum size in bytes of gRPC messages received from the Trillian log server; 0 uses the gRPC default")
+	rootCmd.PersistentFlags().Int("trillian_log_server.grpc_max_send_msg_size", 0, "maximum size in bytes of gRPC messages sent to the Trillian log server; 0 uses the gRPC default")
+	rootCmd.PersistentFlags().Duration("trillian_log_server.queue_timeout", 20*time.Second, "deadline for QueueLeaf calls to the Trillian log server; 0 uses the request deadline")
+	rootCmd.PersistentFlags().Duration("trillian_log_server.rpc_timeout", 20*time.Second, "deadline for read RPCs to the Trillian log server; 0 uses the request deadline")
+	rootCmd.PersistentFlags().Int("trillian_log_server.retry_attempts", 0, "number of times to retry read RPCs that fail with UNAVAILABLE; 0 disables retries")
 
 	rootCmd.PersistentFlags().String("rekor_server.hostname", "rekor.sigstore.dev", "public hostname of instance")
 	rootCmd.PersistentFlags().String("rekor_server.address", "127.0.0.1", "Address to bind to")
-	rootCmd.PersistentFlags().String("rekor_server.signer", "memory", "Rekor signer to use. Current valid options include: [gcpkms, memory]")
+	rootCmd.PersistentFlags().String("rekor_server.signer", "memory", "Rekor signer to use. Current valid options include: [gcpkms, memory, memory-ed25519]")
 
 	rootCmd.PersistentFlags().Uint16("port", 3000, "Port to bind to")
+	rootCmd.PersistentFlags().String("ops_server.address", "", "Address to bind the ops listener (metrics, readiness, pprof, admin API) to")
+	rootCmd.PersistentFlags().Uint16("ops_server.port", 2112, "Port to bind the ops listener to")
+	rootCmd.PersistentFlags().String("rekor_server.unix_socket", "", "path to a Unix domain socket to serve the API on in addition to the TCP listener")
+	rootCmd.PersistentFlags().Duration("rekor_server.keep_alive", 3*time.Minute, "TCP keep-alive period for accepted connections; 0 disables keep-alives")
+	rootCmd.PersistentFlags().Duration("rekor_server.read_timeout", 30*time.Second, "maximum duration for reading an entire request")
+	rootCmd.PersistentFlags().Duration("rekor_server.write_timeout", 30*time.Second, "maximum duration before timing out a response write")
+	rootCmd.PersistentFlags().Duration("rekor_server.max_request_timeout", time.Minute, "upper bound on client-supplied X-Request-Timeout deadlines; 0 applies no bound")
+	rootCmd.PersistentFlags().Int("rekor_server.max_leaf_size", 0, "maximum size in bytes of a canonicalized entry; 0 disables the limit")
+	rootCmd.PersistentFlags().Int("rekor_server.recent_cache_size", 1024, "number of recently queued leaf hashes remembered to short-circuit duplicate submissions; 0 disables the cache")
+	rootCmd.PersistentFlags().StringSlice("rekor_server.remote_fetch.allowed_hosts", nil, "hostnames the server may fetch client-supplied URLs from; empty permits any publicly routable host")
+	rootCmd.PersistentFlags().Int64("rekor_server.remote_fetch.max_size", 33554432, "maximum size in bytes of content fetched from a client-supplied URL; 0 disables the limit")
+	rootCmd.PersistentFlags().Duration("rekor_server.remote_fetch.timeout", 30*time.Second, "maximum time to spend fetching a client-supplied URL; 0 applies no bound beyond the request deadline")
+	rootCmd.PersistentFlags().Duration("rekor_server.idle_timeout", 90*time.Second, "how long an idle keep-alive connection is held open before being closed")
+	rootCmd.PersistentFlags().Bool("rekor_server.http2", false, "serve cleartext HTTP/2 (h2c) on the API listeners so clients can multiplex requests")
+	rootCmd.PersistentFlags().Uint32("rekor_server.http2_max_concurrent_streams", 0, "maximum concurrent HTTP/2 streams per connection; 0 uses the Go default")
+	rootCmd.PersistentFlags().StringSlice("rekor_server.extra_addresses", nil, "additional address:port listeners to serve the API on (e.g. a localhost-only admin interface alongside a public one)")
 
 	rootCmd.PersistentFlags().Bool("enable_retrieve_api", true, "enables Redis-based index API endpoint")
 	rootCmd.PersistentFlags().String("redis_server.address", "127.0.0.1", "Redis server address")
 	rootCmd.PersistentFlags().Uint16("redis_server.port", 6379, "Redis server port")
 
+	rootCmd.PersistentFlags().String("admin_server.token", "", "bearer token required for admin API operations; admin API is disabled if unset")
+	rootCmd.PersistentFlags().Duration("rekor_server.inclusion_wait", 45*time.Second, "maximum time to wait for an entry to be integrated before returning 202 Accepted; 0 waits for the full request deadline")
+	rootCmd.PersistentFlags().String("rekor_server.checkpoint_file", "", "path to persist signed checkpoints; on startup the server verifies log consistency against the last persisted checkpoint")
+	rootCmd.PersistentFlags().Int("rekor_server.checkpoint_history_size", 256, "number of recent signed checkpoints retained in memory for the checkpoint history endpoint; 0 disables retention")
+	rootCmd.PersistentFlags().String("rekor_server.checkpoint_dir", "", "directory to write every signed checkpoint to; lets the checkpoint history endpoint serve sizes beyond the in-memory window")
+	rootCmd.PersistentFlags().StringSlice("rekor_server.witness.pubkey_paths", nil, "paths to PEM-encoded public keys of configured witnesses")
+	rootCmd.PersistentFlags().Int("rekor_server.witness.required_cosignatures", 0, "number of witness cosignatures a checkpoint needs before it is served as current; 0 disables witness policy enforcement")
+	rootCmd.PersistentFlags().StringSlice("rekor_server.witness.urls", nil, "base URLs of witnesses to feed checkpoints to via the built-in feeder")
+	rootCmd.PersistentFlags().Duration("rekor_server.witness.feed_interval", time.Minute, "how often the built-in feeder submits the current checkpoint to the configured witnesses")
+	rootCmd.PersistentFlags().Duration("rekor_server.max_merge_delay", 5*time.Minute, "maximum delay before a queued entry is integrated, advertised in signed inclusion promises on 202 responses")
+	rootCmd.PersistentFlags().StringSlice("rekor_server.type_plugins", nil, "paths to entry type plugin binaries registered at startup; entry operations for their kinds are delegated to the binaries")
+
+	rootCmd.PersistentFlags().Int64("shard_rollover.max_tree_size", 0, "roll over to a new active tree once the current one reaches this many entries; 0 disables size-based rollover")
+	rootCmd.PersistentFlags().Duration("shard_rollover.max_tree_age", 0, "roll over to a new active tree once the current one reaches this age; 0 disables age-based rollover")
+	rootCmd.PersistentFlags().Duration("shard_rollover.check_interval", 5*time.Minute, "how often to check the active tree against the rollover thresholds")
+
+	rootCmd.PersistentFlags().StringSlice("fulcio.root_paths", nil, "paths to PEM-encoded Fulcio root certificates; when set, certificates in entries must chain to one of them")
+
+	rootCmd.PersistentFlags().Float64("quota.per_identity_qps", 0, "sustained entry creations per second allowed per authenticated identity; 0 disables per-identity rate limiting")
+	rootCmd.PersistentFlags().Int("quota.per_identity_burst", 0, "burst of entry creations allowed per authenticated identity; defaults to the sustained rate")
+	rootCmd.PersistentFlags().Float64("quota.read_qps_per_ip", 0, "sustained read requests per second allowed per client IP; 0 disables read throttling")
+	rootCmd.PersistentFlags().Int("quota.read_burst_per_ip", 0, "burst of read requests allowed per client IP; defaults to the sustained rate")
+	rootCmd.PersistentFlags().StringSlice("quota.trusted_proxies", nil, "CIDR ranges of proxies whose X-Forwarded-For headers are trusted when determining client IPs")
+
+	rootCmd.PersistentFlags().Bool("policy.reject_weak_digests", false, "reject entries whose digest algorithm is SHA-1 or MD5")
+	rootCmd.PersistentFlags().Int("policy.min_rsa_key_size", 0, "reject entries containing RSA keys smaller than this many bits; 0 disables the check")
+	rootCmd.PersistentFlags().Bool("policy.reject_expired_certificates", false, "reject entries containing expired X.509 certificates")
+	rootCmd.PersistentFlags().StringSlice("policy.allowed_entry_kinds", nil, "entry kinds this log accepts (e.g. hashedrekord,intoto); empty accepts all kinds")
+	rootCmd.PersistentFlags().StringSlice("policy.allowed_key_algorithms", nil, "key algorithms this log accepts (rsa, ecdsa-p256, ecdsa-p384, ecdsa-p521, ed25519); empty accepts all algorithms")
+	rootCmd.PersistentFlags().String("policy.webhook.url", "", "URL of an external admission webhook POSTed each proposed entry before queueing; empty disables the webhook")
+	rootCmd.PersistentFlags().Duration("policy.webhook.timeout", 5*time.Second, "maximum time to wait for an admission webhook decision")
+	rootCmd.PersistentFlags().Bool("policy.webhook.fail_open", false, "admit entries when the admission webhook cannot be reached; explicit denials are always enforced")
+
+	rootCmd.PersistentFlags().Duration("monitoring.sequencing_poll_interval", 30*time.Second, "how often to poll the latest signed log root to measure sequencing lag")
+	rootCmd.PersistentFlags().Int64("monitoring.sequencing_lag_threshold", 0, "log a warning when more than this many queued leaves have not been integrated; 0 disables the warning")
+	rootCmd.PersistentFlags().Duration("monitoring.max_root_age", 0, "report the server degraded when the latest signed log root is older than this; 0 disables staleness detection")
+
+	rootCmd.PersistentFlags().Int64("migration.shadow_tree_id", 0, "mirror every accepted entry to this Trillian tree as well; the active tree remains authoritative. 0 disables dual-writing")
+	rootCmd.PersistentFlags().String("migration.shadow_log_server.address", "", "Trillian log server address for the shadow tree; empty uses the primary log server connection")
+	rootCmd.PersistentFlags().Uint16("migration.shadow_log_server.port", 8090, "Trillian log server port for the shadow tree")
+
+	rootCmd.PersistentFlags().String("alerting.webhook_url", "", "URL to POST a JSON payload to when log tampering is detected")
+	rootCmd.PersistentFlags().String("alerting.pagerduty_url", "https://events.pagerduty.com/v2/enqueue", "PagerDuty Events API v2 endpoint for tamper alerts")
+	rootCmd.PersistentFlags().String("alerting.pagerduty_routing_key", "", "PagerDuty routing key for tamper alerts; PagerDuty delivery is disabled if unset")
+	rootCmd.PersistentFlags().Int("alerting.exit_code", 0, "exit the process with this code after a tamper alert fires; 0 keeps the process running")
+
 	rootCmd.PersistentFlags().Bool("enable_attestation_storage", false, "enables rich attestation storage")
-	rootCmd.PersistentFlags().String("attestation_storage_bucket", "", "url for attestation storage bucket")
+	rootCmd.PersistentFlags().String("attestation_storage_bucket", "", "url for attestation storage bucket (file://, gs://, s3://, azblob://, or mem://); S3-compatible stores use s3:// with an endpoint query parameter")
 	rootCmd.PersistentFlags().Int("max_attestation_size", 100*1024, "max size for attestation storage, in bytes")
 
 	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
@@ -85,22 +154,6 @@ func init() {
 	}
 
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-
-	log.Logger.Debugf("pprof enabled %v", enablePprof)
-	// Enable pprof
-	if enablePprof {
-		go func() {
-			mux := http.NewServeMux()
-
-			mux.HandleFunc("/debug/pprof/", pprof.Index)
-			mux.HandleFunc("/debug/pprof/{action}", pprof.Index)
-			mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-
-			if err := http.ListenAndServe(":6060", mux); err != nil && err != http.ErrServerClosed {
-				log.Logger.Fatalf("Error when starting or running http server: %v", err)
-			}
-		}()
-	}
 }
 
 // initConfig reads in config file and ENV variables if set.
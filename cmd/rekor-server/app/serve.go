@@ -17,18 +17,24 @@ package app
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
+	"net/http/pprof"
+	"time"
 
 	"github.com/go-openapi/loads"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"sigs.k8s.io/release-utils/version"
 
 	"github.com/sigstore/rekor/pkg/api"
 	"github.com/sigstore/rekor/pkg/generated/restapi"
 	"github.com/sigstore/rekor/pkg/generated/restapi/operations"
 	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/signer"
 	"github.com/sigstore/rekor/pkg/types/alpine"
 	alpine_v001 "github.com/sigstore/rekor/pkg/types/alpine/v0.0.1"
 	hashedrekord "github.com/sigstore/rekor/pkg/types/hashedrekord"
@@ -41,6 +47,7 @@ import (
 	jar_v001 "github.com/sigstore/rekor/pkg/types/jar/v0.0.1"
 	"github.com/sigstore/rekor/pkg/types/rekord"
 	rekord_v001 "github.com/sigstore/rekor/pkg/types/rekord/v0.0.1"
+	rekord_v002 "github.com/sigstore/rekor/pkg/types/rekord/v0.0.2"
 	"github.com/sigstore/rekor/pkg/types/rfc3161"
 	rfc3161_v001 "github.com/sigstore/rekor/pkg/types/rfc3161/v0.0.1"
 	"github.com/sigstore/rekor/pkg/types/rpm"
@@ -63,6 +70,16 @@ var serveCmd = &cobra.Command{
 		// from https://github.com/golang/glog/commit/fca8c8854093a154ff1eb580aae10276ad6b1b5f
 		_ = flag.CommandLine.Parse([]string{})
 
+		// dev mode swaps every external backend for an in-process one: the
+		// Trillian fake, an in-memory search index, and an ephemeral signing
+		// key generated at startup
+		if viper.GetBool("rekor_server.dev") {
+			log.Logger.Warn("dev mode enabled: all log data is in-memory and will be lost on exit")
+			viper.Set("rekor_server.signer", signer.MemoryScheme)
+			viper.Set("enable_retrieve_api", true)
+			viper.Set("trillian_log_server.tlog_id", 0)
+		}
+
 		vi := version.GetVersionInfo()
 		viStr, err := vi.JSONString()
 		if err != nil {
@@ -82,37 +99,106 @@ var serveCmd = &cobra.Command{
 		//TODO: add command line option to print versions supported in binary
 
 		// these trigger loading of package and therefore init() methods to run
-		pluggableTypeMap := map[string]string{
-			rekord.KIND:       rekord_v001.APIVERSION,
-			rpm.KIND:          rpm_v001.APIVERSION,
-			jar.KIND:          jar_v001.APIVERSION,
-			intoto.KIND:       intoto_v001.APIVERSION,
-			rfc3161.KIND:      rfc3161_v001.APIVERSION,
-			alpine.KIND:       alpine_v001.APIVERSION,
-			helm.KIND:         helm_v001.APIVERSION,
-			tuf.KIND:          tuf_v001.APIVERSION,
-			hashedrekord.KIND: hashedrekord_v001.APIVERSION,
+		pluggableTypeMap := map[string][]string{
+			rekord.KIND:       {rekord_v001.APIVERSION, rekord_v002.APIVERSION},
+			rpm.KIND:          {rpm_v001.APIVERSION},
+			jar.KIND:          {jar_v001.APIVERSION},
+			intoto.KIND:       {intoto_v001.APIVERSION},
+			rfc3161.KIND:      {rfc3161_v001.APIVERSION},
+			alpine.KIND:       {alpine_v001.APIVERSION},
+			helm.KIND:         {helm_v001.APIVERSION},
+			tuf.KIND:          {tuf_v001.APIVERSION},
+			hashedrekord.KIND: {hashedrekord_v001.APIVERSION},
 		}
 
-		for k, v := range pluggableTypeMap {
+		for k, versions := range pluggableTypeMap {
 			log.Logger.Infof("Loading support for pluggable type '%v'", k)
-			log.Logger.Infof("Loading version '%v' for pluggable type '%v'", v, k)
+			for _, v := range versions {
+				log.Logger.Infof("Loading version '%v' for pluggable type '%v'", v, k)
+			}
 		}
 
 		server.Host = viper.GetString("rekor_server.address")
 		server.Port = int(viper.GetUint("port"))
 		server.EnabledListeners = []string{"http"}
+		// connection tuning for high-fan-out clients; KeepAlive > 0 enables
+		// TCP keep-alives and CleanupTimeout doubles as the idle timeout
+		server.KeepAlive = viper.GetDuration("rekor_server.keep_alive")
+		server.ReadTimeout = viper.GetDuration("rekor_server.read_timeout")
+		server.WriteTimeout = viper.GetDuration("rekor_server.write_timeout")
+		server.CleanupTimeout = viper.GetDuration("rekor_server.idle_timeout")
+		// optionally serve on a Unix domain socket alongside the TCP listener,
+		// e.g. to expose the API to a local sidecar without opening a port
+		if socketPath := viper.GetString("rekor_server.unix_socket"); socketPath != "" {
+			server.EnabledListeners = append(server.EnabledListeners, "unix")
+			server.SocketPath = socketPath
+		}
 
 		treeID := viper.GetUint("trillian_log_server.tlog_id")
 
-		api.ConfigureAPI(treeID)
-		server.ConfigureAPI()
-
-		http.Handle("/metrics", promhttp.Handler())
+		// operational surfaces are grouped on a dedicated listener so they can
+		// be firewalled separately from the public API; it starts serving
+		// before the backends are up so orchestrators can poll /readyz during
+		// bring-up
+		opsMux := http.NewServeMux()
+		opsMux.Handle("/metrics", promhttp.Handler())
+		opsMux.HandleFunc("/readyz", api.ReadyzHandler)
+		// admin operations only respond if admin_server.token has been configured
+		opsMux.HandleFunc("/admin/v1/freeze", api.FreezeHandler)
+		if enablePprof {
+			opsMux.HandleFunc("/debug/pprof/", pprof.Index)
+			opsMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+			opsMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+			opsMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+			opsMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		}
+		opsAddr := fmt.Sprintf("%s:%d", viper.GetString("ops_server.address"), viper.GetUint("ops_server.port"))
 		go func() {
-			_ = http.ListenAndServe(":2112", nil)
+			if err := http.ListenAndServe(opsAddr, opsMux); err != nil && err != http.ErrServerClosed {
+				log.Logger.Fatalf("serving ops listener on %s: %v", opsAddr, err)
+			}
 		}()
 
+		// retry with backoff instead of exiting if Trillian or the index
+		// backend isn't reachable yet; /readyz reports not-ready until
+		// configuration succeeds
+		backoff := time.Second
+		for {
+			err := api.ConfigureAPI(treeID)
+			if err == nil {
+				break
+			}
+			log.Logger.Errorf("error configuring api, retrying in %v: %v", backoff, err)
+			time.Sleep(backoff)
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+		}
+		server.ConfigureAPI()
+
+		// Go only negotiates HTTP/2 over TLS; since TLS usually terminates at
+		// a load balancer, optionally speak cleartext HTTP/2 (h2c) so clients
+		// can multiplex requests over a single connection
+		if viper.GetBool("rekor_server.http2") {
+			h2s := &http2.Server{
+				MaxConcurrentStreams: uint32(viper.GetUint("rekor_server.http2_max_concurrent_streams")),
+				IdleTimeout:          viper.GetDuration("rekor_server.idle_timeout"),
+			}
+			server.SetHandler(h2c.NewHandler(server.GetHandler(), h2s))
+		}
+
+		// additional TCP listeners share the fully configured handler, so a
+		// single process can serve e.g. localhost-only and public interfaces
+		for _, addr := range viper.GetStringSlice("rekor_server.extra_addresses") {
+			addr := addr
+			go func() {
+				log.Logger.Infof("serving additional listener on %s", addr)
+				if err := http.ListenAndServe(addr, server.GetHandler()); err != nil && err != http.ErrServerClosed {
+					log.Logger.Fatalf("serving on %s: %v", addr, err)
+				}
+			}()
+		}
+
 		if err := server.Serve(); err != nil {
 			log.Logger.Fatal(err)
 		}
@@ -120,5 +206,9 @@ var serveCmd = &cobra.Command{
 }
 
 func init() {
+	serveCmd.Flags().Bool("dev", false, "run with an in-memory Trillian log, in-memory search index, and an ephemeral signing key; no external dependencies are required and nothing is persisted")
+	if err := viper.BindPFlag("rekor_server.dev", serveCmd.Flags().Lookup("dev")); err != nil {
+		log.Logger.Fatal(err)
+	}
 	rootCmd.AddCommand(serveCmd)
 }
@@ -0,0 +1,146 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/mod/sumdb/tlog"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/tiles"
+)
+
+// serveTilesCmd represents the serve-tiles command
+var serveTilesCmd = &cobra.Command{
+	Use:   "serve-tiles",
+	Short: "Serve read requests from an exported tile set instead of Trillian",
+	Long: `Answers get-entry, inclusion proof, and consistency proof requests from a
+tile set previously written by export-tiles. Instances of this command are
+stateless and can be scaled horizontally or kept running through a Trillian
+outage; they serve the log as of the exported checkpoint.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		bucketURL := viper.GetString("bucket")
+		if bucketURL == "" {
+			return errors.New("--bucket must be set, e.g. file:///var/rekor/tiles or gs://my-bucket")
+		}
+		reader, err := tiles.NewReader(ctx, bucketURL)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		log.Logger.Infof("serving tile set at checkpoint size %d", reader.Checkpoint().Size)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/v1/log", func(w http.ResponseWriter, r *http.Request) {
+			checkpoint := reader.Checkpoint()
+			writeTileJSON(w, map[string]interface{}{
+				"rootHash": hex.EncodeToString(checkpoint.Hash),
+				"treeSize": checkpoint.Size,
+			})
+		})
+		mux.HandleFunc("/api/v1/log/entries", func(w http.ResponseWriter, r *http.Request) {
+			index, err := strconv.ParseInt(r.URL.Query().Get("logIndex"), 10, 64)
+			if err != nil {
+				http.Error(w, "logIndex must be a valid integer", http.StatusBadRequest)
+				return
+			}
+			entry, err := reader.GetEntry(r.Context(), index)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			proof, err := reader.InclusionProof(r.Context(), index)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			checkpoint := reader.Checkpoint()
+			writeTileJSON(w, map[string]interface{}{
+				"logIndex": index,
+				"body":     base64.StdEncoding.EncodeToString(entry),
+				"verification": map[string]interface{}{
+					"inclusionProof": map[string]interface{}{
+						"logIndex": index,
+						"rootHash": hex.EncodeToString(checkpoint.Hash),
+						"treeSize": checkpoint.Size,
+						"hashes":   hashStrings(proof),
+					},
+				},
+			})
+		})
+		mux.HandleFunc("/api/v1/log/proof", func(w http.ResponseWriter, r *http.Request) {
+			firstSize, err := strconv.ParseInt(r.URL.Query().Get("firstSize"), 10, 64)
+			if err != nil {
+				http.Error(w, "firstSize must be a valid integer", http.StatusBadRequest)
+				return
+			}
+			proof, err := reader.ConsistencyProof(r.Context(), firstSize)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			checkpoint := reader.Checkpoint()
+			writeTileJSON(w, map[string]interface{}{
+				"rootHash": hex.EncodeToString(checkpoint.Hash),
+				"hashes":   hashStrings(proof),
+			})
+		})
+
+		addr := fmt.Sprintf("%s:%d", viper.GetString("rekor_server.address"), viper.GetUint("port"))
+		log.Logger.Infof("tile read server listening on %s", addr)
+		return http.ListenAndServe(addr, mux)
+	},
+}
+
+func writeTileJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Logger.Errorf("writing response: %v", err)
+	}
+}
+
+func hashStrings(hashes []tlog.Hash) []string {
+	out := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		h := h
+		out = append(out, hex.EncodeToString(h[:]))
+	}
+	return out
+}
+
+func init() {
+	serveTilesCmd.Flags().String("bucket", "", "Bucket URL of the tile set to serve")
+	rootCmd.AddCommand(serveTilesCmd)
+}
@@ -0,0 +1,218 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/trillian"
+	radix "github.com/mediocregopher/radix/v4"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
+)
+
+// indexValidationReport is the machine-readable result of cross-checking the
+// search index against the log.
+type indexValidationReport struct {
+	TreeID          int64                 `json:"treeID"`
+	TreeSize        int64                 `json:"treeSize"`
+	KeysScanned     int64                 `json:"keysScanned"`
+	ValuesScanned   int64                 `json:"valuesScanned"`
+	LeavesScanned   int64                 `json:"leavesScanned"`
+	OrphanedValues  []orphanedIndexValue  `json:"orphanedValues"`
+	MissingKeys     []missingIndexKey     `json:"missingKeys"`
+	UnparseableRefs []unparseableIndexRef `json:"unparseableValues"`
+}
+
+// orphanedIndexValue is an index value that references an entry not present in
+// the log.
+type orphanedIndexValue struct {
+	Key     string `json:"key"`
+	EntryID string `json:"entryID"`
+}
+
+// missingIndexKey is an index key a log entry should be findable under but is
+// not registered in the index.
+type missingIndexKey struct {
+	Key       string `json:"key"`
+	EntryID   string `json:"entryID"`
+	LeafIndex int64  `json:"leafIndex"`
+}
+
+// unparseableIndexRef is an index value that is neither a bare UUID nor a
+// tree-qualified entry ID.
+type unparseableIndexRef struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// validateIndexCmd cross-checks the search index against the log in both
+// directions: every indexed entry ID must correspond to a leaf in the tree,
+// and every key derivable from a leaf must be present in the index.
+var validateIndexCmd = &cobra.Command{
+	Use:   "validate-index",
+	Short: "Cross-check the search index against the log contents",
+	Long: `Scans every key in the Redis search index and every leaf in the Trillian
+tree, then reports values in the index that reference entries missing from the
+log as well as index keys derivable from log entries that are absent from the
+index. The report is emitted as JSON, making it suitable for automated checks
+after a migration. The command exits non-zero when discrepancies are found.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// these are bound here so that they are not overwritten by other commands
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			log.Logger.Fatal("Error initializing cmd line args: ", err)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.ConfigureLogger(viper.GetString("log_type"))
+		ctx := context.Background()
+
+		conn, err := grpc.Dial(viper.GetString("trillian_address"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("dialing log server: %w", err)
+		}
+		defer conn.Close()
+		logClient := trillian.NewTrillianLogClient(conn)
+		treeID := viper.GetInt64("tree_id")
+
+		redisClient, err := (radix.PoolConfig{}).New(ctx, "tcp",
+			fmt.Sprintf("%v:%v", viper.GetString("redis_server.address"), viper.GetUint64("redis_server.port")))
+		if err != nil {
+			return fmt.Errorf("connecting to redis instance: %w", err)
+		}
+		defer redisClient.Close()
+
+		root, err := latestRoot(ctx, logClient, treeID)
+		if err != nil {
+			return fmt.Errorf("getting published root: %w", err)
+		}
+		treeSize := int64(root.TreeSize)
+
+		report := indexValidationReport{
+			TreeID:          treeID,
+			TreeSize:        treeSize,
+			OrphanedValues:  []orphanedIndexValue{},
+			MissingKeys:     []missingIndexKey{},
+			UnparseableRefs: []unparseableIndexRef{},
+		}
+
+		// walk the tree once: collect the set of leaf UUIDs for the forward
+		// check, and verify each leaf's derivable keys against the index
+		batchSize := viper.GetInt64("batch_size")
+		leafUUIDs := make(map[string]struct{}, treeSize)
+		for batchStart := int64(0); batchStart < treeSize; batchStart += batchSize {
+			count := batchSize
+			if remaining := treeSize - batchStart; remaining < count {
+				count = remaining
+			}
+			resp, err := logClient.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+				LogId:      treeID,
+				StartIndex: batchStart,
+				Count:      count,
+			})
+			if err != nil {
+				return fmt.Errorf("getting leaves [%d, %d): %w", batchStart, batchStart+count, err)
+			}
+			for _, leaf := range resp.Leaves {
+				report.LeavesScanned++
+				leafUUIDs[hex.EncodeToString(leaf.MerkleLeafHash)] = struct{}{}
+				keys, entryID, err := indexKeysForLeaf(treeID, leaf)
+				if err != nil {
+					log.Logger.Errorf("leaf at index %d cannot be parsed: %v", leaf.LeafIndex, err)
+					continue
+				}
+				for _, key := range keys {
+					key = strings.ToLower(key)
+					var values []string
+					if err := redisClient.Do(ctx, radix.Cmd(&values, "LRANGE", key, "0", "-1")); err != nil {
+						return fmt.Errorf("looking up index key %q: %w", key, err)
+					}
+					if !containsEntry(values, entryID) {
+						report.MissingKeys = append(report.MissingKeys, missingIndexKey{
+							Key:       key,
+							EntryID:   entryID,
+							LeafIndex: leaf.LeafIndex,
+						})
+					}
+				}
+			}
+		}
+
+		// walk the index: every stored value must reference a leaf in the tree
+		scanner := (radix.ScannerConfig{}).New(redisClient)
+		var key string
+		for scanner.Next(ctx, &key) {
+			report.KeysScanned++
+			var values []string
+			if err := redisClient.Do(ctx, radix.Cmd(&values, "LRANGE", key, "0", "-1")); err != nil {
+				return fmt.Errorf("reading index key %q: %w", key, err)
+			}
+			for _, value := range values {
+				report.ValuesScanned++
+				uuid, err := sharding.GetUUIDFromIDString(value)
+				if err != nil {
+					report.UnparseableRefs = append(report.UnparseableRefs, unparseableIndexRef{Key: key, Value: value})
+					continue
+				}
+				if _, ok := leafUUIDs[strings.ToLower(uuid)]; !ok {
+					report.OrphanedValues = append(report.OrphanedValues, orphanedIndexValue{Key: key, EntryID: value})
+				}
+			}
+		}
+		if err := scanner.Close(); err != nil {
+			return fmt.Errorf("scanning index keys: %w", err)
+		}
+
+		out := os.Stdout
+		if path := viper.GetString("output"); path != "" && path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("creating report file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+
+		if discrepancies := len(report.OrphanedValues) + len(report.MissingKeys) + len(report.UnparseableRefs); discrepancies > 0 {
+			return fmt.Errorf("found %d discrepancies between the index and the log", discrepancies)
+		}
+		log.Logger.Infof("index is consistent with the log: %d leaves, %d index keys checked", report.LeavesScanned, report.KeysScanned)
+		return nil
+	},
+}
+
+func init() {
+	validateIndexCmd.Flags().String("trillian_address", "127.0.0.1:8090", "Trillian log server address:port")
+	validateIndexCmd.Flags().Int64("tree_id", 0, "Trillian tree ID to validate against")
+	validateIndexCmd.Flags().Int64("batch_size", 256, "Number of leaves to fetch per read from the log")
+	validateIndexCmd.Flags().String("output", "-", "path to write the JSON report to; - writes to stdout")
+	rootCmd.AddCommand(validateIndexCmd)
+}
@@ -16,6 +16,7 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/x509"
@@ -34,6 +35,7 @@ import (
 	"github.com/spf13/viper"
 	"gocloud.dev/blob"
 
+	"github.com/sigstore/rekor/pkg/alert"
 	"github.com/sigstore/rekor/pkg/client"
 	genclient "github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/rekor/pkg/log"
@@ -99,6 +101,7 @@ var watchCmd = &cobra.Command{
 		defer bucket.Close()
 		tick := time.NewTicker(interval)
 		var last *SignedAndUnsignedLogRoot
+		alerter := alert.NewFromConfig("rekor-server watch")
 
 		for {
 			<-tick.C
@@ -114,6 +117,21 @@ var watchCmd = &cobra.Command{
 					last.VerifiedLogRoot.Size, lr.VerifiedLogRoot.Size)
 				// If it's the same, it shouldn't have changed but we'll still upload anyway
 				// in case that failed.
+				if !bytes.Equal(last.VerifiedLogRoot.Hash, lr.VerifiedLogRoot.Hash) {
+					alerter.TamperDetected(ctx, "log root hash changed without the tree growing", map[string]string{
+						"treeSize": fmt.Sprintf("%d", lr.VerifiedLogRoot.Size),
+						"lastRoot": fmt.Sprintf("%x", last.VerifiedLogRoot.Hash),
+						"newRoot":  fmt.Sprintf("%x", lr.VerifiedLogRoot.Hash),
+					})
+					continue
+				}
+			}
+			if last != nil && lr.VerifiedLogRoot.Size < last.VerifiedLogRoot.Size {
+				alerter.TamperDetected(ctx, "log shrank between checks", map[string]string{
+					"lastSize": fmt.Sprintf("%d", last.VerifiedLogRoot.Size),
+					"newSize":  fmt.Sprintf("%d", lr.VerifiedLogRoot.Size),
+				})
+				continue
 			}
 
 			if err := uploadToBlobStorage(ctx, bucket, lr); err != nil {
@@ -0,0 +1,138 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alert delivers tamper-detection alerts to operator-configured
+// destinations. Detecting an inconsistency in a transparency log is an
+// incident, not a log line, so the monitor and audit paths report through
+// here rather than only logging.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+const deliveryTimeout = 30 * time.Second
+
+// Alerter fans a tamper alert out to each configured destination.
+type Alerter struct {
+	webhookURL          string
+	pagerdutyURL        string
+	pagerdutyRoutingKey string
+	exitCode            int
+	source              string
+	client              *http.Client
+}
+
+// NewFromConfig builds an Alerter from the alerting.* viper keys. All
+// destinations are optional; with none configured, alerts are only logged.
+func NewFromConfig(source string) *Alerter {
+	return &Alerter{
+		webhookURL:          viper.GetString("alerting.webhook_url"),
+		pagerdutyURL:        viper.GetString("alerting.pagerduty_url"),
+		pagerdutyRoutingKey: viper.GetString("alerting.pagerduty_routing_key"),
+		exitCode:            viper.GetInt("alerting.exit_code"),
+		source:              source,
+		client:              &http.Client{Timeout: deliveryTimeout},
+	}
+}
+
+// webhookPayload is posted as-is to the generic webhook destination.
+type webhookPayload struct {
+	Source  string            `json:"source"`
+	Summary string            `json:"summary"`
+	Details map[string]string `json:"details,omitempty"`
+	Time    string            `json:"time"`
+}
+
+// pagerdutyEvent follows the PagerDuty Events API v2 trigger schema.
+type pagerdutyEvent struct {
+	RoutingKey  string `json:"routing_key"`
+	EventAction string `json:"event_action"`
+	Payload     struct {
+		Summary       string            `json:"summary"`
+		Source        string            `json:"source"`
+		Severity      string            `json:"severity"`
+		CustomDetails map[string]string `json:"custom_details,omitempty"`
+	} `json:"payload"`
+}
+
+// TamperDetected reports a detected log inconsistency to every configured
+// destination and then, if alerting.exit_code is set, terminates the process
+// with that code so supervisors can distinguish tamper detection from other
+// failures. Delivery errors are logged but do not mask the alert.
+func (a *Alerter) TamperDetected(ctx context.Context, summary string, details map[string]string) {
+	log.Logger.Errorf("TAMPER DETECTED: %s %v", summary, details)
+
+	if a.webhookURL != "" {
+		payload := webhookPayload{
+			Source:  a.source,
+			Summary: summary,
+			Details: details,
+			Time:    time.Now().UTC().Format(time.RFC3339),
+		}
+		if err := a.post(ctx, a.webhookURL, payload); err != nil {
+			log.Logger.Errorf("delivering webhook alert: %v", err)
+		}
+	}
+
+	if a.pagerdutyRoutingKey != "" {
+		event := pagerdutyEvent{
+			RoutingKey:  a.pagerdutyRoutingKey,
+			EventAction: "trigger",
+		}
+		event.Payload.Summary = summary
+		event.Payload.Source = a.source
+		event.Payload.Severity = "critical"
+		event.Payload.CustomDetails = details
+		if err := a.post(ctx, a.pagerdutyURL, event); err != nil {
+			log.Logger.Errorf("delivering PagerDuty alert: %v", err)
+		}
+	}
+
+	if a.exitCode != 0 {
+		os.Exit(a.exitCode)
+	}
+}
+
+func (a *Alerter) post(ctx context.Context, url string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert destination %s returned %s", url, resp.Status)
+	}
+	return nil
+}
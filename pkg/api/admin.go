@@ -0,0 +1,138 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+var readOnly struct {
+	sync.RWMutex
+	enabled bool
+}
+
+// ReadOnly reports whether the server has been flipped to read-only mode,
+// e.g. after the active tree was frozen via the admin API.
+func ReadOnly() bool {
+	readOnly.RLock()
+	defer readOnly.RUnlock()
+	return readOnly.enabled
+}
+
+func setReadOnly(enabled bool) {
+	readOnly.Lock()
+	defer readOnly.Unlock()
+	readOnly.enabled = enabled
+}
+
+// checkAdminToken validates the bearer token on an admin request against the
+// configured admin_server.token; admin operations are disabled entirely when
+// no token has been configured.
+func checkAdminToken(w http.ResponseWriter, r *http.Request) bool {
+	token := viper.GetString("admin_server.token")
+	if token == "" {
+		http.Error(w, "admin API is not enabled", http.StatusNotFound)
+		return false
+	}
+	presented := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// FreezeHandler handles POST /admin/v1/freeze on the admin listener. It sets
+// the active tree state to FROZEN via the Trillian admin client, flips the
+// server to read-only so no further entries are accepted, and returns a final
+// signed checkpoint over the frozen tree.
+func FreezeHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkAdminToken(w, r) {
+		return
+	}
+	ctx := r.Context()
+
+	if _, err := api.adminClient.UpdateTree(ctx, &trillian.UpdateTreeRequest{
+		Tree: &trillian.Tree{
+			TreeId:    api.logID,
+			TreeState: trillian.TreeState_FROZEN,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"tree_state"},
+		},
+	}); err != nil {
+		log.RequestIDLogger(r).Errorf("error freezing tree %d: %v", api.logID, err)
+		http.Error(w, "unable to freeze tree", http.StatusInternalServerError)
+		return
+	}
+	setReadOnly(true)
+	log.RequestIDLogger(r).Infof("tree %d frozen; server is now read-only", api.logID)
+
+	// emit a final checkpoint over the frozen tree
+	tc := NewTrillianClient(ctx)
+	root, err := tc.root()
+	if err != nil {
+		http.Error(w, "unable to fetch latest root for frozen tree", http.StatusInternalServerError)
+		return
+	}
+	sth, err := util.CreateSignedCheckpoint(util.Checkpoint{
+		Origin: "Rekor",
+		Size:   root.TreeSize,
+		Hash:   root.RootHash,
+	})
+	if err != nil {
+		http.Error(w, "unable to create checkpoint", http.StatusInternalServerError)
+		return
+	}
+	sth.SetTimestamp(uint64(time.Now().UnixNano()))
+	if _, err := sth.Sign(viper.GetString("rekor_server.hostname"), api.signer, options.WithContext(ctx)); err != nil {
+		http.Error(w, "unable to sign checkpoint", http.StatusInternalServerError)
+		return
+	}
+	scBytes, err := sth.SignedNote.MarshalText()
+	if err != nil {
+		http.Error(w, "unable to marshal checkpoint", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"treeID":          fmt.Sprintf("%d", api.logID),
+		"treeSize":        root.TreeSize,
+		"finalCheckpoint": string(scBytes),
+	}); err != nil {
+		log.RequestIDLogger(r).Error(err)
+	}
+}
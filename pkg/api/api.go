@@ -21,6 +21,8 @@ import (
 	"crypto/x509"
 	"encoding/hex"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/google/trillian"
@@ -29,10 +31,15 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/sigstore/rekor/pkg/alert"
+	"github.com/sigstore/rekor/pkg/fakes"
 	"github.com/sigstore/rekor/pkg/log"
+	x509pki "github.com/sigstore/rekor/pkg/pki/x509"
 	"github.com/sigstore/rekor/pkg/sharding"
 	"github.com/sigstore/rekor/pkg/signer"
 	"github.com/sigstore/rekor/pkg/storage"
+	"github.com/sigstore/rekor/pkg/types/plugin"
+	"github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/options"
@@ -44,33 +51,80 @@ func dial(ctx context.Context, rpcServer string) (*grpc.ClientConn, error) {
 
 	// Set up and test connection to rpc server
 	creds := insecure.NewCredentials()
-	conn, err := grpc.DialContext(ctx, rpcServer, grpc.WithTransportCredentials(creds))
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds), grpc.WithBlock()}
+	// large leaves and proof responses can exceed the default 4MB gRPC limit
+	callOpts := []grpc.CallOption{}
+	if size := viper.GetInt("trillian_log_server.grpc_max_recv_msg_size"); size > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(size))
+	}
+	if size := viper.GetInt("trillian_log_server.grpc_max_send_msg_size"); size > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(size))
+	}
+	if len(callOpts) > 0 {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(clientInterceptors()...))
+	conn, err := grpc.DialContext(ctx, rpcServer, dialOpts...)
 	if err != nil {
-		log.Logger.Fatalf("Failed to connect to RPC server:", err)
+		return nil, fmt.Errorf("failed to connect to RPC server: %w", err)
 	}
 	return conn, nil
 }
 
 type API struct {
-	logClient  trillian.TrillianLogClient
-	logID      int64
-	logRanges  sharding.LogRanges
-	pubkey     string // PEM encoded public key
-	pubkeyHash string // SHA256 hash of DER-encoded public key
-	signer     signature.Signer
+	logClient trillian.TrillianLogClient
+	// readClient serves read RPCs; it points at the configured read replica,
+	// or at logClient when no replica is configured
+	readClient  trillian.TrillianLogClient
+	adminClient trillian.TrillianAdminClient
+	logID       int64
+	logRanges   sharding.LogRanges
+	pubkey      string // PEM encoded public key
+	pubkeyHash  string // SHA256 hash of DER-encoded public key
+	signer      signature.Signer
 }
 
 func NewAPI(treeID uint) (*API, error) {
-	logRPCServer := fmt.Sprintf("%s:%d",
-		viper.GetString("trillian_log_server.address"),
-		viper.GetUint("trillian_log_server.port"))
 	ctx := context.Background()
-	tConn, err := dial(ctx, logRPCServer)
-	if err != nil {
-		return nil, fmt.Errorf("dial: %w", err)
+
+	var logClient trillian.TrillianLogClient
+	var readClient trillian.TrillianLogClient
+	var logAdminClient trillian.TrillianAdminClient
+	if viper.GetBool("rekor_server.dev") {
+		// dev mode runs against an in-process Trillian fake instead of a real
+		// log server; nothing is persisted
+		fake := fakes.NewTrillian(treeHasher())
+		logClient = fake.LogClient()
+		logAdminClient = fake.AdminClient()
+		readClient = logClient
+	} else {
+		logRPCServer := fmt.Sprintf("%s:%d",
+			viper.GetString("trillian_log_server.address"),
+			viper.GetUint("trillian_log_server.port"))
+		tConn, err := dial(ctx, logRPCServer)
+		if err != nil {
+			return nil, fmt.Errorf("dial: %w", err)
+		}
+		logAdminClient = trillian.NewTrillianAdminClient(tConn)
+		logClient = trillian.NewTrillianLogClient(tConn)
+		readClient = logClient
+		if replicaAddr := viper.GetString("trillian_log_server.replica_address"); replicaAddr != "" {
+			replicaRPCServer := fmt.Sprintf("%s:%d",
+				replicaAddr,
+				viper.GetUint("trillian_log_server.replica_port"))
+			rConn, err := dial(ctx, replicaRPCServer)
+			if err != nil {
+				return nil, fmt.Errorf("dial replica: %w", err)
+			}
+			readClient = trillian.NewTrillianLogClient(rConn)
+			log.Logger.Infof("Routing read RPCs to Trillian replica at %v", replicaRPCServer)
+		}
+	}
+
+	// fail fast on a bad hash algorithm rather than serving unverifiable proofs
+	if _, err := util.LogHasher(viper.GetString("trillian_log_server.hash_algorithm")); err != nil {
+		return nil, fmt.Errorf("configuring tree hasher: %w", err)
 	}
-	logAdminClient := trillian.NewTrillianAdminClient(tConn)
-	logClient := trillian.NewTrillianLogClient(tConn)
 
 	shardingConfig := viper.GetString("trillian_log_server.sharding_config")
 	ranges, err := sharding.NewLogRanges(ctx, logClient, shardingConfig, treeID)
@@ -108,9 +162,11 @@ func NewAPI(treeID uint) (*API, error) {
 
 	return &API{
 		// Transparency Log Stuff
-		logClient: logClient,
-		logID:     tid,
-		logRanges: ranges,
+		logClient:   logClient,
+		readClient:  readClient,
+		adminClient: logAdminClient,
+		logID:       tid,
+		logRanges:   ranges,
 		// Signing/verifying fields
 		pubkey:     string(pubkey),
 		pubkeyHash: hex.EncodeToString(pubkeyHashBytes[:]),
@@ -119,30 +175,119 @@ func NewAPI(treeID uint) (*API, error) {
 }
 
 var (
-	api           *API
-	redisClient   radix.Client
+	apiHolder struct {
+		sync.RWMutex
+		current *API
+	}
+	indexClient   indexStorage
 	storageClient storage.AttestationStorage
+	ready         struct {
+		sync.RWMutex
+		configured bool
+	}
 )
 
-func ConfigureAPI(treeID uint) {
+// currentAPI returns the configured API. The shard rollover goroutine swaps
+// in a new value when the active tree changes, so callers must take a single
+// snapshot per request rather than re-reading the global.
+func currentAPI() *API {
+	apiHolder.RLock()
+	defer apiHolder.RUnlock()
+	return apiHolder.current
+}
+
+func setAPI(a *API) {
+	apiHolder.Lock()
+	defer apiHolder.Unlock()
+	apiHolder.current = a
+}
+
+// Ready reports whether the API has been successfully configured against its
+// backends; until then the server should report not-ready via /readyz.
+func Ready() bool {
+	ready.RLock()
+	defer ready.RUnlock()
+	return ready.configured
+}
+
+// ReadyzHandler reports 200 once the API has been configured against its
+// backends, and 503 beforehand so orchestrators hold off routing traffic. A
+// configured but stale log root also reports 503, since a log that stopped
+// advancing should be taken out of rotation.
+func ReadyzHandler(w http.ResponseWriter, _ *http.Request) {
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	if rootStale() {
+		http.Error(w, "degraded: latest signed log root is stale", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+func ConfigureAPI(treeID uint) error {
 	cfg := radix.PoolConfig{}
-	var err error
 
-	api, err = NewAPI(treeID)
+	api, err := NewAPI(treeID)
 	if err != nil {
-		log.Logger.Panic(err)
+		return fmt.Errorf("configuring api: %w", err)
 	}
-	if viper.GetBool("enable_retrieve_api") {
-		redisClient, err = cfg.New(context.Background(), "tcp", fmt.Sprintf("%v:%v", viper.GetString("redis_server.address"), viper.GetUint64("redis_server.port")))
+	setAPI(api)
+	// refuse to serve if the log shrank or forked since we last signed a checkpoint
+	if err := verifyPersistedCheckpoint(context.Background()); err != nil {
+		alert.NewFromConfig("rekor-server").TamperDetected(context.Background(), "startup checkpoint consistency check failed",
+			map[string]string{"treeID": fmt.Sprintf("%d", api.logID), "error": err.Error()})
+		return fmt.Errorf("verifying persisted checkpoint: %w", err)
+	}
+	if viper.GetBool("rekor_server.dev") {
+		indexClient = newMemoryIndex()
+	} else if viper.GetBool("enable_retrieve_api") {
+		redisClient, err := cfg.New(context.Background(), "tcp", fmt.Sprintf("%v:%v", viper.GetString("redis_server.address"), viper.GetUint64("redis_server.port")))
 		if err != nil {
-			log.Logger.Panic("failure connecting to redis instance: ", err)
+			return fmt.Errorf("connecting to redis instance: %w", err)
 		}
+		indexClient = redisIndex{client: redisClient}
 	}
 
 	if viper.GetBool("enable_attestation_storage") {
 		storageClient, err = storage.NewAttestationStorage()
 		if err != nil {
-			log.Logger.Panic(err)
+			return fmt.Errorf("configuring attestation storage: %w", err)
+		}
+	}
+
+	if pluginPaths := viper.GetStringSlice("rekor_server.type_plugins"); len(pluginPaths) > 0 {
+		if err := plugin.RegisterPlugins(context.Background(), pluginPaths); err != nil {
+			return fmt.Errorf("registering type plugins: %w", err)
+		}
+	}
+
+	if err := configureWitnessPolicy(); err != nil {
+		return fmt.Errorf("configuring witness policy: %w", err)
+	}
+	startWitnessFeeder(context.Background())
+
+	if rootPaths := viper.GetStringSlice("fulcio.root_paths"); len(rootPaths) > 0 {
+		pool, err := x509pki.LoadFulcioRoots(rootPaths)
+		if err != nil {
+			return fmt.Errorf("loading Fulcio roots: %w", err)
 		}
+		x509pki.SetFulcioRoots(pool)
 	}
+
+	if err := configureShadowLog(context.Background()); err != nil {
+		return fmt.Errorf("configuring dual-write migration: %w", err)
+	}
+
+	if shardRolloverEnabled() {
+		go shardRolloverLoop(context.Background())
+	}
+	go sequencingLagLoop(context.Background())
+
+	ready.Lock()
+	ready.configured = true
+	ready.Unlock()
+	return nil
 }
@@ -0,0 +1,155 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+const (
+	// how long to keep polling for integration before giving up on notifying
+	integrationNotifyTimeout = 10 * time.Minute
+	integrationPollInterval  = time.Second
+	// how many integration pollers may run at once; unauthenticated clients
+	// must not be able to accumulate an unbounded number of goroutines
+	maxInflightNotifications = 100
+)
+
+// notifySlots bounds the number of concurrent integration pollers
+var notifySlots = make(chan struct{}, maxInflightNotifications)
+
+// notifyClient refuses connections to loopback, private, and link-local
+// addresses; the callback target is caller-controlled input.
+var notifyClient = util.RestrictedClient(30 * time.Second)
+
+// validateCallbackURL ensures a caller-supplied callback target is a usable
+// http(s) URL before we accept the entry for async processing; loopback,
+// private, and link-local destinations are refused at dial time by
+// notifyClient when the notification is delivered.
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("parsing callback URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("callback URL scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("callback URL must include a host")
+	}
+	return nil
+}
+
+// startIntegrationNotifier spawns notifyOnIntegration if an in-flight slot is
+// available, and drops the notification otherwise; a skipped callback only
+// loses the notification, the entry itself is already queued.
+func startIntegrationNotifier(r *http.Request, callbackURL string, leafHash []byte, uuid string) {
+	select {
+	case notifySlots <- struct{}{}:
+		go func() {
+			defer func() { <-notifySlots }()
+			notifyOnIntegration(callbackURL, leafHash, uuid)
+		}()
+	default:
+		log.RequestIDLogger(r).Warnf("in-flight notification limit (%d) reached; skipping callback for %s", maxInflightNotifications, uuid)
+	}
+}
+
+// notifyOnIntegration polls the log until the leaf with the given merkle hash
+// has been integrated, then POSTs a signed notification containing the full
+// log entry (with inclusion proof and SET) to the caller-supplied callback URL.
+func notifyOnIntegration(callbackURL string, leafHash []byte, uuid string) {
+	api := currentAPI()
+	ctx, cancel := context.WithTimeout(context.Background(), integrationNotifyTimeout)
+	defer cancel()
+
+	tc := NewTrillianClient(ctx)
+	for {
+		resp := tc.getLeafAndProofByHash(leafHash)
+		if resp.status == codes.OK && resp.getLeafAndProofResult != nil && resp.getLeafAndProofResult.Leaf != nil {
+			result := resp.getLeafAndProofResult
+			logEntry, err := logEntryFromLeaf(ctx, api.signer, tc, result.Leaf, result.SignedLogRoot, result.Proof, api.logRanges.ActiveTreeID(), api.logRanges)
+			if err != nil {
+				log.Logger.Errorf("error building notification for %s: %v", uuid, err)
+				return
+			}
+			if err := postNotification(ctx, callbackURL, logEntry); err != nil {
+				log.Logger.Errorf("error delivering notification for %s to %s: %v", uuid, callbackURL, err)
+			}
+			return
+		}
+		if resp.status != codes.NotFound && resp.err != nil && ctx.Err() != nil {
+			log.Logger.Errorf("giving up waiting for integration of %s: %v", uuid, resp.err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			log.Logger.Errorf("giving up waiting for integration of %s: %v", uuid, ctx.Err())
+			return
+		case <-time.After(integrationPollInterval):
+		}
+	}
+}
+
+// postNotification signs the canonicalized notification body with the log's
+// signing key and delivers it to the callback URL; the signature is carried in
+// the X-Rekor-Signature header so receivers can authenticate the payload.
+func postNotification(ctx context.Context, callbackURL string, payload interface{}) error {
+	api := currentAPI()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	canonicalized, err := canonical.Transform(body)
+	if err != nil {
+		return err
+	}
+	sig, err := api.signer.SignMessage(bytes.NewReader(canonicalized), options.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("signing notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(canonicalized))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rekor-Signature", base64.StdEncoding.EncodeToString(sig))
+
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
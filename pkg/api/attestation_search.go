@@ -0,0 +1,157 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/generated/restapi/operations/entries"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
+)
+
+// attestationSearchResult is one entry returned from an attestation subject
+// search; the attestation bytes are base64-encoded in the JSON response.
+type attestationSearchResult struct {
+	EntryUUID   string `json:"entryUUID"`
+	LogIndex    int64  `json:"logIndex"`
+	Attestation []byte `json:"attestation"`
+}
+
+// SearchAttestationsHandler serves GET /api/v1/attestations?subjectDigest=...:
+// it resolves the digest through the search index, then returns only those
+// entries whose stored attestation is an in-toto statement naming the digest
+// as a subject. This sits outside the swagger spec alongside the other
+// index-backed lookups.
+func SearchAttestationsHandler(w http.ResponseWriter, r *http.Request) {
+	if indexClient == nil {
+		http.Error(w, "Search Index API not enabled in this Rekor instance", http.StatusNotImplemented)
+		return
+	}
+	if !viper.GetBool("enable_attestation_storage") {
+		http.Error(w, "Attestation storage not enabled in this Rekor instance", http.StatusNotImplemented)
+		return
+	}
+
+	subjectDigest := r.URL.Query().Get("subjectDigest")
+	if subjectDigest == "" {
+		http.Error(w, "subjectDigest query parameter must be specified", http.StatusBadRequest)
+		return
+	}
+	algorithm, digest, found := strings.Cut(strings.ToLower(subjectDigest), ":")
+	if !found {
+		algorithm, digest = "sha256", algorithm
+	}
+	if _, err := hex.DecodeString(digest); err != nil || digest == "" {
+		http.Error(w, "malformed subject digest", http.StatusBadRequest)
+		return
+	}
+
+	uuids, err := indexClient.lookup(r.Context(), algorithm+":"+digest)
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error looking up index entries: %v", err)
+		http.Error(w, redisUnexpectedResult, http.StatusInternalServerError)
+		return
+	}
+
+	results := []attestationSearchResult{}
+	for _, id := range uuids {
+		logEntry, err := lookupLogEntryByID(r, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			log.RequestIDLogger(r).Errorf("error retrieving entry %v: %v", id, err)
+			http.Error(w, trillianUnexpectedResult, http.StatusInternalServerError)
+			return
+		}
+		for entryID, anon := range logEntry {
+			if anon.Attestation == nil || len(anon.Attestation.Data) == 0 {
+				continue
+			}
+			if !statementHasSubject(anon.Attestation.Data, algorithm, digest) {
+				continue
+			}
+			results = append(results, attestationSearchResult{
+				EntryUUID:   entryID,
+				LogIndex:    swag.Int64Value(anon.LogIndex),
+				Attestation: anon.Attestation.Data,
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.RequestIDLogger(r).Errorf("error writing attestation search response: %v", err)
+	}
+}
+
+// lookupLogEntryByID resolves a search index value, which may be a bare UUID
+// or a tree ID-qualified entry ID, to the log entry it names.
+func lookupLogEntryByID(r *http.Request, id string) (models.LogEntry, error) {
+	api := currentAPI()
+	uuid, err := sharding.GetUUIDFromIDString(id)
+	if err != nil {
+		return nil, err
+	}
+	params := entries.GetLogEntryByUUIDParams{HTTPRequest: r, EntryUUID: id}
+
+	if tidString, err := sharding.GetTreeIDFromIDString(id); err == nil {
+		tid, err := strconv.ParseInt(tidString, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		return RetrieveUUID(params, uuid, tid)
+	}
+
+	trees := []sharding.LogRange{{TreeID: api.logRanges.ActiveTreeID()}}
+	trees = append(trees, api.logRanges.GetInactive()...)
+	for _, t := range trees {
+		logEntry, err := RetrieveUUID(params, uuid, t.TreeID)
+		if err != nil {
+			continue
+		}
+		return logEntry, nil
+	}
+	return nil, ErrNotFound
+}
+
+// statementHasSubject reports whether the attestation parses as an in-toto
+// statement with a subject carrying the given digest.
+func statementHasSubject(attestation []byte, algorithm, digest string) bool {
+	statement := in_toto.Statement{}
+	if err := json.Unmarshal(attestation, &statement); err != nil {
+		return false
+	}
+	for _, subject := range statement.Subject {
+		for alg, value := range subject.Digest {
+			if strings.EqualFold(alg, algorithm) && strings.EqualFold(value, digest) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// checkpointHistory retains recent signed checkpoints keyed by tree ID and
+// size, so witnesses and monitors that missed an interval can fetch the exact
+// checkpoint they need for a consistency chain. A bounded window is kept in
+// memory; when a checkpoint directory is configured, every checkpoint is also
+// written through to disk and older ones are served from there.
+type checkpointHistory struct {
+	sync.Mutex
+	entries map[string]string
+	order   *list.List
+}
+
+var checkpointStore = &checkpointHistory{
+	entries: make(map[string]string),
+	order:   list.New(),
+}
+
+func (c *checkpointHistory) capacity() int {
+	return viper.GetInt("rekor_server.checkpoint_history_size")
+}
+
+func checkpointDir() string {
+	return viper.GetString("rekor_server.checkpoint_dir")
+}
+
+func checkpointKey(treeID int64, size uint64) string {
+	return fmt.Sprintf("%d-%d", treeID, size)
+}
+
+// record retains one signed checkpoint, evicting the oldest retained sizes
+// once the configured capacity is exceeded. Re-signing the same size replaces
+// the retained copy; any signed checkpoint for a size is equally valid.
+func (c *checkpointHistory) record(treeID int64, size uint64, checkpoint string) {
+	key := checkpointKey(treeID, size)
+	if dir := checkpointDir(); dir != "" {
+		if err := writeCheckpointFile(filepath.Join(dir, key), checkpoint); err != nil {
+			log.Logger.Warnf("Failed to write checkpoint %s to checkpoint directory: %v", key, err)
+		}
+	}
+
+	capacity := c.capacity()
+	if capacity <= 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.entries[key]; ok {
+		c.entries[key] = checkpoint
+		return
+	}
+	c.entries[key] = checkpoint
+	c.order.PushFront(key)
+	for len(c.entries) > capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+	}
+}
+
+// lookup returns the retained checkpoint for the given tree ID and size,
+// falling back to the checkpoint directory for sizes the in-memory window has
+// already evicted.
+func (c *checkpointHistory) lookup(treeID int64, size uint64) (string, bool) {
+	key := checkpointKey(treeID, size)
+	c.Lock()
+	checkpoint, ok := c.entries[key]
+	c.Unlock()
+	if ok {
+		return checkpoint, true
+	}
+	if dir := checkpointDir(); dir != "" {
+		contents, err := os.ReadFile(filepath.Join(dir, key))
+		if err == nil {
+			return string(contents), true
+		}
+		if !os.IsNotExist(err) {
+			log.Logger.Warnf("Failed to read checkpoint %s from checkpoint directory: %v", key, err)
+		}
+	}
+	return "", false
+}
+
+// writeCheckpointFile writes through a temporary file so a crash cannot leave
+// a torn checkpoint on disk.
+func writeCheckpointFile(path, checkpoint string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(checkpoint); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// CheckpointHistoryHandler serves GET /api/v1/log/checkpoints?size=N: the
+// signed checkpoint this server produced at the given tree size, as a
+// note-formatted document. An optional treeID parameter selects a shard; the
+// active shard is assumed otherwise. Only checkpoints within the retention
+// window are available, so a 404 does not imply the size never existed.
+func CheckpointHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	size, err := strconv.ParseUint(r.URL.Query().Get("size"), 10, 64)
+	if err != nil || size == 0 {
+		http.Error(w, "size must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	treeID := api.logID
+	if t := r.URL.Query().Get("treeID"); t != "" {
+		if treeID, err = strconv.ParseInt(t, 10, 64); err != nil {
+			http.Error(w, "treeID must be an integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	checkpoint, ok := checkpointStore.lookup(treeID, size)
+	if !ok {
+		w.Header().Set("Cache-Control", "no-store")
+		http.Error(w, "no retained checkpoint at this size", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	// any signed checkpoint for a size stays valid, so cached copies are fine
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	fmt.Fprint(w, checkpoint)
+}
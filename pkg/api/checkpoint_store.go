@@ -0,0 +1,143 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/trillian/types"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
+)
+
+// persistedCheckpoint is the on-disk record of the last checkpoint the server
+// signed, tagged with the tree it was signed for so that shard rollovers do
+// not trip the startup consistency check.
+type persistedCheckpoint struct {
+	TreeID     int64  `json:"treeID"`
+	Checkpoint string `json:"checkpoint"`
+}
+
+func checkpointFile() string {
+	return viper.GetString("rekor_server.checkpoint_file")
+}
+
+// persistCheckpoint records the signed checkpoint for the active tree,
+// writing through a temporary file so a crash cannot leave a torn record.
+func persistCheckpoint(sth *util.SignedCheckpoint) error {
+	api := currentAPI()
+	path := checkpointFile()
+	if path == "" {
+		return nil
+	}
+	scBytes, err := sth.SignedNote.MarshalText()
+	if err != nil {
+		return err
+	}
+	contents, err := json.Marshal(persistedCheckpoint{
+		TreeID:     api.logID,
+		Checkpoint: string(scBytes),
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// verifyPersistedCheckpoint proves consistency between the last checkpoint
+// this server signed and the current Trillian root. It returns an error if
+// the log has shrunk or forked since the checkpoint was persisted, in which
+// case the server must not come up.
+func verifyPersistedCheckpoint(ctx context.Context) error {
+	api := currentAPI()
+	path := checkpointFile()
+	if path == "" {
+		return nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Logger.Infof("no persisted checkpoint at %s, skipping startup consistency check", path)
+			return nil
+		}
+		return fmt.Errorf("reading persisted checkpoint: %w", err)
+	}
+	var pc persistedCheckpoint
+	if err := json.Unmarshal(contents, &pc); err != nil {
+		return fmt.Errorf("parsing persisted checkpoint: %w", err)
+	}
+	if pc.TreeID != api.logID {
+		log.Logger.Infof("persisted checkpoint is for tree %d, active tree is %d; skipping startup consistency check", pc.TreeID, api.logID)
+		return nil
+	}
+	sth := &util.SignedCheckpoint{}
+	if err := sth.UnmarshalText([]byte(pc.Checkpoint)); err != nil {
+		return fmt.Errorf("parsing persisted checkpoint: %w", err)
+	}
+
+	tc := NewTrillianClient(ctx)
+	resp := tc.getLatest(0)
+	if resp.status != codes.OK {
+		return fmt.Errorf("fetching current root: %w", resp.err)
+	}
+	root := &types.LogRootV1{}
+	if err := root.UnmarshalBinary(resp.getLatestResult.SignedLogRoot.LogRoot); err != nil {
+		return fmt.Errorf("unmarshalling current root: %w", err)
+	}
+
+	switch {
+	case root.TreeSize < sth.Size:
+		return fmt.Errorf("log has shrunk: persisted checkpoint has size %d, current root has size %d", sth.Size, root.TreeSize)
+	case root.TreeSize == sth.Size:
+		if !bytes.Equal(root.RootHash, sth.Hash) {
+			return fmt.Errorf("log has forked: root hash at size %d changed from %x to %x", sth.Size, sth.Hash, root.RootHash)
+		}
+	case sth.Size > 0:
+		proofResp := tc.getConsistencyProof(int64(sth.Size), int64(root.TreeSize))
+		if proofResp.status != codes.OK {
+			return fmt.Errorf("fetching consistency proof: %w", proofResp.err)
+		}
+		if err := verify.VerifyConsistency(treeHasher(), sth.Size, root.TreeSize,
+			proofResp.getConsistencyProofResult.GetProof().GetHashes(), sth.Hash, root.RootHash); err != nil {
+			return fmt.Errorf("log is inconsistent with persisted checkpoint at size %d (%s): %w",
+				sth.Size, hex.EncodeToString(sth.Hash), err)
+		}
+	}
+	log.Logger.Infof("verified current root (size %d) is consistent with persisted checkpoint (size %d)", root.TreeSize, sth.Size)
+	return nil
+}
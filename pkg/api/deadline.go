@@ -0,0 +1,70 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// requestTimeoutHeader lets clients bound how long the server spends on their
+// request; the value is either a Go duration ("2s") or a number of seconds.
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// RequestTimeoutMiddleware applies a client-supplied deadline to the request
+// context. All Trillian calls derive their contexts from the request context,
+// so a client that only wants to wait two seconds gets a timely 504 instead of
+// being held for the server's own timeouts. The requested deadline is capped
+// at rekor_server.max_request_timeout so clients cannot hold connections open
+// longer than the operator allows.
+func RequestTimeoutMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(requestTimeoutHeader)
+		if header == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		timeout, err := parseRequestTimeout(header)
+		if err != nil {
+			http.Error(w, "malformed X-Request-Timeout header; expected a positive duration or number of seconds", http.StatusBadRequest)
+			return
+		}
+		if max := viper.GetDuration("rekor_server.max_request_timeout"); max > 0 && timeout > max {
+			timeout = max
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func parseRequestTimeout(value string) (time.Duration, error) {
+	var timeout time.Duration
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		timeout = time.Duration(seconds * float64(time.Second))
+	} else if timeout, err = time.ParseDuration(value); err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, errors.New("timeout must be positive")
+	}
+	return timeout, nil
+}
@@ -19,13 +19,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/strfmt"
@@ -33,16 +34,19 @@ import (
 	"github.com/google/trillian"
 	ttypes "github.com/google/trillian/types"
 	"github.com/spf13/viper"
-	"github.com/transparency-dev/merkle/rfc6962"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/genproto/googleapis/rpc/code"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/sigstore/rekor/pkg/canonical"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/generated/restapi/operations/entries"
 	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/policy"
 	"github.com/sigstore/rekor/pkg/sharding"
 	"github.com/sigstore/rekor/pkg/types"
+	"github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
@@ -52,7 +56,7 @@ func signEntry(ctx context.Context, signer signature.Signer, entry models.LogEnt
 	if err != nil {
 		return nil, fmt.Errorf("marshalling error: %v", err)
 	}
-	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	canonicalized, err := canonical.Transform(payload)
 	if err != nil {
 		return nil, fmt.Errorf("canonicalizing error: %v", err)
 	}
@@ -67,6 +71,7 @@ func signEntry(ctx context.Context, signer signature.Signer, entry models.LogEnt
 func logEntryFromLeaf(ctx context.Context, signer signature.Signer, tc TrillianClient, leaf *trillian.LogLeaf,
 	signedLogRoot *trillian.SignedLogRoot, proof *trillian.Proof, tid int64, ranges sharding.LogRanges) (models.LogEntry, error) {
 
+	api := currentAPI()
 	root := &ttypes.LogRootV1{}
 	if err := root.UnmarshalBinary(signedLogRoot.LogRoot); err != nil {
 		return nil, err
@@ -76,12 +81,17 @@ func logEntryFromLeaf(ctx context.Context, signer signature.Signer, tc TrillianC
 		hashes = append(hashes, hex.EncodeToString(hash))
 	}
 
+	integratedTime, err := leafIntegratedTime(leaf)
+	if err != nil {
+		return nil, err
+	}
+
 	virtualIndex := sharding.VirtualLogIndex(leaf.GetLeafIndex(), tid, ranges)
 	logEntryAnon := models.LogEntryAnon{
 		LogID:          swag.String(api.pubkeyHash),
 		LogIndex:       &virtualIndex,
 		Body:           leaf.LeafValue,
-		IntegratedTime: swag.Int64(leaf.IntegrateTimestamp.AsTime().Unix()),
+		IntegratedTime: swag.Int64(integratedTime),
 	}
 
 	signature, err := signEntry(ctx, signer, logEntryAnon)
@@ -136,12 +146,20 @@ func logEntryFromLeaf(ctx context.Context, signer signature.Signer, tc TrillianC
 		SignedEntryTimestamp: strfmt.Base64(signature),
 	}
 
+	// key the entry by its tree-qualified ID so a returned ID alone is enough
+	// to route a subsequent read to the right shard
+	entryIDstruct, err := sharding.CreateEntryIDFromParts(fmt.Sprintf("%x", tid), uuid)
+	if err != nil {
+		return nil, fmt.Errorf("error creating EntryID from treeID %v and uuid %v: %w", tid, uuid, err)
+	}
+
 	return models.LogEntry{
-		uuid: logEntryAnon}, nil
+		entryIDstruct.ReturnEntryIDString(): logEntryAnon}, nil
 }
 
 // GetLogEntryAndProofByIndexHandler returns the entry and inclusion proof for a specified log index
 func GetLogEntryByIndexHandler(params entries.GetLogEntryByIndexParams) middleware.Responder {
+	api := currentAPI()
 	ctx := params.HTTPRequest.Context()
 	tid, resolvedIndex := api.logRanges.ResolveVirtualIndex(int(params.LogIndex))
 	tc := NewTrillianClientFromTreeID(ctx, tid)
@@ -153,7 +171,7 @@ func GetLogEntryByIndexHandler(params entries.GetLogEntryByIndexParams) middlewa
 	case codes.NotFound, codes.OutOfRange, codes.InvalidArgument:
 		return handleRekorAPIError(params, http.StatusNotFound, fmt.Errorf("grpc error: %w", resp.err), "")
 	default:
-		return handleRekorAPIError(params, http.StatusInternalServerError, fmt.Errorf("grpc err: %w", resp.err), trillianCommunicationError)
+		return handleTrillianAPIError(params, resp)
 	}
 
 	result := resp.getLeafAndProofResult
@@ -171,25 +189,142 @@ func GetLogEntryByIndexHandler(params entries.GetLogEntryByIndexParams) middlewa
 }
 
 func createLogEntry(params entries.CreateLogEntryParams) (models.LogEntry, middleware.Responder) {
+	api := currentAPI()
 	ctx := params.HTTPRequest.Context()
+	if ReadOnly() {
+		return nil, handleRekorAPIError(params, http.StatusServiceUnavailable, errors.New("server is read-only"), readOnlyError)
+	}
+	kind := params.ProposedEntry.Kind()
 	entry, err := types.NewEntry(params.ProposedEntry)
 	if err != nil {
+		metricRejectedEntries.WithLabelValues(kind, "unknown", "malformed_entry").Inc()
+		return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(validationError, err))
+	}
+	apiVersion := entry.APIVersion()
+	if err := validateProposedEntryKeys(params.ProposedEntry); err != nil {
+		metricRejectedEntries.WithLabelValues(kind, apiVersion, "invalid_key_material").Inc()
 		return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(validationError, err))
 	}
+	if err := validateProposedEntryChecksums(params.ProposedEntry); err != nil {
+		metricRejectedEntries.WithLabelValues(kind, apiVersion, "checksum_mismatch").Inc()
+		return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(validationError, err))
+	}
+	if engine := policy.NewEngineFromConfig(); engine.Enabled() {
+		if err := engine.CheckKind(params.ProposedEntry.Kind()); err != nil {
+			metricRejectedEntries.WithLabelValues(kind, apiVersion, "policy_violation").Inc()
+			return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(policyViolationError, err))
+		}
+		if err := engine.CheckEntry(params.ProposedEntry); err != nil {
+			metricRejectedEntries.WithLabelValues(kind, apiVersion, "policy_violation").Inc()
+			return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(policyViolationError, err))
+		}
+	}
+	if webhook := policy.NewWebhookFromConfig(); webhook != nil {
+		if err := webhook.Admit(ctx, kind, apiVersion, params.ProposedEntry); err != nil {
+			var denied *policy.DeniedError
+			if errors.As(err, &denied) {
+				metricRejectedEntries.WithLabelValues(kind, apiVersion, "webhook_denied").Inc()
+				return nil, handleRekorAPIError(params, http.StatusForbidden, err, fmt.Sprintf(webhookDeniedError, denied.Reason))
+			}
+			return nil, handleRekorAPIError(params, http.StatusServiceUnavailable, err, webhookUnavailableError)
+		}
+	}
 	leaf, err := types.CanonicalizeEntry(ctx, entry)
 	if err != nil {
 		if _, ok := (err).(types.ValidationError); ok {
+			metricRejectedEntries.WithLabelValues(kind, apiVersion, "validation_failed").Inc()
 			return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(validationError, err))
 		}
+		metricRejectedEntries.WithLabelValues(kind, apiVersion, "canonicalization_error").Inc()
 		return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, failedToGenerateCanonicalEntry)
 	}
 
-	tc := NewTrillianClient(ctx)
-
-	resp := tc.addLeaf(leaf)
+	// enforce the size limit on the canonicalized leaf rather than the request
+	// body: the canonical form is what is stored and what Trillian's own
+	// message limits apply to
+	if maxSize := viper.GetInt("rekor_server.max_leaf_size"); maxSize > 0 && len(leaf) > maxSize {
+		metricRejectedEntries.WithLabelValues(kind, apiVersion, "oversized").Inc()
+		return nil, handleRekorAPIError(params, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("canonicalized leaf is %d bytes, limit is %d bytes", len(leaf), maxSize),
+			fmt.Sprintf(entryTooLargeError, len(leaf), maxSize))
+	}
+
+	// the leaf hash (and therefore the entry UUID) is derived entirely from the
+	// canonicalized entry, so it is known before the leaf is integrated
+	rawLeafHash := treeHasher().HashLeaf(leaf)
+	leafHash := hex.EncodeToString(rawLeafHash)
+
+	// a leaf we queued into the active tree moments ago cannot be queued
+	// again; answer the retry from memory with the same conflict Trillian
+	// would produce, without consuming quota or another round trip
+	activeTreeID := api.logRanges.ActiveTreeID()
+	if recentSubmissions.contains(activeTreeID, leafHash) {
+		existingEntryID := existingEntryID(activeTreeID, leafHash)
+		metricRejectedEntries.WithLabelValues(kind, apiVersion, "duplicate").Inc()
+		return nil, handleRekorAPIError(params, http.StatusConflict, errors.New("leaf hash found in recent submission cache"), fmt.Sprintf(entryAlreadyExists, existingEntryID), "entryURL", getEntryURL(*params.HTTPRequest.URL, existingEntryID))
+	}
+
+	// authenticated submissions are charged to a per-identity quota both
+	// locally and in Trillian, so one tenant's burst cannot exhaust the log's
+	// write capacity
+	identity := chargedIdentity(params.HTTPRequest)
+	if !writeQuota.allow(identity) {
+		metricRejectedEntries.WithLabelValues(kind, apiVersion, "rate_limited").Inc()
+		return nil, handleRekorAPIError(params, http.StatusTooManyRequests, errors.New("identity exceeded write rate"), rateLimitExceeded)
+	}
+
+	// clients can opt out of waiting for inclusion entirely with ?wait=false,
+	// optionally supplying a callback URL to be notified once the entry has
+	// been integrated into the log
+	callbackURL := params.HTTPRequest.URL.Query().Get("callbackURL")
+	if callbackURL != "" {
+		if err := validateCallbackURL(callbackURL); err != nil {
+			return nil, handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf(validationError, err))
+		}
+	}
+	if params.HTTPRequest.URL.Query().Get("wait") == "false" {
+		tc := NewTrillianClient(ctx)
+		resp := tc.queueLeaf(leaf, identity)
+		if resp.status != codes.OK {
+			return nil, handleTrillianAPIError(params, resp)
+		}
+		if insertionStatus := resp.getAddResult.QueuedLeaf.Status; insertionStatus != nil &&
+			(insertionStatus.Code == int32(code.Code_ALREADY_EXISTS) || insertionStatus.Code == int32(code.Code_FAILED_PRECONDITION)) {
+			err := fmt.Errorf("grpc error: %v", insertionStatus.String())
+			existingEntryID := existingEntryID(tc.logID, leafHash)
+			metricRejectedEntries.WithLabelValues(kind, apiVersion, "duplicate").Inc()
+			return nil, handleRekorAPIError(params, http.StatusConflict, err, fmt.Sprintf(entryAlreadyExists, existingEntryID), "entryURL", getEntryURL(*params.HTTPRequest.URL, existingEntryID))
+		}
+		if callbackURL != "" {
+			startIntegrationNotifier(params.HTTPRequest, callbackURL, rawLeafHash, leafHash)
+		}
+		logStats.record(kind)
+		return nil, newEntryAcceptedResponder(params.HTTPRequest, leafHash)
+	}
+
+	// bound how long we wait for the leaf to be integrated; if the deadline
+	// passes while the leaf is still queued, fall back to a 202 response
+	waitCtx := ctx
+	if timeout := viper.GetDuration("rekor_server.inclusion_wait"); timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	tc := NewTrillianClient(waitCtx)
+
+	resp := tc.addLeaf(leaf, identity)
+	// a 202 is only appropriate when the leaf made it into the queue and just
+	// the confirmation wait ran out; a deadline on the QueueLeaf write itself
+	// means the entry may not be in the log at all
+	leafQueued := resp.getAddResult != nil && resp.getAddResult.QueuedLeaf != nil &&
+		(resp.getAddResult.QueuedLeaf.Status == nil || resp.getAddResult.QueuedLeaf.Status.Code == int32(code.Code_OK))
+	if leafQueued && (resp.status == codes.DeadlineExceeded || errors.Is(resp.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+		log.RequestIDLogger(params.HTTPRequest).Infof("inclusion wait expired for %s, returning 202", leafHash)
+		return nil, newEntryAcceptedResponder(params.HTTPRequest, leafHash)
+	}
 	// this represents overall GRPC response state (not the results of insertion into the log)
 	if resp.status != codes.OK {
-		return nil, handleRekorAPIError(params, http.StatusInternalServerError, fmt.Errorf("grpc error: %w", resp.err), trillianUnexpectedResult)
+		return nil, handleTrillianAPIError(params, resp)
 	}
 
 	// this represents the results of inserting the proposed leaf into the log; status is nil in success path
@@ -198,9 +333,10 @@ func createLogEntry(params entries.CreateLogEntryParams) (models.LogEntry, middl
 		switch insertionStatus.Code {
 		case int32(code.Code_OK):
 		case int32(code.Code_ALREADY_EXISTS), int32(code.Code_FAILED_PRECONDITION):
-			existingUUID := hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(leaf))
 			err := fmt.Errorf("grpc error: %v", insertionStatus.String())
-			return nil, handleRekorAPIError(params, http.StatusConflict, err, fmt.Sprintf(entryAlreadyExists, existingUUID), "entryURL", getEntryURL(*params.HTTPRequest.URL, existingUUID))
+			existingEntryID := existingEntryID(tc.logID, leafHash)
+			metricRejectedEntries.WithLabelValues(kind, apiVersion, "duplicate").Inc()
+			return nil, handleRekorAPIError(params, http.StatusConflict, err, fmt.Sprintf(entryAlreadyExists, existingEntryID), "entryURL", getEntryURL(*params.HTTPRequest.URL, existingEntryID))
 		default:
 			err := fmt.Errorf("grpc error: %v", insertionStatus.String())
 			return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, trillianUnexpectedResult)
@@ -209,6 +345,8 @@ func createLogEntry(params entries.CreateLogEntryParams) (models.LogEntry, middl
 
 	// We made it this far, that means the entry was successfully added.
 	metricNewEntries.Inc()
+	metricAcceptedEntries.WithLabelValues(kind, apiVersion).Inc()
+	logStats.record(kind)
 
 	queuedLeaf := resp.getAddResult.QueuedLeaf.Leaf
 
@@ -221,13 +359,20 @@ func createLogEntry(params entries.CreateLogEntryParams) (models.LogEntry, middl
 	}
 	entryID := entryIDstruct.ReturnEntryIDString()
 
+	// the integrated leaf fetched after the inclusion wait carries the
+	// integrate timestamp the SET is signed over
+	integratedTime, err := leafIntegratedTime(queuedLeaf)
+	if err != nil {
+		return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, trillianUnexpectedResult)
+	}
+
 	// The log index should be the virtual log index across all shards
 	virtualIndex := sharding.VirtualLogIndex(queuedLeaf.LeafIndex, api.logRanges.ActiveTreeID(), api.logRanges)
 	logEntryAnon := models.LogEntryAnon{
 		LogID:          swag.String(api.pubkeyHash),
 		LogIndex:       swag.Int64(virtualIndex),
 		Body:           queuedLeaf.GetLeafValue(),
-		IntegratedTime: swag.Int64(queuedLeaf.IntegrateTimestamp.AsTime().Unix()),
+		IntegratedTime: swag.Int64(integratedTime),
 	}
 
 	if viper.GetBool("enable_retrieve_api") {
@@ -271,8 +416,46 @@ func createLogEntry(params entries.CreateLogEntryParams) (models.LogEntry, middl
 		SignedEntryTimestamp: strfmt.Base64(signature),
 	}
 
+	// embed the inclusion proof and a signed checkpoint in the response so
+	// clients can assemble an offline verification bundle without an
+	// immediate follow-up read
+	if leafProof := resp.getLeafAndProofResult; leafProof != nil && leafProof.Proof != nil {
+		root := &ttypes.LogRootV1{}
+		if err := root.UnmarshalBinary(leafProof.SignedLogRoot.LogRoot); err != nil {
+			return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, trillianUnexpectedResult)
+		}
+		hashes := []string{}
+		for _, hash := range leafProof.Proof.Hashes {
+			hashes = append(hashes, hex.EncodeToString(hash))
+		}
+		inclusionProof := &models.InclusionProof{
+			TreeSize: swag.Int64(int64(root.TreeSize)),
+			RootHash: swag.String(hex.EncodeToString(root.RootHash)),
+			LogIndex: swag.Int64(leafProof.Proof.GetLeafIndex()),
+			Hashes:   hashes,
+		}
+		sth, err := util.CreateSignedCheckpoint(util.Checkpoint{
+			Origin: "Rekor",
+			Size:   root.TreeSize,
+			Hash:   root.RootHash,
+		})
+		if err != nil {
+			return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, sthGenerateError)
+		}
+		sth.SetTimestamp(uint64(time.Now().UnixNano()))
+		if _, err := sth.Sign(viper.GetString("rekor_server.hostname"), api.signer, options.WithContext(ctx)); err != nil {
+			return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, sthGenerateError)
+		}
+		scBytes, err := sth.SignedNote.MarshalText()
+		if err != nil {
+			return nil, handleRekorAPIError(params, http.StatusInternalServerError, err, sthGenerateError)
+		}
+		inclusionProof.Checkpoint = string(scBytes)
+		logEntryAnon.Verification.InclusionProof = inclusionProof
+	}
+
 	logEntry := models.LogEntry{
-		uuid: logEntryAnon,
+		entryID: logEntryAnon,
 	}
 	return logEntry, nil
 }
@@ -294,6 +477,56 @@ func CreateLogEntryHandler(params entries.CreateLogEntryParams) middleware.Respo
 	return entries.NewCreateLogEntryCreated().WithPayload(logEntry).WithLocation(getEntryURL(*httpReq.URL, uuid)).WithETag(uuid)
 }
 
+// existingEntryID returns the full tree-qualified entry ID for a duplicate
+// submission so the Location header on a 409 routes to the correct shard;
+// it falls back to the bare UUID if the entry ID cannot be constructed
+func existingEntryID(treeID int64, uuid string) string {
+	entryIDstruct, err := sharding.CreateEntryIDFromParts(fmt.Sprintf("%x", treeID), uuid)
+	if err != nil {
+		return uuid
+	}
+	return entryIDstruct.ReturnEntryIDString()
+}
+
+// newEntryAcceptedResponder returns a 202 response with a Location header the
+// client can poll until the entry has been integrated into the log. The body
+// carries a signed inclusion promise committing the log to integrate the leaf
+// within the configured max merge delay, mirroring CT's SCT model so
+// low-latency submitters have something verifiable to hold on to.
+func newEntryAcceptedResponder(httpReq *http.Request, uuid string) middleware.Responder {
+	api := currentAPI()
+	locationURL := *httpReq.URL
+	query := locationURL.Query()
+	query.Del("wait")
+	locationURL.RawQuery = query.Encode()
+	location := getEntryURL(locationURL, uuid)
+
+	promise := util.InclusionPromise{
+		LogID:         api.pubkeyHash,
+		LeafHash:      uuid,
+		Timestamp:     time.Now().Unix(),
+		MaxMergeDelay: int64(viper.GetDuration("rekor_server.max_merge_delay").Seconds()),
+	}
+	signedPromise, err := promise.Sign(httpReq.Context(), api.signer)
+	if err != nil {
+		// the 202 and its Location header remain usable without the promise
+		log.RequestIDLogger(httpReq).Errorf("signing inclusion promise: %v", err)
+	}
+
+	return middleware.ResponderFunc(func(w http.ResponseWriter, _ runtime.Producer) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", location.String())
+		w.WriteHeader(http.StatusAccepted)
+		body := struct {
+			EntryUUID        string                       `json:"entryUUID"`
+			InclusionPromise *util.SignedInclusionPromise `json:"inclusionPromise,omitempty"`
+		}{EntryUUID: uuid, InclusionPromise: signedPromise}
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			log.RequestIDLogger(httpReq).Errorf("writing accepted response: %v", err)
+		}
+	})
+}
+
 // getEntryURL returns the absolute path to the log entry in a RESTful style
 func getEntryURL(locationURL url.URL, uuid string) strfmt.URI {
 	// remove API key from output
@@ -307,6 +540,7 @@ func getEntryURL(locationURL url.URL, uuid string) strfmt.URI {
 
 // GetLogEntryByUUIDHandler gets log entry and inclusion proof for specified UUID aka merkle leaf hash
 func GetLogEntryByUUIDHandler(params entries.GetLogEntryByUUIDParams) middleware.Responder {
+	api := currentAPI()
 	uuid, err := sharding.GetUUIDFromIDString(params.EntryUUID)
 	if err != nil {
 		return handleRekorAPIError(params, http.StatusBadRequest, err, fmt.Sprintf("could not get UUID from ID string %v", params.EntryUUID))
@@ -348,6 +582,7 @@ func GetLogEntryByUUIDHandler(params entries.GetLogEntryByUUIDParams) middleware
 
 // SearchLogQueryHandler searches log by index, UUID, or proposed entry and returns array of entries found with inclusion proofs
 func SearchLogQueryHandler(params entries.SearchLogQueryParams) middleware.Responder {
+	api := currentAPI()
 	httpReqCtx := params.HTTPRequest.Context()
 	resultPayload := []models.LogEntry{}
 	tc := NewTrillianClient(httpReqCtx)
@@ -378,7 +613,7 @@ func SearchLogQueryHandler(params entries.SearchLogQueryParams) middleware.Respo
 					code = http.StatusInternalServerError
 					return err
 				}
-				hasher := rfc6962.DefaultHasher
+				hasher := treeHasher()
 				leafHash := hasher.HashLeaf(leaf)
 				searchHashes[i+len(params.Entry.EntryUUIDs)] = leafHash
 				return nil
@@ -428,10 +663,15 @@ func SearchLogQueryHandler(params entries.SearchLogQueryParams) middleware.Respo
 		g, _ := errgroup.WithContext(httpReqCtx)
 
 		leafResults := make([]*trillian.GetEntryAndProofResponse, len(params.Entry.LogIndexes))
+		leafTreeIDs := make([]int64, len(params.Entry.LogIndexes))
 		for i, logIndex := range params.Entry.LogIndexes {
 			i, logIndex := i, logIndex // https://golang.org/doc/faq#closures_and_goroutines
 			g.Go(func() error {
-				resp := tc.getLeafAndProofByIndex(swag.Int64Value(logIndex))
+				// indexes in the request are virtual indexes spanning all
+				// shards; resolve them through the shard table
+				tid, resolvedIndex := api.logRanges.ResolveVirtualIndex(int(swag.Int64Value(logIndex)))
+				shardClient := NewTrillianClientFromTreeID(httpReqCtx, tid)
+				resp := shardClient.getLeafAndProofByIndex(resolvedIndex)
 				switch resp.status {
 				case codes.OK, codes.NotFound:
 				default:
@@ -440,18 +680,20 @@ func SearchLogQueryHandler(params entries.SearchLogQueryParams) middleware.Respo
 				leafResult := resp.getLeafAndProofResult
 				if leafResult != nil && leafResult.Leaf != nil {
 					leafResults[i] = leafResult
+					leafTreeIDs[i] = tid
 				}
 				return nil
 			})
 		}
 
 		if err := g.Wait(); err != nil {
-			return handleRekorAPIError(params, http.StatusInternalServerError, fmt.Errorf("grpc error: %w", err), trillianUnexpectedResult)
+			httpStatus := trillianHTTPStatus(status.Code(err))
+			return handleRekorAPIError(params, httpStatus, fmt.Errorf("grpc error: %w", err), trillianErrorMessage(httpStatus))
 		}
 
-		for _, result := range leafResults {
+		for i, result := range leafResults {
 			if result != nil {
-				logEntry, err := logEntryFromLeaf(httpReqCtx, api.signer, tc, result.Leaf, result.SignedLogRoot, result.Proof, api.logRanges.ActiveTreeID(), api.logRanges)
+				logEntry, err := logEntryFromLeaf(httpReqCtx, api.signer, tc, result.Leaf, result.SignedLogRoot, result.Proof, leafTreeIDs[i], api.logRanges)
 				if err != nil {
 					return handleRekorAPIError(params, http.StatusInternalServerError, err, trillianUnexpectedResult)
 				}
@@ -467,6 +709,7 @@ var ErrNotFound = errors.New("grpc returned 0 leaves with success code")
 
 // Attempt to retrieve a UUID from a backend tree
 func RetrieveUUID(params entries.GetLogEntryByUUIDParams, uuid string, tid int64) (models.LogEntry, error) {
+	api := currentAPI()
 	ctx := params.HTTPRequest.Context()
 	hashValue, err := hex.DecodeString(uuid)
 	if err != nil {
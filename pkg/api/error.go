@@ -19,10 +19,13 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 
+	chimiddleware "github.com/go-chi/chi/middleware"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/strfmt"
 	"github.com/mitchellh/mapstructure"
+	"google.golang.org/grpc/codes"
 
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/generated/restapi/operations/entries"
@@ -35,12 +38,16 @@ import (
 const (
 	trillianCommunicationError     = "Unexpected error communicating with transparency log"
 	trillianUnexpectedResult       = "Unexpected result from transparency log"
+	trillianInvalidRequestError    = "Request rejected by transparency log"
+	trillianTimeoutError           = "Timed out communicating with transparency log"
+	trillianUnavailableError       = "The transparency log is currently unavailable"
 	validationError                = "Error processing entry: %v"
 	failedToGenerateCanonicalEntry = "Error generating canonicalized entry"
 	entryAlreadyExists             = "An equivalent entry already exists in the transparency log with UUID %v"
 	firstSizeLessThanLastSize      = "firstSize(%d) must be less than lastSize(%d)"
 	malformedUUID                  = "UUID must be a 64-character hexadecimal string"
 	malformedPublicKey             = "Public key provided could not be parsed"
+	remoteFetchError               = "Error fetching remote content: %v"
 	failedToGenerateCanonicalKey   = "Error generating canonicalized public key"
 	redisUnexpectedResult          = "Unexpected result from searching index"
 	lastSizeGreaterThanKnown       = "The tree size requested(%d) was greater than what is currently observable(%d)"
@@ -48,12 +55,83 @@ const (
 	sthGenerateError               = "Error generating signed tree head"
 	unsupportedPKIFormat           = "The PKI format requested is not supported by this server"
 	unexpectedInactiveShardError   = "Unexpected error communicating with inactive shard"
+	readOnlyError                  = "The server is currently in read-only mode and not accepting new entries"
+	policyViolationError           = "Entry rejected by admission policy: %v"
+	webhookDeniedError             = "Entry denied by admission webhook: %v"
+	webhookUnavailableError        = "Unable to reach the admission webhook; the entry was not queued"
+	rateLimitExceeded              = "Request rejected: write rate limit exceeded for this identity"
+	entryTooLargeError             = "Entry rejected: canonicalized entry is %d bytes, this log accepts at most %d bytes"
+	checkpointNotWitnessedError    = "No checkpoint has been cosigned by the required witnesses yet"
 )
 
-func errorMsg(message string, code int) *models.Error {
+// problemTypeBase prefixes the machine-readable problem type in RFC 7807
+// responses; the final path element identifies the error class.
+const problemTypeBase = "https://rekor.sigstore.dev/errors/"
+
+// trillianHTTPStatus maps a gRPC code returned by the Trillian log server to
+// the HTTP status surfaced to clients. Codes that indicate a problem with the
+// request map to 4xx, infrastructure failures map to the matching 5xx, and
+// anything unrecognized stays a 500.
+func trillianHTTPStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded, codes.Canceled:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// trillianErrorMessage picks the client-facing message for a translated
+// Trillian error status; 404 responders carry no body, so no message is
+// needed there.
+func trillianErrorMessage(httpStatus int) string {
+	switch {
+	case httpStatus == http.StatusNotFound:
+		return ""
+	case httpStatus == http.StatusGatewayTimeout:
+		return trillianTimeoutError
+	case httpStatus == http.StatusServiceUnavailable:
+		return trillianUnavailableError
+	case httpStatus >= http.StatusBadRequest && httpStatus < http.StatusInternalServerError:
+		return trillianInvalidRequestError
+	default:
+		return trillianCommunicationError
+	}
+}
+
+// handleTrillianAPIError translates a Trillian RPC response into the
+// appropriate HTTP error for the handler's params type.
+func handleTrillianAPIError(params interface{}, resp *Response) middleware.Responder {
+	httpStatus := trillianHTTPStatus(resp.status)
+	return handleRekorAPIError(params, httpStatus, fmt.Errorf("grpc error: %w", resp.err), trillianErrorMessage(httpStatus))
+}
+
+func errorMsg(r *http.Request, message string, code int) *models.Error {
 	return &models.Error{
-		Code:    int64(code),
-		Message: message,
+		Code:     int64(code),
+		Message:  message,
+		Type:     problemTypeBase + strings.ReplaceAll(strings.ToLower(http.StatusText(code)), " ", "-"),
+		Title:    http.StatusText(code),
+		Detail:   message,
+		Instance: chimiddleware.GetReqID(r.Context()),
 	}
 }
 
@@ -81,7 +159,7 @@ func handleRekorAPIError(params interface{}, code int, err error, message string
 		case http.StatusNotFound:
 			return entries.NewGetLogEntryByIndexNotFound()
 		default:
-			return entries.NewGetLogEntryByIndexDefault(code).WithPayload(errorMsg(message, code))
+			return entries.NewGetLogEntryByIndexDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 		}
 	case entries.GetLogEntryByUUIDParams:
 		logMsg(params.HTTPRequest)
@@ -89,16 +167,16 @@ func handleRekorAPIError(params interface{}, code int, err error, message string
 		case http.StatusNotFound:
 			return entries.NewGetLogEntryByUUIDNotFound()
 		default:
-			return entries.NewGetLogEntryByUUIDDefault(code).WithPayload(errorMsg(message, code))
+			return entries.NewGetLogEntryByUUIDDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 		}
 	case entries.CreateLogEntryParams:
 		switch code {
 		// We treat "duplicate entry" as an error, but it's not really an error, so we don't need to log it as one.
 		case http.StatusBadRequest:
 			logMsg(params.HTTPRequest)
-			return entries.NewCreateLogEntryBadRequest().WithPayload(errorMsg(message, code))
+			return entries.NewCreateLogEntryBadRequest().WithPayload(errorMsg(params.HTTPRequest, message, code))
 		case http.StatusConflict:
-			resp := entries.NewCreateLogEntryConflict().WithPayload(errorMsg(message, code))
+			resp := entries.NewCreateLogEntryConflict().WithPayload(errorMsg(params.HTTPRequest, message, code))
 			locationFound := false
 			for _, field := range fields {
 				if locationFound {
@@ -113,37 +191,37 @@ func handleRekorAPIError(params interface{}, code int, err error, message string
 			return resp
 		default:
 			logMsg(params.HTTPRequest)
-			return entries.NewCreateLogEntryDefault(code).WithPayload(errorMsg(message, code))
+			return entries.NewCreateLogEntryDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 		}
 	case entries.SearchLogQueryParams:
 		logMsg(params.HTTPRequest)
 		switch code {
 		case http.StatusBadRequest:
-			return entries.NewSearchLogQueryBadRequest().WithPayload(errorMsg(message, code))
+			return entries.NewSearchLogQueryBadRequest().WithPayload(errorMsg(params.HTTPRequest, message, code))
 		default:
-			return entries.NewSearchLogQueryDefault(code).WithPayload(errorMsg(message, code))
+			return entries.NewSearchLogQueryDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 		}
 	case tlog.GetLogInfoParams:
 		logMsg(params.HTTPRequest)
-		return tlog.NewGetLogInfoDefault(code).WithPayload(errorMsg(message, code))
+		return tlog.NewGetLogInfoDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 	case tlog.GetLogProofParams:
 		logMsg(params.HTTPRequest)
 		switch code {
 		case http.StatusBadRequest:
-			return tlog.NewGetLogProofBadRequest().WithPayload(errorMsg(message, code))
+			return tlog.NewGetLogProofBadRequest().WithPayload(errorMsg(params.HTTPRequest, message, code))
 		default:
-			return tlog.NewGetLogProofDefault(code).WithPayload(errorMsg(message, code))
+			return tlog.NewGetLogProofDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 		}
 	case pubkey.GetPublicKeyParams:
 		logMsg(params.HTTPRequest)
-		return pubkey.NewGetPublicKeyDefault(code).WithPayload(errorMsg(message, code))
+		return pubkey.NewGetPublicKeyDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 	case index.SearchIndexParams:
 		logMsg(params.HTTPRequest)
 		switch code {
 		case http.StatusBadRequest:
-			return index.NewSearchIndexBadRequest().WithPayload(errorMsg(message, code))
+			return index.NewSearchIndexBadRequest().WithPayload(errorMsg(params.HTTPRequest, message, code))
 		default:
-			return index.NewSearchIndexDefault(code).WithPayload(errorMsg(message, code))
+			return index.NewSearchIndexDefault(code).WithPayload(errorMsg(params.HTTPRequest, message, code))
 		}
 	default:
 		log.Logger.Errorf("unable to find method for type %T; error: %v", params, err)
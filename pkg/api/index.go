@@ -19,70 +19,194 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/swag"
 	radix "github.com/mediocregopher/radix/v4"
+	"github.com/spf13/viper"
 
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/generated/restapi/operations/index"
+	"github.com/sigstore/rekor/pkg/log"
 	"github.com/sigstore/rekor/pkg/pki"
 	"github.com/sigstore/rekor/pkg/util"
 )
 
-func SearchIndexHandler(params index.SearchIndexParams) middleware.Responder {
-	httpReqCtx := params.HTTPRequest.Context()
+// indexStorage abstracts the search index backend so dev mode can substitute
+// an in-memory implementation for Redis.
+type indexStorage interface {
+	lookup(ctx context.Context, key string) ([]string, error)
+	add(ctx context.Context, key, value string) error
+}
+
+// redisIndex stores the search index in Redis, one list per key.
+type redisIndex struct {
+	client radix.Client
+}
+
+func (r redisIndex) lookup(ctx context.Context, key string) ([]string, error) {
+	var values []string
+	if err := r.client.Do(ctx, radix.Cmd(&values, "LRANGE", key, "0", "-1")); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (r redisIndex) add(ctx context.Context, key, value string) error {
+	return r.client.Do(ctx, radix.Cmd(nil, "LPUSH", key, value))
+}
+
+// memoryIndex stores the search index in process memory; it backs dev mode
+// and is lost when the server exits.
+type memoryIndex struct {
+	mu      sync.RWMutex
+	entries map[string][]string
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{entries: make(map[string][]string)}
+}
 
+func (m *memoryIndex) lookup(_ context.Context, key string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.entries[key], nil
+}
+
+func (m *memoryIndex) add(_ context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = append(m.entries[key], value)
+	return nil
+}
+
+// searchError carries the HTTP status and client-safe message for a failed
+// index query alongside the underlying error.
+type searchError struct {
+	code    int
+	message string
+	err     error
+}
+
+func (e *searchError) Error() string { return e.message }
+
+// remoteFetchOptions returns the constraints applied when the server fetches
+// a URL on a client's behalf; restricted IP ranges are always blocked.
+func remoteFetchOptions() util.FetchOptions {
+	return util.FetchOptions{
+		AllowedHosts: viper.GetStringSlice("rekor_server.remote_fetch.allowed_hosts"),
+		MaxSize:      viper.GetInt64("rekor_server.remote_fetch.max_size"),
+		Timeout:      viper.GetDuration("rekor_server.remote_fetch.timeout"),
+	}
+}
+
+// queryIndex resolves one search query (hash, public key, and/or email) to
+// the matching entry UUIDs.
+func queryIndex(ctx context.Context, query *models.SearchIndex) ([]string, *searchError) {
 	var result []string
-	if params.Query.Hash != "" {
+	if query.Hash != "" {
 		// This must be a valid sha256 hash
-		sha := util.PrefixSHA(params.Query.Hash)
-		var resultUUIDs []string
-		if err := redisClient.Do(httpReqCtx, radix.Cmd(&resultUUIDs, "LRANGE", strings.ToLower(sha), "0", "-1")); err != nil {
-			return handleRekorAPIError(params, http.StatusInternalServerError, err, redisUnexpectedResult)
+		sha := util.PrefixSHA(query.Hash)
+		resultUUIDs, err := indexClient.lookup(ctx, strings.ToLower(sha))
+		if err != nil {
+			return nil, &searchError{http.StatusInternalServerError, redisUnexpectedResult, err}
 		}
 		result = append(result, resultUUIDs...)
 	}
-	if params.Query.PublicKey != nil {
-		af, err := pki.NewArtifactFactory(pki.Format(swag.StringValue(params.Query.PublicKey.Format)))
+	if query.PublicKey != nil {
+		af, err := pki.NewArtifactFactory(pki.Format(swag.StringValue(query.PublicKey.Format)))
 		if err != nil {
-			return handleRekorAPIError(params, http.StatusBadRequest, err, unsupportedPKIFormat)
+			return nil, &searchError{http.StatusBadRequest, unsupportedPKIFormat, err}
 		}
-		keyReader, err := util.FileOrURLReadCloser(httpReqCtx, params.Query.PublicKey.URL.String(), params.Query.PublicKey.Content)
+		keyReader, err := util.SafeFileOrURLReadCloser(ctx, query.PublicKey.URL.String(), query.PublicKey.Content, remoteFetchOptions())
 		if err != nil {
-			return handleRekorAPIError(params, http.StatusBadRequest, err, malformedPublicKey)
+			return nil, &searchError{http.StatusBadRequest, fmt.Sprintf(remoteFetchError, err), err}
 		}
 		defer keyReader.Close()
 
 		key, err := af.NewPublicKey(keyReader)
 		if err != nil {
-			return handleRekorAPIError(params, http.StatusBadRequest, err, malformedPublicKey)
+			return nil, &searchError{http.StatusBadRequest, malformedPublicKey, err}
 		}
 		canonicalKey, err := key.CanonicalValue()
 		if err != nil {
-			return handleRekorAPIError(params, http.StatusInternalServerError, err, failedToGenerateCanonicalKey)
+			return nil, &searchError{http.StatusInternalServerError, failedToGenerateCanonicalKey, err}
 		}
 
 		keyHash := sha256.Sum256(canonicalKey)
-		var resultUUIDs []string
-		if err := redisClient.Do(httpReqCtx, radix.Cmd(&resultUUIDs, "LRANGE", strings.ToLower(hex.EncodeToString(keyHash[:])), "0", "-1")); err != nil {
-			return handleRekorAPIError(params, http.StatusInternalServerError, err, redisUnexpectedResult)
+		resultUUIDs, err := indexClient.lookup(ctx, strings.ToLower(hex.EncodeToString(keyHash[:])))
+		if err != nil {
+			return nil, &searchError{http.StatusInternalServerError, redisUnexpectedResult, err}
 		}
 		result = append(result, resultUUIDs...)
 	}
-	if params.Query.Email != "" {
-		var resultUUIDs []string
-		if err := redisClient.Do(httpReqCtx, radix.Cmd(&resultUUIDs, "LRANGE", strings.ToLower(params.Query.Email.String()), "0", "-1")); err != nil {
-			return handleRekorAPIError(params, http.StatusInternalServerError, err, redisUnexpectedResult)
+	if query.Email != "" {
+		resultUUIDs, err := indexClient.lookup(ctx, strings.ToLower(query.Email.String()))
+		if err != nil {
+			return nil, &searchError{http.StatusInternalServerError, redisUnexpectedResult, err}
 		}
 		result = append(result, resultUUIDs...)
 	}
+	return result, nil
+}
 
+func SearchIndexHandler(params index.SearchIndexParams) middleware.Responder {
+	result, sErr := queryIndex(params.HTTPRequest.Context(), params.Query)
+	if sErr != nil {
+		return handleRekorAPIError(params, sErr.code, sErr.err, sErr.message)
+	}
 	return index.NewSearchIndexOK().WithPayload(result)
 }
 
+// BatchSearchIndexHandler handles POST /api/v1/index/retrieve/batch. It
+// accepts multiple queries in one request and returns the matching UUIDs
+// grouped per query, in query order, so batch verifiers avoid N round trips.
+func BatchSearchIndexHandler(w http.ResponseWriter, r *http.Request) {
+	if indexClient == nil {
+		http.Error(w, "Search Index API not enabled in this Rekor instance", http.StatusNotImplemented)
+		return
+	}
+	var body struct {
+		Queries []*models.SearchIndex `json:"queries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "malformed batch search request", http.StatusBadRequest)
+		return
+	}
+	if len(body.Queries) == 0 {
+		http.Error(w, "at least one query must be provided", http.StatusBadRequest)
+		return
+	}
+
+	results := make([][]string, len(body.Queries))
+	for i, query := range body.Queries {
+		if query == nil {
+			http.Error(w, fmt.Sprintf("query %d is empty", i), http.StatusBadRequest)
+			return
+		}
+		uuids, sErr := queryIndex(r.Context(), query)
+		if sErr != nil {
+			log.RequestIDLogger(r).Errorf("error resolving batch query %d: %v", i, sErr.err)
+			http.Error(w, sErr.message, sErr.code)
+			return
+		}
+		if uuids == nil {
+			uuids = []string{}
+		}
+		results[i] = uuids
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string][][]string{"results": results}); err != nil {
+		log.RequestIDLogger(r).Errorf("error writing batch search response: %v", err)
+	}
+}
+
 func SearchIndexNotImplementedHandler(params index.SearchIndexParams) middleware.Responder {
 	err := models.Error{
 		Code:    http.StatusNotImplemented,
@@ -93,8 +217,48 @@ func SearchIndexNotImplementedHandler(params index.SearchIndexParams) middleware
 
 }
 
+// LookupIndexHandler handles GET /api/v1/index/entries/{algorithm}:{digest},
+// a cache-friendly GET alternative to the POST search API that resolves an
+// artifact digest straight to entry UUIDs.
+func LookupIndexHandler(w http.ResponseWriter, r *http.Request) {
+	if indexClient == nil {
+		http.Error(w, "Search Index API not enabled in this Rekor instance", http.StatusNotImplemented)
+		return
+	}
+	algorithm, digest, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/index/entries/"), ":")
+	if !found {
+		http.Error(w, "expected path of the form {algorithm}:{digest}", http.StatusBadRequest)
+		return
+	}
+	if algorithm != "sha256" && algorithm != "sha1" {
+		http.Error(w, "unsupported digest algorithm "+algorithm, http.StatusBadRequest)
+		return
+	}
+	digest = strings.ToLower(digest)
+	if _, err := hex.DecodeString(digest); err != nil ||
+		(algorithm == "sha256" && len(digest) != 64) ||
+		(algorithm == "sha1" && len(digest) != 40) {
+		http.Error(w, "malformed digest for algorithm "+algorithm, http.StatusBadRequest)
+		return
+	}
+
+	uuids, err := indexClient.lookup(r.Context(), algorithm+":"+digest)
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error looking up index entries: %v", err)
+		http.Error(w, redisUnexpectedResult, http.StatusInternalServerError)
+		return
+	}
+	if uuids == nil {
+		uuids = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(uuids); err != nil {
+		log.RequestIDLogger(r).Errorf("error writing index lookup response: %v", err)
+	}
+}
+
 func addToIndex(ctx context.Context, key, value string) error {
-	return redisClient.Do(ctx, radix.Cmd(nil, "LPUSH", key, value))
+	return indexClient.add(ctx, key, value)
 }
 
 func storeAttestation(ctx context.Context, uuid string, attestation []byte) error {
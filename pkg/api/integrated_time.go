@@ -0,0 +1,67 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/trillian"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// leafIntegratedTime derives an entry's integratedTime from the Trillian
+// leaf's integrate timestamp. Deriving it from the leaf rather than the
+// server wall clock at response time makes SETs reproducible: every instance
+// signs the same integratedTime for the same entry, regardless of when or
+// where it is fetched. A leaf without an integrate timestamp has not been
+// integrated, and nothing should be signed for it.
+func leafIntegratedTime(leaf *trillian.LogLeaf) (int64, error) {
+	if leaf.GetIntegrateTimestamp() == nil || leaf.IntegrateTimestamp.AsTime().Unix() <= 0 {
+		return 0, errors.New("leaf carries no integrate timestamp; it has not been integrated yet")
+	}
+	t := leaf.IntegrateTimestamp.AsTime().Unix()
+	integratedTimes.observe(leaf.GetLeafIndex(), t)
+	return t, nil
+}
+
+// integratedTimeMonotonicity watches integrate timestamps as leaves are
+// served and flags regressions: a leaf at a higher index with an earlier
+// timestamp means the Trillian signers' clocks are skewed, which creates
+// anomalies for monitors that treat integratedTime as ordered. Regressions
+// are logged rather than rejected; the timestamps are already sealed into the
+// log.
+type integratedTimeMonotonicity struct {
+	mu        sync.Mutex
+	lastIndex int64
+	lastTime  int64
+}
+
+var integratedTimes = &integratedTimeMonotonicity{lastIndex: -1}
+
+func (m *integratedTimeMonotonicity) observe(leafIndex, integratedTime int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if leafIndex > m.lastIndex && m.lastTime > 0 && integratedTime < m.lastTime {
+		log.Logger.Warnf("integrate timestamp regression: leaf %d has integratedTime %d, earlier than %d at leaf %d; check Trillian signer clocks",
+			leafIndex, integratedTime, m.lastTime, m.lastIndex)
+	}
+	if leafIndex > m.lastIndex {
+		m.lastIndex = leafIndex
+		m.lastTime = integratedTime
+	}
+}
@@ -0,0 +1,105 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// queueLeafMethod is the one non-read Trillian RPC; it gets its own write
+// deadline and is excluded from automatic retries.
+const queueLeafMethod = "/trillian.TrillianLog/QueueLeaf"
+
+// clientInterceptors is the interceptor chain installed on the Trillian client
+// connection. All cross-cutting RPC behavior — trace propagation, logging,
+// metrics, retries, and per-RPC deadlines — is configured here rather than in
+// the individual TrillianClient methods. Ordering matters: the timeout
+// interceptor is innermost so every retry attempt gets a fresh deadline.
+func clientInterceptors() []grpc.UnaryClientInterceptor {
+	return []grpc.UnaryClientInterceptor{
+		traceMetadataInterceptor,
+		loggingInterceptor,
+		metricsInterceptor,
+		retryInterceptor,
+		timeoutInterceptor,
+	}
+}
+
+// loggingInterceptor records each Trillian RPC at debug level with its
+// duration and result code.
+func loggingInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	log.Logger.Debugw("trillian RPC", "method", method, "duration", time.Since(start), "code", status.Code(err).String())
+	return err
+}
+
+// metricsInterceptor exports per-RPC latency broken down by method and
+// result code.
+func metricsInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	metricTrillianRPCLatency.With(map[string]string{
+		"method": method,
+		"code":   status.Code(err).String(),
+	}).Observe(float64(time.Since(start)))
+	return err
+}
+
+// retryInterceptor retries read RPCs that fail with Unavailable, backing off
+// linearly between attempts. QueueLeaf is never retried here: duplicate
+// submissions are handled by the duplicate-leaf path in createLogEntry, and
+// retrying writes from inside the RPC layer would mask that.
+func retryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	attempts := viper.GetInt("trillian_log_server.retry_attempts")
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || method == queueLeafMethod || attempt >= attempts || status.Code(err) != codes.Unavailable {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * 100 * time.Millisecond):
+		}
+	}
+}
+
+// timeoutInterceptor bounds each RPC with its own deadline: reads get the
+// fixed read timeout, while QueueLeaf gets the (typically longer) queue
+// timeout since queueing under load can stall well past read latencies. A
+// shorter deadline already present on the context still wins.
+func timeoutInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	timeout := viper.GetDuration("trillian_log_server.rpc_timeout")
+	if method == queueLeafMethod {
+		timeout = viper.GetDuration("trillian_log_server.queue_timeout")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -26,8 +26,38 @@ var (
 		Help: "The total number of new log entries",
 	})
 
+	metricAcceptedEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rekor_accepted_entries",
+		Help: "The number of accepted log entries by kind and api version",
+	}, []string{"kind", "api_version"})
+
+	metricRejectedEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rekor_rejected_entries",
+		Help: "The number of rejected proposed entries by kind, api version, and rejection reason",
+	}, []string{"kind", "api_version", "reason"})
+
+	metricSequencingLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "rekor_sequencing_lag",
+		Help: "Leaves queued by this instance that have not yet been observed in the integrated tree",
+	})
+
+	metricShadowQueuedLeaves = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "rekor_shadow_queued_leaves",
+		Help: "The number of leaves successfully mirrored to the shadow tree during migration",
+	})
+
+	metricShadowWriteErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "rekor_shadow_write_errors",
+		Help: "The number of failed attempts to mirror leaves to the shadow tree during migration",
+	})
+
 	MetricLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name: "rekor_api_latency",
 		Help: "Api Latency on calls",
 	}, []string{"path", "code"})
+
+	metricTrillianRPCLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "rekor_trillian_rpc_latency",
+		Help: "Latency of Trillian RPCs by method and result code",
+	}, []string{"method", "code"})
 )
@@ -0,0 +1,109 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// shadowLog holds the dual-write migration target: a second Trillian tree
+// (optionally on a different log server) that receives a copy of every leaf
+// queued to the active tree. The active tree remains authoritative — shadow
+// writes are asynchronous and their failures never affect client responses —
+// so operators can populate a new backend, verify it matches, and cut over
+// without a big-bang switch.
+var shadowLog struct {
+	sync.RWMutex
+	client trillian.TrillianLogClient
+	treeID int64
+}
+
+// configureShadowLog sets up the dual-write migration target if one is
+// configured; with no shadow tree ID set, dual-writing is disabled.
+func configureShadowLog(ctx context.Context) error {
+	api := currentAPI()
+	treeID := viper.GetInt64("migration.shadow_tree_id")
+	if treeID == 0 {
+		return nil
+	}
+
+	logClient := api.logClient
+	if addr := viper.GetString("migration.shadow_log_server.address"); addr != "" {
+		rpcServer := fmt.Sprintf("%s:%d", addr, viper.GetUint("migration.shadow_log_server.port"))
+		conn, err := dial(ctx, rpcServer)
+		if err != nil {
+			return fmt.Errorf("dialing shadow log server: %w", err)
+		}
+		logClient = trillian.NewTrillianLogClient(conn)
+	}
+
+	shadowLog.Lock()
+	shadowLog.client = logClient
+	shadowLog.treeID = treeID
+	shadowLog.Unlock()
+	log.Logger.Infof("Dual-write migration enabled: mirroring writes to shadow tree %d", treeID)
+	return nil
+}
+
+func shadowLogTarget() (trillian.TrillianLogClient, int64) {
+	shadowLog.RLock()
+	defer shadowLog.RUnlock()
+	return shadowLog.client, shadowLog.treeID
+}
+
+// shadowQueueLeaf mirrors a leaf to the shadow tree. It runs in its own
+// goroutine with its own deadline; errors are counted and logged only, since
+// the authoritative write has already succeeded by the time this is called.
+func shadowQueueLeaf(byteValue []byte) {
+	logClient, treeID := shadowLogTarget()
+	if logClient == nil {
+		return
+	}
+
+	timeout := viper.GetDuration("trillian_log_server.queue_timeout")
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := logClient.QueueLeaf(ctx, &trillian.QueueLeafRequest{
+		LogId: treeID,
+		Leaf:  &trillian.LogLeaf{LeafValue: byteValue},
+	})
+	if err != nil {
+		metricShadowWriteErrors.Inc()
+		log.Logger.Warnf("Failed to mirror leaf to shadow tree %d: %v", treeID, err)
+		return
+	}
+	// an already-exists status is still a successful mirror (e.g. after a
+	// restart replays an entry the shadow tree has already seen)
+	if s := resp.QueuedLeaf.GetStatus(); s != nil && s.Code != int32(codes.OK) && s.Code != int32(codes.AlreadyExists) {
+		metricShadowWriteErrors.Inc()
+		log.Logger.Warnf("Shadow tree %d rejected mirrored leaf: %v", treeID, s.Message)
+		return
+	}
+	metricShadowQueuedLeaves.Inc()
+}
@@ -25,6 +25,7 @@ import (
 )
 
 func GetPublicKeyHandler(params pubkey.GetPublicKeyParams) middleware.Responder {
+	api := currentAPI()
 	ctx := params.HTTPRequest.Context()
 	treeID := swag.StringValue(params.TreeID)
 	tc := NewTrillianClient(ctx)
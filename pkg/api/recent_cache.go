@@ -0,0 +1,85 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// recentSubmissionCache is a fixed-size LRU of leaf hashes this instance has
+// recently queued, qualified by the tree they were queued into. An immediate
+// duplicate retry — common when CI systems retry on slow responses — is
+// answered from memory with the same conflict response Trillian would
+// eventually produce, without another round trip. Trillian deduplicates per
+// tree, so after a shard rollover a hash queued into the now-frozen tree must
+// not suppress a submission the new active tree would accept.
+type recentSubmissionCache struct {
+	sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+var recentSubmissions = &recentSubmissionCache{
+	entries: make(map[string]*list.Element),
+	order:   list.New(),
+}
+
+func (c *recentSubmissionCache) capacity() int {
+	return viper.GetInt("rekor_server.recent_cache_size")
+}
+
+// cacheKey qualifies a leaf hash by the tree it was queued into.
+func cacheKey(treeID int64, leafHash string) string {
+	return fmt.Sprintf("%d-%s", treeID, leafHash)
+}
+
+// add records a leaf hash queued into the given tree, evicting the least
+// recently seen hashes once the configured capacity is exceeded.
+func (c *recentSubmissionCache) add(treeID int64, leafHash string) {
+	capacity := c.capacity()
+	if capacity <= 0 {
+		return
+	}
+	key := cacheKey(treeID, leafHash)
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(key)
+	for len(c.entries) > capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+	}
+}
+
+// contains reports whether the leaf hash was recently queued into the given
+// tree by this instance.
+func (c *recentSubmissionCache) contains(treeID int64, leafHash string) bool {
+	if c.capacity() <= 0 {
+		return false
+	}
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.entries[cacheKey(treeID, leafHash)]
+	return ok
+}
@@ -0,0 +1,122 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
+)
+
+// shardRolloverEnabled reports whether either rollover threshold is set.
+func shardRolloverEnabled() bool {
+	return viper.GetInt64("shard_rollover.max_tree_size") > 0 ||
+		viper.GetDuration("shard_rollover.max_tree_age") > 0
+}
+
+// shardRolloverLoop periodically checks the active tree against the
+// configured size and age thresholds and rolls over to a fresh tree when
+// either is exceeded, so the log keeps serving without operator involvement.
+func shardRolloverLoop(ctx context.Context) {
+	interval := viper.GetDuration("shard_rollover.check_interval")
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := checkShardRollover(ctx); err != nil {
+				log.Logger.Errorf("shard rollover check: %v", err)
+			}
+		}
+	}
+}
+
+func checkShardRollover(ctx context.Context) error {
+	api := currentAPI()
+	tc := NewTrillianClient(ctx)
+	root, err := tc.root()
+	if err != nil {
+		return fmt.Errorf("getting active tree root: %w", err)
+	}
+
+	if maxSize := viper.GetInt64("shard_rollover.max_tree_size"); maxSize > 0 && int64(root.TreeSize) >= maxSize {
+		log.Logger.Infof("active tree %d has %d entries (threshold %d), rolling over", api.logID, root.TreeSize, maxSize)
+		return rolloverActiveShard(ctx, int64(root.TreeSize))
+	}
+
+	if maxAge := viper.GetDuration("shard_rollover.max_tree_age"); maxAge > 0 {
+		tree, err := api.adminClient.GetTree(ctx, &trillian.GetTreeRequest{TreeId: api.logID})
+		if err != nil {
+			return fmt.Errorf("getting active tree: %w", err)
+		}
+		if age := time.Since(tree.CreateTime.AsTime()); age >= maxAge {
+			log.Logger.Infof("active tree %d is %v old (threshold %v), rolling over", api.logID, age, maxAge)
+			return rolloverActiveShard(ctx, int64(root.TreeSize))
+		}
+	}
+	return nil
+}
+
+// rolloverActiveShard creates and initializes a new tree, freezes the current
+// active tree, and updates the shard table so reads against the old shard
+// keep resolving while writes continue against the new one.
+func rolloverActiveShard(ctx context.Context, oldTreeLength int64) error {
+	api := currentAPI()
+	oldTreeID := api.logID
+
+	newTree, err := createAndInitTree(ctx, api.adminClient, api.logClient)
+	if err != nil {
+		return fmt.Errorf("creating replacement tree: %w", err)
+	}
+
+	if _, err := api.adminClient.UpdateTree(ctx, &trillian.UpdateTreeRequest{
+		Tree: &trillian.Tree{
+			TreeId:    oldTreeID,
+			TreeState: trillian.TreeState_FROZEN,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"tree_state"},
+		},
+	}); err != nil {
+		return fmt.Errorf("freezing tree %d: %w", oldTreeID, err)
+	}
+
+	newRanges := api.logRanges
+	newRanges.AppendInactive(sharding.LogRange{TreeID: oldTreeID, TreeLength: oldTreeLength})
+	newRanges.SetActive(newTree.TreeId)
+
+	// swap in a new API value so in-flight requests that already took their
+	// snapshot keep a consistent view
+	newAPI := *api
+	newAPI.logID = newTree.TreeId
+	newAPI.logRanges = newRanges
+	setAPI(&newAPI)
+
+	log.Logger.Infof("rolled over from tree %d (length %d) to new active tree %d", oldTreeID, oldTreeLength, newTree.TreeId)
+	return nil
+}
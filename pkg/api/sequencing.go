@@ -0,0 +1,104 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+const defaultSequencingPollInterval = 30 * time.Second
+
+// queuedLeafCount counts leaves successfully queued by this instance since
+// startup; the sequencing lag loop compares it against observed tree growth.
+var queuedLeafCount int64
+
+// lastRootTimestampNanos records the timestamp of the most recently observed
+// signed log root, updated by the monitoring loop and loginfo requests.
+var lastRootTimestampNanos int64
+
+func recordRootTimestamp(nanos uint64) {
+	atomic.StoreInt64(&lastRootTimestampNanos, int64(nanos))
+}
+
+// rootStale reports whether the most recently observed signed log root is
+// older than the configured maximum age. A non-advancing root means the
+// Trillian signer has stopped, so the server reports itself degraded in
+// /readyz and flags loginfo responses. Staleness is never reported before a
+// root has been observed or when no maximum age is configured.
+func rootStale() bool {
+	maxAge := viper.GetDuration("monitoring.max_root_age")
+	if maxAge <= 0 {
+		return false
+	}
+	last := atomic.LoadInt64(&lastRootTimestampNanos)
+	if last == 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, last)) > maxAge
+}
+
+// sequencingLagLoop periodically polls the latest signed log root and exports
+// the number of leaves this instance has queued that have not yet shown up in
+// the integrated tree. A growing gap means the Trillian signer has stalled,
+// which is logged once the configured threshold is exceeded.
+func sequencingLagLoop(ctx context.Context) {
+	interval := viper.GetDuration("monitoring.sequencing_poll_interval")
+	if interval <= 0 {
+		interval = defaultSequencingPollInterval
+	}
+
+	tc := NewTrillianClient(ctx)
+	baseline, err := tc.root()
+	if err != nil {
+		log.Logger.Errorf("sequencing lag monitor: fetching baseline root: %v", err)
+		return
+	}
+	baselineSize := int64(baseline.TreeSize)
+	recordRootTimestamp(baseline.TimestampNanos)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			root, err := tc.root()
+			if err != nil {
+				log.Logger.Errorf("sequencing lag monitor: fetching root: %v", err)
+				continue
+			}
+			recordRootTimestamp(root.TimestampNanos)
+			integrated := int64(root.TreeSize) - baselineSize
+			lag := atomic.LoadInt64(&queuedLeafCount) - integrated
+			// other instances writing to the same log can push tree growth
+			// past our own queue count; that just means we have no backlog
+			if lag < 0 {
+				lag = 0
+			}
+			metricSequencingLag.Set(float64(lag))
+			if threshold := viper.GetInt64("monitoring.sequencing_lag_threshold"); threshold > 0 && lag > threshold {
+				log.Logger.Warnf("sequencing lag of %d leaves exceeds threshold %d; the Trillian signer may be stalled", lag, threshold)
+			}
+		}
+	}
+}
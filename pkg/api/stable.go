@@ -0,0 +1,207 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/trillian"
+	"github.com/transparency-dev/merkle/proof"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// stableCacheControl marks a response as immutable so CDNs and proxies can
+// cache it indefinitely; responses under /api/v1/log/stable are fully
+// determined by the tree size in the URL and never change once served.
+const stableCacheControl = "public, max-age=31536000, immutable"
+
+// stableEntryResult is an entry with its inclusion proof, both fixed at the
+// tree size in the request; the root hash is recomputed from the proof so
+// clients can verify without a second request.
+type stableEntryResult struct {
+	TreeID    string   `json:"treeID"`
+	TreeSize  int64    `json:"treeSize"`
+	LeafIndex int64    `json:"leafIndex"`
+	RootHash  string   `json:"rootHash"`
+	Hashes    []string `json:"hashes"`
+	LeafValue []byte   `json:"leafValue"`
+}
+
+// stableProofResult is a consistency proof between two fixed tree sizes.
+type stableProofResult struct {
+	TreeID    string   `json:"treeID"`
+	FirstSize int64    `json:"firstSize"`
+	LastSize  int64    `json:"lastSize"`
+	Hashes    []string `json:"hashes"`
+}
+
+// StableEndpointsHandler serves GET /api/v1/log/stable/...: read endpoints
+// parameterized by tree size whose responses never change once the log has
+// reached that size, so they can sit behind long-TTL CDN caching:
+//
+//	/api/v1/log/stable/entry/{treeSize}/{leafIndex}
+//	/api/v1/log/stable/consistency/{firstSize}/{lastSize}
+//
+// An optional treeID query parameter selects a shard; the active shard is
+// used otherwise. Requests naming a size the log has not reached yet return
+// 404 with caching disabled, so the miss is not pinned by intermediaries.
+func StableEndpointsHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/log/stable/")
+	segments := strings.Split(rest, "/")
+	if len(segments) != 3 {
+		http.Error(w, "unknown stable endpoint", http.StatusNotFound)
+		return
+	}
+	first, errFirst := strconv.ParseInt(segments[1], 10, 64)
+	second, errSecond := strconv.ParseInt(segments[2], 10, 64)
+	if errFirst != nil || errSecond != nil || first < 0 || second < 0 {
+		http.Error(w, "path parameters must be decimal integers", http.StatusBadRequest)
+		return
+	}
+
+	tid := api.logID
+	if treeIDStr := r.URL.Query().Get("treeID"); treeIDStr != "" {
+		var err error
+		tid, err = strconv.ParseInt(treeIDStr, 10, 64)
+		if err != nil {
+			http.Error(w, "malformed treeID", http.StatusBadRequest)
+			return
+		}
+	}
+	tc := NewTrillianClientFromTreeID(r.Context(), tid)
+
+	switch segments[0] {
+	case "entry":
+		serveStableEntry(w, r, tc, second, first)
+	case "consistency":
+		serveStableConsistency(w, r, tc, first, second)
+	default:
+		http.Error(w, "unknown stable endpoint", http.StatusNotFound)
+	}
+}
+
+func serveStableEntry(w http.ResponseWriter, r *http.Request, tc TrillianClient, leafIndex, treeSize int64) {
+	if treeSize < 1 || leafIndex >= treeSize {
+		http.Error(w, "leafIndex must be smaller than treeSize", http.StatusBadRequest)
+		return
+	}
+	if !treeReachedSize(tc, treeSize) {
+		serveNotYetAvailable(w)
+		return
+	}
+
+	resp, err := tc.reader.GetEntryAndProof(r.Context(), &trillian.GetEntryAndProofRequest{
+		LogId:     tc.logID,
+		LeafIndex: leafIndex,
+		TreeSize:  treeSize,
+	})
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error fetching entry and proof at size %d: %v", treeSize, err)
+		http.Error(w, trillianUnexpectedResult, http.StatusInternalServerError)
+		return
+	}
+	if resp.Leaf == nil || resp.Proof == nil {
+		serveNotYetAvailable(w)
+		return
+	}
+
+	// recompute the root implied by the proof; it both sanity-checks the
+	// backend response and gives clients a verification anchor
+	rootHash, err := proof.RootFromInclusionProof(treeHasher(), uint64(leafIndex), uint64(treeSize), resp.Leaf.MerkleLeafHash, resp.Proof.Hashes)
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error computing root from inclusion proof: %v", err)
+		http.Error(w, trillianUnexpectedResult, http.StatusInternalServerError)
+		return
+	}
+
+	writeStableResponse(w, r, stableEntryResult{
+		TreeID:    strconv.FormatInt(tc.logID, 10),
+		TreeSize:  treeSize,
+		LeafIndex: leafIndex,
+		RootHash:  hex.EncodeToString(rootHash),
+		Hashes:    hexHashes(resp.Proof.Hashes),
+		LeafValue: resp.Leaf.LeafValue,
+	})
+}
+
+func serveStableConsistency(w http.ResponseWriter, r *http.Request, tc TrillianClient, firstSize, lastSize int64) {
+	if firstSize < 1 || firstSize > lastSize {
+		http.Error(w, "firstSize must be between 1 and lastSize", http.StatusBadRequest)
+		return
+	}
+	if !treeReachedSize(tc, lastSize) {
+		serveNotYetAvailable(w)
+		return
+	}
+
+	resp := tc.getConsistencyProof(firstSize, lastSize)
+	if resp.err != nil {
+		log.RequestIDLogger(r).Errorf("error fetching consistency proof between %d and %d: %v", firstSize, lastSize, resp.err)
+		http.Error(w, trillianUnexpectedResult, http.StatusInternalServerError)
+		return
+	}
+
+	writeStableResponse(w, r, stableProofResult{
+		TreeID:    strconv.FormatInt(tc.logID, 10),
+		FirstSize: firstSize,
+		LastSize:  lastSize,
+		Hashes:    hexHashes(resp.getConsistencyProofResult.Proof.Hashes),
+	})
+}
+
+// treeReachedSize reports whether the tree has grown to at least the given
+// size; responses below the current size are stable and safe to cache.
+func treeReachedSize(tc TrillianClient, size int64) bool {
+	root, err := tc.root()
+	if err != nil {
+		return false
+	}
+	return int64(root.TreeSize) >= size
+}
+
+// serveNotYetAvailable responds 404 without caching, so intermediaries do not
+// pin a miss for a size the log will soon reach.
+func serveNotYetAvailable(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.Error(w, "tree has not reached the requested size", http.StatusNotFound)
+}
+
+func writeStableResponse(w http.ResponseWriter, r *http.Request, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", stableCacheControl)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.RequestIDLogger(r).Errorf("error writing stable endpoint response: %v", err)
+	}
+}
+
+func hexHashes(hashes [][]byte) []string {
+	out := make([]string, len(hashes))
+	for i, h := range hashes {
+		out[i] = hex.EncodeToString(h)
+	}
+	return out
+}
@@ -0,0 +1,176 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// statsWindowMinutes is how far back per-minute ingestion buckets are kept
+// for computing recent-rate figures.
+const statsWindowMinutes = 60
+
+// statsRecorder tracks accepted entries since server start: totals per kind
+// and per-minute buckets for recent ingestion rates. It feeds the public
+// stats endpoint, which dashboards can hit without scraping Prometheus.
+type statsRecorder struct {
+	mu      sync.Mutex
+	started time.Time
+	perKind map[string]int64
+	// buckets holds accepted-entry counts per minute, indexed by unix minute
+	// modulo the window size; stale buckets are zeroed as the window advances
+	buckets    [statsWindowMinutes]int64
+	lastMinute int64
+}
+
+var logStats = &statsRecorder{
+	started: time.Now(),
+	perKind: make(map[string]int64),
+}
+
+// record notes one accepted entry of the given kind.
+func (s *statsRecorder) record(kind string) {
+	now := time.Now().Unix() / 60
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(now)
+	s.perKind[kind]++
+	s.buckets[now%statsWindowMinutes]++
+}
+
+// advance zeroes any buckets the window has moved past; callers must hold mu.
+func (s *statsRecorder) advance(nowMinute int64) {
+	if s.lastMinute == 0 {
+		s.lastMinute = nowMinute
+		return
+	}
+	for m := s.lastMinute + 1; m <= nowMinute; m++ {
+		s.buckets[m%statsWindowMinutes] = 0
+		if m-s.lastMinute >= statsWindowMinutes {
+			break
+		}
+	}
+	s.lastMinute = nowMinute
+}
+
+// rates sums the buckets over the trailing 1, 5, 15, and 60 minutes.
+func (s *statsRecorder) rates() map[string]int64 {
+	now := time.Now().Unix() / 60
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(now)
+	out := map[string]int64{"1m": 0, "5m": 0, "15m": 0, "60m": 0}
+	for i := int64(0); i < statsWindowMinutes; i++ {
+		count := s.buckets[(now-i+statsWindowMinutes)%statsWindowMinutes]
+		if i < 1 {
+			out["1m"] += count
+		}
+		if i < 5 {
+			out["5m"] += count
+		}
+		if i < 15 {
+			out["15m"] += count
+		}
+		out["60m"] += count
+	}
+	return out
+}
+
+// snapshotPerKind copies the per-kind totals; counts are since server start.
+func (s *statsRecorder) snapshotPerKind() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int64, len(s.perKind))
+	for k, v := range s.perKind {
+		out[k] = v
+	}
+	return out
+}
+
+// statsShard describes one shard in the stats response.
+type statsShard struct {
+	TreeID   string `json:"treeID"`
+	TreeSize int64  `json:"treeSize"`
+	Status   string `json:"status"`
+}
+
+// statsResponse is the payload of the log statistics endpoint. Per-kind
+// counts and ingestion rates are tracked in process memory, so they cover
+// entries accepted by this server instance since countingSince.
+type statsResponse struct {
+	TotalEntries   int64            `json:"totalEntries"`
+	Shards         []statsShard     `json:"shards"`
+	EntriesPerKind map[string]int64 `json:"entriesPerKind"`
+	// IngestionRates maps a trailing window (1m, 5m, 15m, 60m) to the number
+	// of entries accepted within it
+	IngestionRates map[string]int64 `json:"ingestionRates"`
+	CountingSince  string           `json:"countingSince"`
+}
+
+// StatsHandler serves GET /api/v1/log/stats: public log statistics for
+// dashboards, covering total entries, shard layout, entries per kind, and
+// recent ingestion rates. This sits outside the swagger spec like the other
+// informational endpoints.
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	tc := NewTrillianClient(r.Context())
+	root, err := tc.root()
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error fetching log root for stats: %v", err)
+		httpStatus := trillianHTTPStatus(status.Code(err))
+		http.Error(w, trillianErrorMessage(httpStatus), httpStatus)
+		return
+	}
+
+	resp := statsResponse{
+		TotalEntries:   api.logRanges.TotalInactiveLength() + int64(root.TreeSize),
+		EntriesPerKind: logStats.snapshotPerKind(),
+		IngestionRates: logStats.rates(),
+		CountingSince:  logStats.started.UTC().Format(time.RFC3339),
+	}
+	for _, shard := range api.logRanges.GetInactive() {
+		resp.Shards = append(resp.Shards, statsShard{
+			TreeID:   strconv.FormatInt(shard.TreeID, 10),
+			TreeSize: shard.TreeLength,
+			Status:   "inactive",
+		})
+	}
+	resp.Shards = append(resp.Shards, statsShard{
+		TreeID:   strconv.FormatInt(api.logID, 10),
+		TreeSize: int64(root.TreeSize),
+		Status:   "active",
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	// cheap to recompute and quick to go stale; let dashboards cache briefly
+	w.Header().Set("Cache-Control", "public, max-age=10")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.RequestIDLogger(r).Errorf("error writing stats response: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
+)
+
+// streamBatchSize is how many leaves are fetched from Trillian and flushed to
+// the client at a time while streaming.
+const streamBatchSize = 512
+
+// streamedLogEntry is one NDJSON line of the entry stream: the leaf content
+// plus its authoritative placement metadata. The signedEntryTimestamp is
+// computed over the same canonicalized document (logID, logIndex, body,
+// integratedTime) as the SET on the retrieval endpoints, so a line taken out
+// of the stream verifies exactly like an individually fetched entry.
+type streamedLogEntry struct {
+	UUID                 string        `json:"uuid"`
+	LogID                string        `json:"logID"`
+	LogIndex             int64         `json:"logIndex"`
+	TreeID               string        `json:"treeID"`
+	Body                 []byte        `json:"body"`
+	IntegratedTime       int64         `json:"integratedTime"`
+	SignedEntryTimestamp []byte        `json:"signedEntryTimestamp,omitempty"`
+	Error                *models.Error `json:"error,omitempty"`
+}
+
+// StreamEntriesHandler handles GET /api/v1/log/entries/stream. It streams
+// leaves in log order as NDJSON, one entry per line, starting from the
+// virtual log index given by the start query parameter (default 0) and
+// stopping at the tree size observed when the stream began, or after count
+// entries if a count parameter is supplied. Leaves are fetched and flushed in
+// fixed-size batches so a slow reader throttles the server through TCP
+// backpressure rather than unbounded buffering.
+func StreamEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	ctx := r.Context()
+
+	start := int64(0)
+	if s := r.URL.Query().Get("start"); s != "" {
+		var err error
+		if start, err = strconv.ParseInt(s, 10, 64); err != nil || start < 0 {
+			http.Error(w, "start must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+	count := int64(-1)
+	if c := r.URL.Query().Get("count"); c != "" {
+		var err error
+		if count, err = strconv.ParseInt(c, 10, 64); err != nil || count <= 0 {
+			http.Error(w, "count must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// snapshot the log size up front; entries integrated after this point are
+	// left for the monitor's next poll so the stream has a defined end
+	activeClient := NewTrillianClient(ctx)
+	root, err := activeClient.root()
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error fetching log root for stream: %v", err)
+		httpStatus := trillianHTTPStatus(status.Code(err))
+		http.Error(w, trillianErrorMessage(httpStatus), httpStatus)
+		return
+	}
+	totalSize := api.logRanges.TotalInactiveLength() + int64(root.TreeSize)
+	if start >= totalSize {
+		http.Error(w, "start is beyond the current tree size", http.StatusNotFound)
+		return
+	}
+	end := totalSize
+	if count > 0 && start+count < end {
+		end = start + count
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	virtualIndex := start
+	for virtualIndex < end {
+		if ctx.Err() != nil {
+			return
+		}
+		tid, resolvedIndex := api.logRanges.ResolveVirtualIndex(int(virtualIndex))
+		tc := NewTrillianClientFromTreeID(ctx, tid)
+
+		batch := end - virtualIndex
+		if batch > streamBatchSize {
+			batch = streamBatchSize
+		}
+		resp := tc.getLeavesByRange(resolvedIndex, batch)
+		if resp.err != nil {
+			log.RequestIDLogger(r).Errorf("error streaming leaves [%d, %d) from tree %d: %v", resolvedIndex, resolvedIndex+batch, tid, resp.err)
+			// headers are already out; surface the failure in-band so the
+			// client does not mistake truncation for the end of the log
+			_ = enc.Encode(streamedLogEntry{Error: &models.Error{Code: http.StatusInternalServerError, Message: trillianCommunicationError}})
+			return
+		}
+		leaves := resp.getLeavesByRangeResult.GetLeaves()
+		if len(leaves) == 0 {
+			log.RequestIDLogger(r).Errorf("tree %d returned no leaves at index %d with %d entries outstanding", tid, resolvedIndex, end-virtualIndex)
+			return
+		}
+		for _, leaf := range leaves {
+			virtualLeafIndex := sharding.VirtualLogIndex(leaf.GetLeafIndex(), tid, api.logRanges)
+			integratedTime, err := leafIntegratedTime(leaf)
+			if err != nil {
+				log.RequestIDLogger(r).Errorf("error deriving integrated time for streamed entry at index %d: %v", virtualLeafIndex, err)
+				_ = enc.Encode(streamedLogEntry{Error: &models.Error{Code: http.StatusInternalServerError, Message: trillianUnexpectedResult}})
+				return
+			}
+			set, err := signEntry(ctx, api.signer, models.LogEntryAnon{
+				LogID:          &api.pubkeyHash,
+				LogIndex:       &virtualLeafIndex,
+				Body:           leaf.LeafValue,
+				IntegratedTime: &integratedTime,
+			})
+			if err != nil {
+				log.RequestIDLogger(r).Errorf("error signing streamed entry at index %d: %v", virtualLeafIndex, err)
+				_ = enc.Encode(streamedLogEntry{Error: &models.Error{Code: http.StatusInternalServerError, Message: signingError}})
+				return
+			}
+			entry := streamedLogEntry{
+				UUID:                 hex.EncodeToString(leaf.MerkleLeafHash),
+				LogID:                api.pubkeyHash,
+				LogIndex:             virtualLeafIndex,
+				TreeID:               strconv.FormatInt(tid, 10),
+				Body:                 leaf.LeafValue,
+				IntegratedTime:       integratedTime,
+				SignedEntryTimestamp: set,
+			}
+			if err := enc.Encode(entry); err != nil {
+				log.RequestIDLogger(r).Debugf("client went away while streaming entries: %v", err)
+				return
+			}
+		}
+		// a short read means the shard boundary was crossed; the next pass
+		// resolves the following shard
+		virtualIndex += int64(len(leaves))
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
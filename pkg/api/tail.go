@@ -0,0 +1,215 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/sharding"
+)
+
+const (
+	// tailPollInterval is how often the tail handler checks the log for newly
+	// integrated entries once it has caught up.
+	tailPollInterval = 2 * time.Second
+	// tailKeepAliveInterval is how often an SSE comment is sent on an idle
+	// tail so proxies do not reap the connection.
+	tailKeepAliveInterval = 15 * time.Second
+)
+
+// TailEntriesHandler handles GET /api/v1/log/entries/tail. It pushes each
+// newly integrated entry to the client as a server-sent event, so monitors
+// do not have to poll. Each event's id is the entry's virtual log index;
+// reconnecting clients resume where they left off via the standard
+// Last-Event-ID header, or explicitly with a start query parameter. Without
+// either, tailing begins at the current tree size, i.e. with the next entry
+// integrated. Event payloads are the same documents served by the entry
+// stream endpoint, including the signed entry timestamp.
+func TailEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported on this connection", http.StatusInternalServerError)
+		return
+	}
+
+	var start int64 = -1
+	if s := r.URL.Query().Get("start"); s != "" {
+		var err error
+		if start, err = strconv.ParseInt(s, 10, 64); err != nil || start < 0 {
+			http.Error(w, "start must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	} else if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		last, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || last < 0 {
+			http.Error(w, "malformed Last-Event-ID header", http.StatusBadRequest)
+			return
+		}
+		start = last + 1
+	}
+	if start < 0 {
+		size, err := currentTotalSize(r)
+		if err != nil {
+			httpStatus := trillianHTTPStatus(status.Code(err))
+			http.Error(w, trillianErrorMessage(httpStatus), httpStatus)
+			return
+		}
+		start = size
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	poll := time.NewTicker(tailPollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(tailKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	virtualIndex := start
+	for {
+		caughtUp, err := tailToCurrentSize(w, r, flusher, &virtualIndex)
+		if err != nil || !caughtUp {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// tailToCurrentSize emits every entry between *virtualIndex and the current
+// tree size as SSE events, advancing *virtualIndex as it goes. It reports
+// whether tailing should continue; in-band errors terminate the stream.
+func tailToCurrentSize(w http.ResponseWriter, r *http.Request, flusher http.Flusher, virtualIndex *int64) (bool, error) {
+	api := currentAPI()
+	ctx := r.Context()
+
+	totalSize, err := currentTotalSize(r)
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error fetching log root while tailing: %v", err)
+		sendTailError(w, flusher, trillianCommunicationError)
+		return false, err
+	}
+
+	for *virtualIndex < totalSize {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		tid, resolvedIndex := api.logRanges.ResolveVirtualIndex(int(*virtualIndex))
+		tc := NewTrillianClientFromTreeID(ctx, tid)
+
+		batch := totalSize - *virtualIndex
+		if batch > streamBatchSize {
+			batch = streamBatchSize
+		}
+		resp := tc.getLeavesByRange(resolvedIndex, batch)
+		if resp.err != nil {
+			log.RequestIDLogger(r).Errorf("error tailing leaves [%d, %d) from tree %d: %v", resolvedIndex, resolvedIndex+batch, tid, resp.err)
+			sendTailError(w, flusher, trillianCommunicationError)
+			return false, resp.err
+		}
+		leaves := resp.getLeavesByRangeResult.GetLeaves()
+		if len(leaves) == 0 {
+			// nothing at this index yet despite the advertised size; let the
+			// next poll retry rather than spinning
+			return true, nil
+		}
+		for _, leaf := range leaves {
+			virtualLeafIndex := sharding.VirtualLogIndex(leaf.GetLeafIndex(), tid, api.logRanges)
+			integratedTime, err := leafIntegratedTime(leaf)
+			if err != nil {
+				log.RequestIDLogger(r).Errorf("error deriving integrated time for tailed entry at index %d: %v", virtualLeafIndex, err)
+				sendTailError(w, flusher, trillianUnexpectedResult)
+				return false, err
+			}
+			set, err := signEntry(ctx, api.signer, models.LogEntryAnon{
+				LogID:          &api.pubkeyHash,
+				LogIndex:       &virtualLeafIndex,
+				Body:           leaf.LeafValue,
+				IntegratedTime: &integratedTime,
+			})
+			if err != nil {
+				log.RequestIDLogger(r).Errorf("error signing tailed entry at index %d: %v", virtualLeafIndex, err)
+				sendTailError(w, flusher, signingError)
+				return false, err
+			}
+			data, err := json.Marshal(streamedLogEntry{
+				UUID:                 hex.EncodeToString(leaf.MerkleLeafHash),
+				LogID:                api.pubkeyHash,
+				LogIndex:             virtualLeafIndex,
+				TreeID:               strconv.FormatInt(tid, 10),
+				Body:                 leaf.LeafValue,
+				IntegratedTime:       integratedTime,
+				SignedEntryTimestamp: set,
+			})
+			if err != nil {
+				return false, err
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: entry\ndata: %s\n\n", virtualLeafIndex, data); err != nil {
+				log.RequestIDLogger(r).Debugf("client went away while tailing entries: %v", err)
+				return false, err
+			}
+		}
+		*virtualIndex += int64(len(leaves))
+		flusher.Flush()
+	}
+	return true, nil
+}
+
+// currentTotalSize returns the current virtual size of the log across all
+// shards.
+func currentTotalSize(r *http.Request) (int64, error) {
+	api := currentAPI()
+	tc := NewTrillianClient(r.Context())
+	root, err := tc.root()
+	if err != nil {
+		return 0, err
+	}
+	return api.logRanges.TotalInactiveLength() + int64(root.TreeSize), nil
+}
+
+// sendTailError surfaces a failure in-band as an SSE error event, so the
+// client does not mistake the drop for a clean end of stream.
+func sendTailError(w http.ResponseWriter, flusher http.Flusher, message string) {
+	payload, err := json.Marshal(models.Error{Code: http.StatusInternalServerError, Message: message})
+	if err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload); err != nil {
+		return
+	}
+	flusher.Flush()
+}
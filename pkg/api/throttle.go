@@ -0,0 +1,109 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// readQuota shapes unauthenticated read traffic per client IP, independently
+// of the per-identity write quota; reads are cheap so its limits are
+// expected to be far more generous.
+var readQuota = &identityLimiters{
+	qpsKey:   "quota.read_qps_per_ip",
+	burstKey: "quota.read_burst_per_ip",
+}
+
+// ReadThrottleMiddleware rate limits GET requests by client IP. Writes pass
+// through untouched; they are governed by the per-identity quota on the
+// create-entry path.
+func ReadThrottleMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && !readQuota.allow(clientIP(r)) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintf(w, `{"code":%d,"message":"read rate limit exceeded for this client"}`, http.StatusTooManyRequests)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// clientIP determines the address a request should be throttled under. The
+// X-Forwarded-For header is only honored when the directly connected peer is
+// in quota.trusted_proxies; otherwise clients could evade the limiter by
+// forging the header. Walking the header right to left skips over any
+// trusted proxies that appended themselves along the way.
+func clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	trusted := trustedProxyNets()
+	if !ipInNets(remote, trusted) {
+		return remote
+	}
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		if !ipInNets(hop, trusted) {
+			return hop
+		}
+	}
+	return remote
+}
+
+func trustedProxyNets() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range viper.GetStringSlice("quota.trusted_proxies") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		if _, n, err := net.ParseCIDR(cidr); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	return nets
+}
+
+func ipInNets(addr string, nets []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
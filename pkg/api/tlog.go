@@ -38,6 +38,7 @@ import (
 
 // GetLogInfoHandler returns the current size of the tree and the STH
 func GetLogInfoHandler(params tlog.GetLogInfoParams) middleware.Responder {
+	api := currentAPI()
 	tc := NewTrillianClient(params.HTTPRequest.Context())
 
 	// for each inactive shard, get the loginfo
@@ -56,7 +57,7 @@ func GetLogInfoHandler(params tlog.GetLogInfoParams) middleware.Responder {
 
 	resp := tc.getLatest(0)
 	if resp.status != codes.OK {
-		return handleRekorAPIError(params, http.StatusInternalServerError, fmt.Errorf("grpc error: %w", resp.err), trillianCommunicationError)
+		return handleTrillianAPIError(params, resp)
 	}
 	result := resp.getLatestResult
 
@@ -90,12 +91,36 @@ func GetLogInfoHandler(params tlog.GetLogInfoParams) middleware.Responder {
 	}
 	scString := string(scBytes)
 
+	if err := persistCheckpoint(sth); err != nil {
+		// serving the checkpoint is still safe; only the startup consistency
+		// baseline is affected
+		log.Logger.Warnf("Failed to persist signed checkpoint: %v", err)
+	}
+	checkpointStore.record(tc.logID, root.TreeSize, scString)
+
+	// when a witness policy is configured, the state presented as current is
+	// the latest checkpoint that gathered enough cosignatures, not the raw
+	// Trillian root; witnesses fetch the raw checkpoint from its own endpoint
+	if witnessPolicy != nil {
+		witnessedRaw, witnessedSize, witnessedHash, err := witnessedTreeState()
+		if err != nil {
+			return handleRekorAPIError(params, http.StatusServiceUnavailable, err, checkpointNotWitnessedError)
+		}
+		scString = witnessedRaw
+		treeSize = witnessedSize
+		hashString = hex.EncodeToString(witnessedHash)
+	}
+
+	recordRootTimestamp(root.TimestampNanos)
+
 	logInfo := models.LogInfo{
+		HashAlgorithm:  util.LogHasherName(viper.GetString("trillian_log_server.hash_algorithm")),
 		RootHash:       &hashString,
 		TreeSize:       &treeSize,
 		SignedTreeHead: &scString,
 		TreeID:         stringPointer(fmt.Sprintf("%d", tc.logID)),
 		InactiveShards: inactiveShards,
+		Stale:          rootStale(),
 	}
 
 	return tlog.NewGetLogInfoOK().WithPayload(&logInfo)
@@ -122,7 +147,7 @@ func GetLogProofHandler(params tlog.GetLogProofParams) middleware.Responder {
 
 	resp := tc.getConsistencyProof(*params.FirstSize, params.LastSize)
 	if resp.status != codes.OK {
-		return handleRekorAPIError(params, http.StatusInternalServerError, fmt.Errorf("grpc error: %w", resp.err), trillianCommunicationError)
+		return handleTrillianAPIError(params, resp)
 	}
 	result := resp.getConsistencyProofResult
 
@@ -154,6 +179,7 @@ func GetLogProofHandler(params tlog.GetLogProofParams) middleware.Responder {
 }
 
 func inactiveShardLogInfo(ctx context.Context, tid int64) (*models.InactiveShardLogInfo, error) {
+	api := currentAPI()
 	tc := NewTrillianClientFromTreeID(ctx, tid)
 	resp := tc.getLatest(0)
 	if resp.status != codes.OK {
@@ -188,11 +214,17 @@ func inactiveShardLogInfo(ctx context.Context, tid int64) (*models.InactiveShard
 	if err != nil {
 		return nil, err
 	}
+	checkpointStore.record(tid, root.TreeSize, string(scBytes))
 	m := models.InactiveShardLogInfo{
 		RootHash:       &hashString,
 		TreeSize:       &treeSize,
 		TreeID:         stringPointer(fmt.Sprintf("%d", tid)),
 		SignedTreeHead: stringPointer(string(scBytes)),
 	}
+	// only set if the shard signs with its own key; clients fall back to the
+	// active shard's key otherwise
+	if pk, err := api.logRanges.PublicKey("", fmt.Sprintf("%d", tid)); err == nil && pk != "" {
+		m.PublicKey = pk
+	}
 	return &m, nil
 }
@@ -0,0 +1,70 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type traceContextKey struct{}
+
+// traceContext carries the W3C trace context headers of the incoming request
+// through the request context so they can be forwarded to Trillian.
+type traceContext struct {
+	traceParent string
+	traceState  string
+}
+
+// WithTraceContext captures the W3C trace context headers (traceparent,
+// tracestate) from an incoming request into the context; they are forwarded
+// as gRPC metadata on every Trillian RPC made on behalf of the request.
+func WithTraceContext(ctx context.Context, header http.Header) context.Context {
+	tc := traceContext{
+		traceParent: header.Get("Traceparent"),
+		traceState:  header.Get("Tracestate"),
+	}
+	if tc.traceParent == "" && tc.traceState == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// traceMetadataInterceptor forwards the request's trace context and request ID
+// as gRPC metadata so distributed traces and Trillian-side logs can be
+// correlated with the originating API request.
+func traceMetadataInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var pairs []string
+	if reqID := chimiddleware.GetReqID(ctx); reqID != "" {
+		pairs = append(pairs, "x-request-id", reqID)
+	}
+	if tc, ok := ctx.Value(traceContextKey{}).(traceContext); ok {
+		if tc.traceParent != "" {
+			pairs = append(pairs, "traceparent", tc.traceParent)
+		}
+		if tc.traceState != "" {
+			pairs = append(pairs, "tracestate", tc.traceState)
+		}
+	}
+	if len(pairs) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -19,12 +19,16 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/sigstore/rekor/pkg/log"
 	"github.com/sigstore/rekor/pkg/sharding"
-	"github.com/transparency-dev/merkle/proof"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
+	"github.com/spf13/viper"
 	"github.com/transparency-dev/merkle/rfc6962"
+	"golang.org/x/sync/errgroup"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -35,16 +39,32 @@ import (
 	"github.com/google/trillian/types"
 )
 
+// treeHasher returns the tree hasher matching the configured log hash
+// algorithm; the configuration is validated at startup in NewAPI, so an
+// invalid value here falls back to the SHA-256 default.
+func treeHasher() *rfc6962.Hasher {
+	hasher, err := util.LogHasher(viper.GetString("trillian_log_server.hash_algorithm"))
+	if err != nil {
+		return rfc6962.DefaultHasher
+	}
+	return hasher
+}
+
 type TrillianClient struct {
-	client  trillian.TrillianLogClient
+	client trillian.TrillianLogClient
+	// reader serves read RPCs and may point at a read replica; writes and
+	// read-after-write flows always use client
+	reader  trillian.TrillianLogClient
 	ranges  sharding.LogRanges
 	logID   int64
 	context context.Context
 }
 
 func NewTrillianClient(ctx context.Context) TrillianClient {
+	api := currentAPI()
 	return TrillianClient{
 		client:  api.logClient,
+		reader:  api.readClient,
 		ranges:  api.logRanges,
 		logID:   api.logID,
 		context: ctx,
@@ -52,13 +72,23 @@ func NewTrillianClient(ctx context.Context) TrillianClient {
 }
 
 func NewTrillianClientFromTreeID(ctx context.Context, tid int64) TrillianClient {
+	api := currentAPI()
 	return TrillianClient{
 		client:  api.logClient,
+		reader:  api.readClient,
 		logID:   tid,
 		context: ctx,
 	}
 }
 
+// withPrimaryReads returns a copy of the client with reads pinned to the
+// primary, for flows that must observe a write they just made; a replica may
+// not have caught up yet.
+func (t TrillianClient) withPrimaryReads() TrillianClient {
+	t.reader = t.client
+	return t
+}
+
 type Response struct {
 	status                    codes.Code
 	err                       error
@@ -67,13 +97,14 @@ type Response struct {
 	getLeafAndProofResult     *trillian.GetEntryAndProofResponse
 	getLatestResult           *trillian.GetLatestSignedLogRootResponse
 	getConsistencyProofResult *trillian.GetConsistencyProofResponse
+	getLeavesByRangeResult    *trillian.GetLeavesByRangeResponse
 }
 
 func (t *TrillianClient) root() (types.LogRootV1, error) {
 	rqst := &trillian.GetLatestSignedLogRootRequest{
 		LogId: t.logID,
 	}
-	resp, err := t.client.GetLatestSignedLogRoot(t.context, rqst)
+	resp, err := t.reader.GetLatestSignedLogRoot(t.context, rqst)
 	if err != nil {
 		return types.LogRootV1{}, err
 	}
@@ -84,7 +115,8 @@ func (t *TrillianClient) root() (types.LogRootV1, error) {
 	return root, nil
 }
 
-func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
+// queueLeaf submits a leaf to the log without waiting for it to be integrated.
+func (t *TrillianClient) queueLeaf(byteValue []byte, chargeTo string) *Response {
 	leaf := &trillian.LogLeaf{
 		LeafValue: byteValue,
 	}
@@ -92,7 +124,29 @@ func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
 		LogId: t.logID,
 		Leaf:  leaf,
 	}
+	if chargeTo != "" {
+		rqst.ChargeTo = &trillian.ChargeTo{User: []string{chargeTo}}
+	}
+	// per-RPC deadlines, retries, and instrumentation are applied by the
+	// interceptor chain configured in interceptors.go
 	resp, err := t.client.QueueLeaf(t.context, rqst)
+	if err == nil && (resp.QueuedLeaf.Status == nil || resp.QueuedLeaf.Status.Code == int32(codes.OK)) {
+		atomic.AddInt64(&queuedLeafCount, 1)
+		recentSubmissions.add(t.logID, hex.EncodeToString(resp.QueuedLeaf.Leaf.MerkleLeafHash))
+		// mirror the leaf to the shadow tree if a dual-write migration is in
+		// progress; the write above remains authoritative
+		go shadowQueueLeaf(byteValue)
+	}
+	return &Response{
+		status:       status.Code(err),
+		err:          err,
+		getAddResult: resp,
+	}
+}
+
+func (t *TrillianClient) addLeaf(byteValue []byte, chargeTo string) *Response {
+	queueResp := t.queueLeaf(byteValue, chargeTo)
+	resp, err := queueResp.getAddResult, queueResp.err
 
 	// check for error
 	if err != nil || (resp.QueuedLeaf.Status != nil && resp.QueuedLeaf.Status.Code != int32(codes.OK)) {
@@ -103,7 +157,11 @@ func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
 		}
 	}
 
-	root, err := t.root()
+	// the rest of this flow must observe the leaf just queued, so keep all
+	// reads on the primary even when a replica is configured
+	tp := t.withPrimaryReads()
+
+	root, err := tp.root()
 	if err != nil {
 		return &Response{
 			status:       status.Code(err),
@@ -111,7 +169,7 @@ func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
 			getAddResult: resp,
 		}
 	}
-	v := client.NewLogVerifier(rfc6962.DefaultHasher)
+	v := client.NewLogVerifier(treeHasher())
 	logClient := client.New(t.logID, t.client, v, root)
 
 	waitForInclusion := func(ctx context.Context, leafHash []byte) *Response {
@@ -128,7 +186,7 @@ func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
 		for {
 			root = *logClient.GetRoot()
 			if root.TreeSize >= 1 {
-				proofResp := t.getProofByHash(resp.QueuedLeaf.Leaf.MerkleLeafHash)
+				proofResp := tp.getProofByHash(resp.QueuedLeaf.Leaf.MerkleLeafHash)
 				// if this call succeeds or returns an error other than "not found", return
 				if proofResp.err == nil || (proofResp.err != nil && status.Code(proofResp.err) != codes.NotFound) {
 					return proofResp
@@ -165,7 +223,7 @@ func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
 	}
 
 	leafIndex := proofs[0].LeafIndex
-	leafResp := t.getLeafAndProofByIndex(leafIndex)
+	leafResp := tp.getLeafAndProofByIndex(leafIndex)
 	if leafResp.err != nil {
 		return &Response{
 			status:       status.Code(leafResp.err),
@@ -181,6 +239,9 @@ func (t *TrillianClient) addLeaf(byteValue []byte) *Response {
 		status:       status.Code(err),
 		err:          err,
 		getAddResult: resp,
+		// surface the verified proof fetched above so the create response can
+		// embed it without a second round trip
+		getLeafAndProofResult: leafResp.getLeafAndProofResult,
 	}
 }
 
@@ -207,8 +268,7 @@ func (t *TrillianClient) getLeafAndProofByHash(hash []byte) *Response {
 }
 
 func (t *TrillianClient) getLeafAndProofByIndex(index int64) *Response {
-	ctx, cancel := context.WithTimeout(t.context, 20*time.Second)
-	defer cancel()
+	ctx := t.context
 
 	root, err := t.root()
 	if err != nil {
@@ -218,7 +278,7 @@ func (t *TrillianClient) getLeafAndProofByIndex(index int64) *Response {
 		}
 	}
 
-	resp, err := t.client.GetEntryAndProof(ctx,
+	resp, err := t.reader.GetEntryAndProof(ctx,
 		&trillian.GetEntryAndProofRequest{
 			LogId:     t.logID,
 			LeafIndex: index,
@@ -226,7 +286,7 @@ func (t *TrillianClient) getLeafAndProofByIndex(index int64) *Response {
 		})
 
 	if resp != nil && resp.Proof != nil {
-		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(index), root.TreeSize, resp.GetLeaf().MerkleLeafHash, resp.Proof.Hashes, root.RootHash); err != nil {
+		if err := verify.VerifyInclusion(treeHasher(), uint64(index), root.TreeSize, resp.GetLeaf().MerkleLeafHash, resp.Proof.Hashes, root.RootHash); err != nil {
 			return &Response{
 				status: status.Code(err),
 				err:    err,
@@ -242,8 +302,7 @@ func (t *TrillianClient) getLeafAndProofByIndex(index int64) *Response {
 }
 
 func (t *TrillianClient) getProofByHash(hashValue []byte) *Response {
-	ctx, cancel := context.WithTimeout(t.context, 20*time.Second)
-	defer cancel()
+	ctx := t.context
 
 	root, err := t.root()
 	if err != nil {
@@ -253,7 +312,7 @@ func (t *TrillianClient) getProofByHash(hashValue []byte) *Response {
 		}
 	}
 
-	resp, err := t.client.GetInclusionProofByHash(ctx,
+	resp, err := t.reader.GetInclusionProofByHash(ctx,
 		&trillian.GetInclusionProofByHashRequest{
 			LogId:    t.logID,
 			LeafHash: hashValue,
@@ -261,14 +320,21 @@ func (t *TrillianClient) getProofByHash(hashValue []byte) *Response {
 		})
 
 	if resp != nil {
-		v := client.NewLogVerifier(rfc6962.DefaultHasher)
+		// responses may contain multiple proofs if the same leaf was integrated
+		// more than once; verify them concurrently
+		v := client.NewLogVerifier(treeHasher())
+		g, _ := errgroup.WithContext(ctx)
 		for _, proof := range resp.Proof {
-
-			if err := v.VerifyInclusionByHash(&root, hashValue, proof); err != nil {
-				return &Response{
-					status: status.Code(err),
-					err:    err,
-				}
+			proof := proof // https://golang.org/doc/faq#closures_and_goroutines
+			g.Go(func() error {
+				log.Logger.Debugf("verifying inclusion proof for leaf hash %v", hex.EncodeToString(hashValue))
+				return v.VerifyInclusionByHash(&root, hashValue, proof)
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return &Response{
+				status: status.Code(err),
+				err:    err,
 			}
 		}
 	}
@@ -280,12 +346,24 @@ func (t *TrillianClient) getProofByHash(hashValue []byte) *Response {
 	}
 }
 
-func (t *TrillianClient) getLatest(leafSizeInt int64) *Response {
+func (t *TrillianClient) getLeavesByRange(startIndex, count int64) *Response {
+	resp, err := t.reader.GetLeavesByRange(t.context,
+		&trillian.GetLeavesByRangeRequest{
+			LogId:      t.logID,
+			StartIndex: startIndex,
+			Count:      count,
+		})
+
+	return &Response{
+		status:                 status.Code(err),
+		err:                    err,
+		getLeavesByRangeResult: resp,
+	}
+}
 
-	ctx, cancel := context.WithTimeout(t.context, 20*time.Second)
-	defer cancel()
+func (t *TrillianClient) getLatest(leafSizeInt int64) *Response {
 
-	resp, err := t.client.GetLatestSignedLogRoot(ctx,
+	resp, err := t.reader.GetLatestSignedLogRoot(t.context,
 		&trillian.GetLatestSignedLogRootRequest{
 			LogId:         t.logID,
 			FirstTreeSize: leafSizeInt,
@@ -300,10 +378,7 @@ func (t *TrillianClient) getLatest(leafSizeInt int64) *Response {
 
 func (t *TrillianClient) getConsistencyProof(firstSize, lastSize int64) *Response {
 
-	ctx, cancel := context.WithTimeout(t.context, 20*time.Second)
-	defer cancel()
-
-	resp, err := t.client.GetConsistencyProof(ctx,
+	resp, err := t.reader.GetConsistencyProof(t.context,
 		&trillian.GetConsistencyProofRequest{
 			LogId:          t.logID,
 			FirstTreeSize:  firstSize,
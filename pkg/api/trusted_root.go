@@ -0,0 +1,114 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// logIDFromPEM computes the hex-encoded SHA-256 hash of the DER encoding of a
+// PEM public key, matching how the log identifies its own active key.
+func logIDFromPEM(pemKey string) string {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return ""
+	}
+	h := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(h[:])
+}
+
+// buildTrustedRoot assembles the trusted root document from the sharding
+// config and the active signing key.
+func buildTrustedRoot() util.TrustedRoot {
+	api := currentAPI()
+	tr := util.TrustedRoot{MediaType: util.TrustedRootMediaType}
+	for _, shard := range api.logRanges.GetInactive() {
+		pubKey, err := api.logRanges.PublicKey(api.pubkey, fmt.Sprintf("%d", shard.TreeID))
+		if err != nil {
+			// validated at startup; don't serve a partial document if it fails now
+			log.Logger.Errorf("resolving public key for shard %d: %v", shard.TreeID, err)
+			continue
+		}
+		entry := util.TrustedRootLog{
+			TreeID:     fmt.Sprintf("%d", shard.TreeID),
+			LogID:      logIDFromPEM(pubKey),
+			PublicKey:  pubKey,
+			Status:     "inactive",
+			TreeLength: shard.TreeLength,
+		}
+		if shard.NotBefore != "" || shard.NotAfter != "" {
+			entry.ValidFor = &util.ValidityWindow{Start: shard.NotBefore, End: shard.NotAfter}
+		}
+		tr.Logs = append(tr.Logs, entry)
+	}
+	tr.Logs = append(tr.Logs, util.TrustedRootLog{
+		TreeID:    fmt.Sprintf("%d", api.logID),
+		LogID:     api.pubkeyHash,
+		PublicKey: api.pubkey,
+		Status:    "active",
+	})
+	return tr
+}
+
+// TrustedRootHandler handles GET /api/v1/log/trustedRoot. It serves a signed
+// document describing every shard's public key, validity window, and frozen
+// size, so verifiers can bootstrap trust material programmatically instead of
+// copy-pasting PEMs.
+func TrustedRootHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	tr := buildTrustedRoot()
+	payload, err := json.Marshal(tr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	canonicalized, err := canonical.Transform(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sig, err := api.signer.SignMessage(bytes.NewReader(canonicalized), options.WithContext(r.Context()))
+	if err != nil {
+		log.Logger.Errorf("signing trusted root: %v", err)
+		http.Error(w, "signing trusted root", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(util.SignedTrustedRoot{
+		TrustedRoot: tr,
+		Signature:   base64.StdEncoding.EncodeToString(sig),
+	}); err != nil {
+		log.Logger.Errorf("writing trusted root response: %v", err)
+	}
+}
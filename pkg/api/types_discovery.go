@@ -0,0 +1,72 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// typeVersion describes one supported apiVersion of an entry kind, along with
+// the JSON schema its proposed entries must satisfy.
+type typeVersion struct {
+	APIVersion string          `json:"apiVersion"`
+	Schema     json.RawMessage `json:"schema,omitempty"`
+}
+
+// typeDescription describes one registered entry kind.
+type typeDescription struct {
+	Kind           string        `json:"kind"`
+	DefaultVersion string        `json:"defaultVersion"`
+	Versions       []typeVersion `json:"versions"`
+}
+
+// TypesHandler serves GET /api/v1/types: the entry kinds this server accepts,
+// their supported apiVersions, and the JSON schemas for their proposed entry
+// shapes, so client tooling can validate payloads before submission. The set
+// is fixed at compile time, so responses are cacheable.
+func TypesHandler(w http.ResponseWriter, r *http.Request) {
+	var resp []typeDescription
+	types.TypeMap.Range(func(k, v interface{}) bool {
+		kind := k.(string)
+		ti := v.(func() types.TypeImpl)()
+		desc := typeDescription{
+			Kind:           kind,
+			DefaultVersion: ti.DefaultVersion(),
+		}
+		versions := ti.SupportedVersions()
+		sort.Strings(versions)
+		for _, version := range versions {
+			desc.Versions = append(desc.Versions, typeVersion{
+				APIVersion: version,
+				Schema:     types.SchemaForVersion(kind, version),
+			})
+		}
+		resp = append(resp, desc)
+		return true
+	})
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Kind < resp[j].Kind })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.RequestIDLogger(r).Errorf("error writing types response: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/witness"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// witnessPolicy is the configured checkpoint cosignature requirement; nil
+// means checkpoints are served without witnessing.
+var witnessPolicy *witness.Policy
+
+func configureWitnessPolicy() error {
+	wp, err := witness.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	witnessPolicy = wp
+	if wp != nil {
+		log.Logger.Infof("Witness policy configured: %d cosignatures required before checkpoints are served as current", wp.Threshold())
+	}
+	return nil
+}
+
+// witnessedCheckpointState holds the latest checkpoint that satisfied the
+// witness policy, as submitted by the feeder or by witnesses directly.
+type witnessedCheckpointState struct {
+	sync.RWMutex
+	raw string
+	sc  *util.SignedCheckpoint
+}
+
+var witnessedCheckpoint = &witnessedCheckpointState{}
+
+func (w *witnessedCheckpointState) set(raw string, sc *util.SignedCheckpoint) {
+	w.Lock()
+	defer w.Unlock()
+	w.raw = raw
+	w.sc = sc
+}
+
+func (w *witnessedCheckpointState) current() (string, *util.SignedCheckpoint, bool) {
+	w.RLock()
+	defer w.RUnlock()
+	return w.raw, w.sc, w.sc != nil
+}
+
+// signedCheckpoint signs a checkpoint over the current root of the active
+// tree.
+func signedCheckpoint(ctx context.Context) (*util.SignedCheckpoint, error) {
+	api := currentAPI()
+	tc := NewTrillianClient(ctx)
+	root, err := tc.root()
+	if err != nil {
+		return nil, err
+	}
+	sth, err := util.CreateSignedCheckpoint(util.Checkpoint{
+		Origin: "Rekor",
+		Size:   root.TreeSize,
+		Hash:   root.RootHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sth.SetTimestamp(uint64(time.Now().UnixNano()))
+	if _, err := sth.Sign(viper.GetString("rekor_server.hostname"), api.signer, options.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+	return sth, nil
+}
+
+// RawCheckpointHandler serves GET /api/v1/log/checkpoint: a freshly signed
+// checkpoint over the current root, carrying only the log's own signature.
+// This is the document witnesses fetch and cosign; it is served regardless of
+// the witness policy, which only gates what is presented as current.
+func RawCheckpointHandler(w http.ResponseWriter, r *http.Request) {
+	api := currentAPI()
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	sth, err := signedCheckpoint(r.Context())
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error signing raw checkpoint: %v", err)
+		httpStatus := trillianHTTPStatus(status.Code(err))
+		http.Error(w, trillianErrorMessage(httpStatus), httpStatus)
+		return
+	}
+	scBytes, err := sth.SignedNote.MarshalText()
+	if err != nil {
+		log.RequestIDLogger(r).Errorf("error marshalling raw checkpoint: %v", err)
+		http.Error(w, sthGenerateError, http.StatusInternalServerError)
+		return
+	}
+	checkpointStore.record(api.logID, sth.Size, string(scBytes))
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(scBytes)
+}
+
+// WitnessedCheckpointHandler serves /api/v1/log/checkpoint/witnessed. GET
+// returns the latest checkpoint that satisfied the witness policy; POST
+// accepts a cosigned checkpoint from the feeder or from a witness, verifying
+// the log's own signature and the cosignature threshold before accepting it.
+func WitnessedCheckpointHandler(w http.ResponseWriter, r *http.Request) {
+	if witnessPolicy == nil {
+		http.Error(w, "no witness policy is configured on this server", http.StatusNotFound)
+		return
+	}
+	if !Ready() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		raw, _, ok := witnessedCheckpoint.current()
+		if !ok {
+			w.Header().Set("Cache-Control", "no-store")
+			http.Error(w, "no witnessed checkpoint is available yet", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		fmt.Fprint(w, raw)
+	case http.MethodPost:
+		contents, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 65536))
+		if err != nil {
+			http.Error(w, "reading request body", http.StatusBadRequest)
+			return
+		}
+		if err := acceptWitnessedCheckpoint(string(contents)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// acceptWitnessedCheckpoint validates a cosigned checkpoint and, if it passes
+// the witness policy, stores it as the current witnessed checkpoint.
+func acceptWitnessedCheckpoint(raw string) error {
+	api := currentAPI()
+	sc := &util.SignedCheckpoint{}
+	if err := sc.UnmarshalText([]byte(raw)); err != nil {
+		return fmt.Errorf("malformed checkpoint: %w", err)
+	}
+	logKey, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(api.pubkey))
+	if err != nil {
+		return fmt.Errorf("parsing log public key: %w", err)
+	}
+	if !witness.SignedBy(sc.SignedNote, logKey) {
+		return fmt.Errorf("checkpoint does not carry a signature by this log")
+	}
+	if err := witnessPolicy.Satisfied(sc); err != nil {
+		return err
+	}
+	if _, current, ok := witnessedCheckpoint.current(); ok && sc.Size < current.Size {
+		return fmt.Errorf("checkpoint size %d is behind the current witnessed checkpoint at size %d", sc.Size, current.Size)
+	}
+	witnessedCheckpoint.set(raw, sc)
+	log.Logger.Infof("Accepted witnessed checkpoint at size %d", sc.Size)
+	return nil
+}
+
+// startWitnessFeeder launches the built-in feeder when witness URLs are
+// configured alongside the policy, so operators do not need a separate feeder
+// deployment.
+func startWitnessFeeder(ctx context.Context) {
+	urls := viper.GetStringSlice("rekor_server.witness.urls")
+	if witnessPolicy == nil || len(urls) == 0 {
+		return
+	}
+	feeder := &witness.Feeder{
+		Policy:           witnessPolicy,
+		Witnesses:        urls,
+		Interval:         viper.GetDuration("rekor_server.witness.feed_interval"),
+		Latest:           signedCheckpoint,
+		ConsistencyProof: witnessConsistencyProof,
+		Submit:           acceptWitnessedCheckpoint,
+	}
+	log.Logger.Infof("Starting witness feeder against %d witnesses", len(urls))
+	go feeder.Run(ctx)
+}
+
+// witnessConsistencyProof fetches the consistency proof hashes between two
+// sizes of the active tree for catching up a witness that has fallen behind.
+func witnessConsistencyProof(ctx context.Context, first, last uint64) ([][]byte, error) {
+	tc := NewTrillianClient(ctx)
+	resp := tc.getConsistencyProof(int64(first), int64(last))
+	if resp.status != codes.OK {
+		return nil, resp.err
+	}
+	return resp.getConsistencyProofResult.GetProof().GetHashes(), nil
+}
+
+// witnessedTreeState returns the witnessed checkpoint's serialized form, size
+// and root hash for serving as the current log state. It returns a NotFound
+// gRPC-style error when no checkpoint has satisfied the policy yet, which
+// surfaces as 404 via the usual Trillian error mapping.
+func witnessedTreeState() (string, int64, []byte, error) {
+	raw, sc, ok := witnessedCheckpoint.current()
+	if !ok {
+		return "", 0, nil, status.Error(codes.NotFound, "no checkpoint has satisfied the witness policy yet")
+	}
+	return raw, int64(sc.Size), sc.Hash, nil
+}
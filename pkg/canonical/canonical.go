@@ -0,0 +1,42 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canonical is the single place Rekor canonicalizes JSON. Every leaf
+// stored in the log is the RFC 8785 (JSON Canonicalization Scheme) form of
+// its entry, so any two implementations that agree on these functions agree
+// on leaf hashes. The test vectors under testdata let independent clients
+// prove their canonicalization is byte-for-byte identical.
+package canonical
+
+import (
+	"encoding/json"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+)
+
+// Transform canonicalizes a raw JSON document per RFC 8785.
+func Transform(raw []byte) ([]byte, error) {
+	return jsoncanonicalizer.Transform(raw)
+}
+
+// Marshal serializes a value to canonical JSON; it is equivalent to
+// encoding/json marshalling followed by Transform.
+func Marshal(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Transform(raw)
+}
@@ -0,0 +1,85 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canonical
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVectors proves Transform against the shipped corpus; independent
+// clients can run the same testdata files through their implementation to
+// confirm they compute identical leaf bytes.
+func TestVectors(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test vectors found in testdata")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input.json")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", name+".output.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Transform(raw)
+			if err != nil {
+				t.Fatalf("Transform: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Transform mismatch:\n got: %s\nwant: %s", got, want)
+			}
+			// canonical output must be a fixed point
+			again, err := Transform(got)
+			if err != nil {
+				t.Fatalf("Transform of canonical form: %v", err)
+			}
+			if !bytes.Equal(again, got) {
+				t.Errorf("Transform is not idempotent:\nfirst:  %s\nsecond: %s", got, again)
+			}
+		})
+	}
+}
+
+func TestTransformRejectsMalformedJSON(t *testing.T) {
+	if _, err := Transform([]byte(`{"unterminated": `)); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	got, err := Marshal(map[string]interface{}{
+		"zebra": 1,
+		"apple": "two",
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"apple":"two","zebra":1}`
+	if string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
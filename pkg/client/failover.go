@@ -0,0 +1,118 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// endpointCooldown is how long an endpoint sits out after a failed request
+// before read traffic is routed to it again.
+const endpointCooldown = 30 * time.Second
+
+// endpointState tracks the health of one read endpoint.
+type endpointState struct {
+	scheme string
+	host   string
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func (e *endpointState) healthy() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Now().After(e.downUntil)
+}
+
+func (e *endpointState) markDown() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.downUntil = time.Now().Add(endpointCooldown)
+}
+
+// failoverTransport routes read requests (GET and HEAD) across the primary
+// endpoint and a set of mirrors, skipping endpoints that recently failed.
+// Writes always go to the primary, as mirrors are read replicas of the log.
+type failoverTransport struct {
+	inner     http.RoundTripper
+	endpoints []*endpointState // primary first
+}
+
+// newFailoverTransport wraps inner so reads fail over from primaryURL to
+// mirrors in order.
+func newFailoverTransport(inner http.RoundTripper, primaryURL string, mirrors []string) (*failoverTransport, error) {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	t := &failoverTransport{inner: inner}
+	for _, base := range append([]string{primaryURL}, mirrors...) {
+		u, err := url.Parse(base)
+		if err != nil {
+			return nil, fmt.Errorf("parsing mirror URL %v: %w", base, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("mirror URL %v must include a scheme and host", base)
+		}
+		t.endpoints = append(t.endpoints, &endpointState{scheme: u.Scheme, host: u.Host})
+	}
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.inner.RoundTrip(req)
+	}
+
+	candidates := make([]*endpointState, 0, len(t.endpoints))
+	for _, e := range t.endpoints {
+		if e.healthy() {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		// every endpoint is cooling down; try them all rather than failing
+		// without sending anything
+		candidates = t.endpoints
+	}
+
+	var lastErr error
+	for i, e := range candidates {
+		attempt := req.Clone(req.Context())
+		attempt.URL.Scheme = e.scheme
+		attempt.URL.Host = e.host
+		attempt.Host = ""
+
+		resp, err := t.inner.RoundTrip(attempt)
+		if err != nil {
+			e.markDown()
+			lastErr = fmt.Errorf("read from %s://%s: %w", e.scheme, e.host, err)
+			continue
+		}
+		if resp.StatusCode >= 500 && i < len(candidates)-1 {
+			e.markDown()
+			lastErr = fmt.Errorf("read from %s://%s: unexpected status %v", e.scheme, e.host, resp.Status)
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFailoverTransportReadsFailOver(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "degraded", http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "mirror")
+	}))
+	defer mirror.Close()
+
+	ft, err := newFailoverTransport(nil, primary.URL, []string{mirror.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, primary.URL+"/api/v1/log", nil)
+	resp, err := ft.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("expected failover to mirror, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "mirror" {
+		t.Errorf("expected response from mirror, got %q", body)
+	}
+
+	// the primary is now cooling down, so the next read skips it entirely
+	if ft.endpoints[0].healthy() {
+		t.Error("expected primary to be marked down")
+	}
+}
+
+func TestFailoverTransportWritesStayOnPrimary(t *testing.T) {
+	var mirrorHits int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "primary")
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorHits++
+		io.WriteString(w, "mirror")
+	}))
+	defer mirror.Close()
+
+	ft, err := newFailoverTransport(nil, primary.URL, []string{mirror.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, primary.URL+"/api/v1/log/entries", strings.NewReader("{}"))
+	resp, err := ft.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "primary" {
+		t.Errorf("expected write to hit primary, got %q", body)
+	}
+	if mirrorHits != 0 {
+		t.Errorf("expected no mirror traffic for writes, got %d hits", mirrorHits)
+	}
+}
+
+func TestFailoverTransportLastEndpointErrorSurfaces(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "degraded", http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	ft, err := newFailoverTransport(nil, primary.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with a single endpoint the 5xx response is returned as-is so callers
+	// (and the retry layer) can decide what to do with it
+	req, _ := http.NewRequest(http.MethodGet, primary.URL+"/api/v1/log", nil)
+	resp, err := ft.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 from sole endpoint, got %v", resp.Status)
+	}
+}
+
+func TestNewFailoverTransportRejectsBadURLs(t *testing.T) {
+	if _, err := newFailoverTransport(nil, "https://rekor.example.com", []string{"not-a-url"}); err == nil {
+		t.Error("expected error for mirror URL without scheme")
+	}
+}
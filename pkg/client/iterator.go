@@ -0,0 +1,228 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/sharding"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
+)
+
+const (
+	// iteratorBatchSize is how many log indexes are requested per
+	// searchLogQuery page while iterating.
+	iteratorBatchSize = 10
+	// iteratorRetries is how many times a failed page fetch is retried
+	// before the iterator gives up.
+	iteratorRetries = 3
+	// iteratorPollInterval is how long the iterator waits before checking
+	// for new entries once it has caught up with the log head.
+	iteratorPollInterval = 10 * time.Second
+)
+
+// VerifiedEntry is one element of the stream produced by StreamVerifiedEntries.
+// Either Entry is populated with an entry whose inclusion proof and signed
+// entry timestamp have been verified, or Err carries the error that ended the
+// stream; the channel is closed after an element with Err set is delivered.
+type VerifiedEntry struct {
+	// EntryUUID is the entry's UUID (the merkle leaf hash), without a tree
+	// ID prefix.
+	EntryUUID string
+	Entry     models.LogEntryAnon
+	Err       error
+}
+
+// StreamVerifiedEntries returns a channel of verified log entries in log
+// order, starting at the given virtual log index. Pages of entries are
+// fetched ahead of the consumer, transient fetch errors are retried, and each
+// entry's inclusion proof and signed entry timestamp are verified against the
+// log's public key before it is delivered, so a monitor is a for-range loop
+// over the result. Once the iterator reaches the log head it polls for new
+// entries until the context is cancelled; cancellation closes the channel.
+func (c *Client) StreamVerifiedEntries(ctx context.Context, startIndex int64) <-chan VerifiedEntry {
+	out := make(chan VerifiedEntry)
+	go func() {
+		defer close(out)
+
+		pub, err := util.PublicKey(ctx, c.Rekor)
+		if err != nil {
+			emit(ctx, out, VerifiedEntry{Err: fmt.Errorf("fetching log public key: %w", err)})
+			return
+		}
+
+		index := startIndex
+		for ctx.Err() == nil {
+			treeSize, err := c.waitForTreeSize(ctx, index+1)
+			if err != nil {
+				if ctx.Err() == nil {
+					emit(ctx, out, VerifiedEntry{Err: err})
+				}
+				return
+			}
+
+			batch := treeSize - index
+			if batch > iteratorBatchSize {
+				batch = iteratorBatchSize
+			}
+			entries, err := c.fetchPage(ctx, index, batch)
+			if err != nil {
+				if ctx.Err() == nil {
+					emit(ctx, out, VerifiedEntry{Err: err})
+				}
+				return
+			}
+
+			for _, le := range entries {
+				ve, err := verifyStreamedEntry(le, index, pub)
+				if err != nil {
+					emit(ctx, out, VerifiedEntry{Err: fmt.Errorf("entry at index %d: %w", index, err)})
+					return
+				}
+				if !emit(ctx, out, ve) {
+					return
+				}
+				index++
+			}
+		}
+	}()
+	return out
+}
+
+// emit delivers e unless the context is cancelled first; it reports whether
+// the delivery happened.
+func emit(ctx context.Context, out chan<- VerifiedEntry, e VerifiedEntry) bool {
+	select {
+	case out <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// waitForTreeSize polls the log until its tree size (across all shards)
+// reaches at least minSize, returning the observed size.
+func (c *Client) waitForTreeSize(ctx context.Context, minSize int64) (int64, error) {
+	for {
+		li, err := c.getLogInfoWithRetry(ctx)
+		if err != nil {
+			return 0, err
+		}
+		size := swag.Int64Value(li.TreeSize)
+		for _, shard := range li.InactiveShards {
+			size += swag.Int64Value(shard.TreeSize)
+		}
+		if size >= minSize {
+			return size, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(iteratorPollInterval):
+		}
+	}
+}
+
+func (c *Client) getLogInfoWithRetry(ctx context.Context) (li *models.LogInfo, err error) {
+	for attempt := 0; attempt <= iteratorRetries; attempt++ {
+		if li, err = c.GetLogInfo(ctx); err == nil {
+			return li, nil
+		}
+		if !sleepCtx(ctx, time.Duration(attempt+1)*time.Second) {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("fetching log info: %w", err)
+}
+
+// fetchPage retrieves count entries starting at startIndex, retrying
+// transient failures, and returns them ordered by log index.
+func (c *Client) fetchPage(ctx context.Context, startIndex, count int64) (page []models.LogEntry, err error) {
+	query := &models.SearchLogQuery{}
+	for i := int64(0); i < count; i++ {
+		query.LogIndexes = append(query.LogIndexes, swag.Int64(startIndex+i))
+	}
+
+	for attempt := 0; attempt <= iteratorRetries; attempt++ {
+		if page, err = c.SearchLogQuery(ctx, query); err == nil {
+			if int64(len(page)) != count {
+				return nil, fmt.Errorf("expected %d entries starting at index %d, log returned %d", count, startIndex, len(page))
+			}
+			return page, nil
+		}
+		if !sleepCtx(ctx, time.Duration(attempt+1)*time.Second) {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("fetching entries starting at index %d: %w", startIndex, err)
+}
+
+// verifyStreamedEntry checks that the entry sits at the expected index and
+// that its leaf hash, inclusion proof, and signed entry timestamp all verify.
+func verifyStreamedEntry(le models.LogEntry, expectedIndex int64, pub crypto.PublicKey) (VerifiedEntry, error) {
+	for id, anon := range le {
+		uuid, err := sharding.GetUUIDFromIDString(id)
+		if err != nil {
+			return VerifiedEntry{}, err
+		}
+		if got := swag.Int64Value(anon.LogIndex); got != expectedIndex {
+			return VerifiedEntry{}, fmt.Errorf("log returned index %d", got)
+		}
+		body, err := base64.StdEncoding.DecodeString(anon.Body.(string))
+		if err != nil {
+			return VerifiedEntry{}, fmt.Errorf("decoding body: %w", err)
+		}
+		leafHash, err := hex.DecodeString(uuid)
+		if err != nil {
+			return VerifiedEntry{}, fmt.Errorf("decoding entry UUID: %w", err)
+		}
+		if !bytes.Equal(verify.ComputeLeafHash(nil, body), leafHash) {
+			return VerifiedEntry{}, fmt.Errorf("computed leaf hash does not match entry UUID %v", uuid)
+		}
+		if anon.Verification == nil {
+			return VerifiedEntry{}, fmt.Errorf("no verification material returned")
+		}
+		if err := VerifyInclusionProof(anon.Verification.InclusionProof, leafHash); err != nil {
+			return VerifiedEntry{}, fmt.Errorf("verifying inclusion proof: %w", err)
+		}
+		if err := VerifySignedEntryTimestamp(anon, pub); err != nil {
+			return VerifiedEntry{}, err
+		}
+		return VerifiedEntry{EntryUUID: uuid, Entry: anon}, nil
+	}
+	return VerifiedEntry{}, fmt.Errorf("log returned an empty entry")
+}
+
+// sleepCtx sleeps for d unless the context is cancelled first; it reports
+// whether the full duration elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -20,12 +20,22 @@ import "net/http"
 type Option func(*options)
 
 type options struct {
-	UserAgent string
+	UserAgent   string
+	RetryCount  uint
+	Transport   http.RoundTripper
+	ReadMirrors []string
 }
 
+const (
+	// DefaultRetryCount is the default number of retries for retryable
+	// (429/5xx or transport-level) failures.
+	DefaultRetryCount = 3
+)
+
 func makeOptions(opts ...Option) *options {
 	o := &options{
-		UserAgent: "",
+		UserAgent:  "",
+		RetryCount: DefaultRetryCount,
 	}
 
 	for _, opt := range opts {
@@ -42,6 +52,32 @@ func WithUserAgent(userAgent string) Option {
 	}
 }
 
+// WithRetryCount sets the number of retries on retryable HTTP failures;
+// 0 disables retries entirely.
+func WithRetryCount(retryCount uint) Option {
+	return func(o *options) {
+		o.RetryCount = retryCount
+	}
+}
+
+// WithTransport sets the base http.RoundTripper used for requests, so callers
+// can layer in their own auth headers, tracing, or proxy handling; retries and
+// the user-agent header are still applied on top of it.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.Transport = rt
+	}
+}
+
+// WithReadMirrors adds base URLs of read mirrors for the log. Read operations
+// fail over from the primary to the mirrors in order when an endpoint errors
+// or serves 5xx responses; writes always go to the primary.
+func WithReadMirrors(urls ...string) Option {
+	return func(o *options) {
+		o.ReadMirrors = urls
+	}
+}
+
 type roundTripper struct {
 	http.RoundTripper
 	UserAgent string
@@ -29,11 +29,15 @@ func TestMakeOptions(t *testing.T) {
 		want *options
 	}{{
 		desc: "no opts",
-		want: &options{},
+		want: &options{RetryCount: DefaultRetryCount},
 	}, {
 		desc: "WithUserAgent",
 		opts: []Option{WithUserAgent("test user agent")},
-		want: &options{UserAgent: "test user agent"},
+		want: &options{UserAgent: "test user agent", RetryCount: DefaultRetryCount},
+	}, {
+		desc: "WithRetryCount",
+		opts: []Option{WithRetryCount(2)},
+		want: &options{RetryCount: 2},
 	}}
 	for _, tc := range tests {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -45,6 +49,14 @@ func TestMakeOptions(t *testing.T) {
 	}
 }
 
+func TestWithTransport(t *testing.T) {
+	expected := &mockRoundTripper{}
+	o := makeOptions(WithTransport(expected))
+	if o.Transport != expected {
+		t.Errorf("WithTransport() expected %v, got %v", expected, o.Transport)
+	}
+}
+
 type mockRoundTripper struct {
 	gotReqs []*http.Request
 
@@ -20,6 +20,7 @@ import (
 	"github.com/go-openapi/runtime"
 	httptransport "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
 	"github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/rekor/pkg/util"
 	"github.com/spf13/viper"
@@ -32,8 +33,32 @@ func GetRekorClient(rekorServerURL string, opts ...Option) (*client.Rekor, error
 	}
 	o := makeOptions(opts...)
 
-	rt := httptransport.New(url.Host, client.DefaultBasePath, []string{url.Scheme})
+	// the retryable client backs off exponentially on 429/5xx responses,
+	// honors Retry-After, and respects request context cancellation
+	retryableClient := retryablehttp.NewClient()
+	retryableClient.RetryMax = int(o.RetryCount)
+	retryableClient.Logger = nil
+	if o.Transport != nil {
+		retryableClient.HTTPClient.Transport = o.Transport
+	}
+	if len(o.ReadMirrors) > 0 {
+		// place failover beneath the retry layer so each retry attempt is
+		// free to pick a different healthy endpoint
+		failover, err := newFailoverTransport(retryableClient.HTTPClient.Transport, rekorServerURL, o.ReadMirrors)
+		if err != nil {
+			return nil, err
+		}
+		retryableClient.HTTPClient.Transport = failover
+	}
+
+	httpClient := retryableClient.StandardClient()
+	httpClient.Transport = createRoundTripper(httpClient.Transport, o)
+
+	rt := httptransport.NewWithClient(url.Host, client.DefaultBasePath, []string{url.Scheme}, httpClient)
 	rt.Consumers["application/yaml"] = YamlConsumer()
+	// error bodies are served as RFC 7807 problem documents; parse them as JSON
+	// so typed error responses (e.g. conflicts) are still recognized
+	rt.Consumers["application/problem+json"] = runtime.JSONConsumer()
 	rt.Consumers["application/x-pem-file"] = runtime.TextConsumer()
 	rt.Consumers["application/pem-certificate-chain"] = runtime.TextConsumer()
 	rt.Producers["application/yaml"] = YamlProducer()
@@ -44,8 +69,6 @@ func GetRekorClient(rekorServerURL string, opts ...Option) (*client.Rekor, error
 		rt.DefaultAuthentication = httptransport.APIKeyAuth("apiKey", "query", viper.GetString("api-key"))
 	}
 
-	rt.Transport = createRoundTripper(rt.Transport, o)
-
 	registry := strfmt.Default
 	registry.Add("signedCheckpoint", &util.SignedNote{}, util.SignedCheckpointValidator)
 	return client.New(rt, registry), nil
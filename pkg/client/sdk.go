@@ -0,0 +1,148 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/client/index"
+	"github.com/sigstore/rekor/pkg/generated/client/pubkey"
+	"github.com/sigstore/rekor/pkg/generated/client/tlog"
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// Client is a typed convenience wrapper around the generated Rekor API client
+// for downstream Go tools. It exposes the common operations — upload, get,
+// search, proofs, and log info — without requiring callers to construct
+// go-openapi parameter objects themselves.
+type Client struct {
+	// Rekor is the underlying generated client, available for operations not
+	// covered by the typed helpers.
+	Rekor *client.Rekor
+}
+
+// New returns a typed Rekor client for the given base URL. The same options
+// accepted by GetRekorClient (user agent, custom transports, ...) apply.
+func New(rekorServerURL string, opts ...Option) (*Client, error) {
+	c, err := GetRekorClient(rekorServerURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Rekor: c}, nil
+}
+
+// Upload submits a proposed entry to the log and returns the created entry.
+func (c *Client) Upload(ctx context.Context, pe models.ProposedEntry) (models.LogEntry, error) {
+	params := entries.NewCreateLogEntryParamsWithContext(ctx)
+	params.SetProposedEntry(pe)
+	resp, err := c.Rekor.Entries.CreateLogEntry(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// GetLogEntryByUUID returns the entry and inclusion proof for the given entry
+// UUID (with or without a treeID prefix).
+func (c *Client) GetLogEntryByUUID(ctx context.Context, entryUUID string) (models.LogEntry, error) {
+	params := entries.NewGetLogEntryByUUIDParamsWithContext(ctx)
+	params.SetEntryUUID(entryUUID)
+	resp, err := c.Rekor.Entries.GetLogEntryByUUID(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// GetLogEntryByIndex returns the entry and inclusion proof at the given
+// virtual log index.
+func (c *Client) GetLogEntryByIndex(ctx context.Context, logIndex int64) (models.LogEntry, error) {
+	params := entries.NewGetLogEntryByIndexParamsWithContext(ctx)
+	params.SetLogIndex(logIndex)
+	resp, err := c.Rekor.Entries.GetLogEntryByIndex(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// SearchIndex returns the UUIDs of entries matching the given query.
+func (c *Client) SearchIndex(ctx context.Context, query *models.SearchIndex) ([]string, error) {
+	params := index.NewSearchIndexParamsWithContext(ctx)
+	params.SetQuery(query)
+	resp, err := c.Rekor.Index.SearchIndex(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// SearchLogQuery returns entries matching the given log query of entry UUIDs,
+// log indexes, and/or proposed entries.
+func (c *Client) SearchLogQuery(ctx context.Context, query *models.SearchLogQuery) ([]models.LogEntry, error) {
+	params := entries.NewSearchLogQueryParamsWithContext(ctx)
+	params.SetEntry(query)
+	resp, err := c.Rekor.Entries.SearchLogQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// GetLogInfo returns the current root hash, tree size, and signed tree head
+// of the log, along with any inactive shard details.
+func (c *Client) GetLogInfo(ctx context.Context) (*models.LogInfo, error) {
+	params := tlog.NewGetLogInfoParamsWithContext(ctx)
+	resp, err := c.Rekor.Tlog.GetLogInfo(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// GetLogProof returns a consistency proof between two snapshots of the log;
+// treeID may be empty to query the active shard.
+func (c *Client) GetLogProof(ctx context.Context, firstSize, lastSize int64, treeID string) (*models.ConsistencyProof, error) {
+	params := tlog.NewGetLogProofParamsWithContext(ctx)
+	params.SetFirstSize(&firstSize)
+	params.SetLastSize(lastSize)
+	if treeID != "" {
+		params.SetTreeID(swag.String(treeID))
+	}
+	resp, err := c.Rekor.Tlog.GetLogProof(params)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// GetPublicKey returns the PEM-encoded public key of the log; treeID may be
+// empty to fetch the active shard's key.
+func (c *Client) GetPublicKey(ctx context.Context, treeID string) (string, error) {
+	params := pubkey.NewGetPublicKeyParamsWithContext(ctx)
+	if treeID != "" {
+		params.SetTreeID(swag.String(treeID))
+	}
+	resp, err := c.Rekor.Pubkey.GetPublicKey(params)
+	if err != nil {
+		return "", err
+	}
+	return resp.GetPayload(), nil
+}
@@ -0,0 +1,163 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+	gclient "github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/rekor/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// VerifySignedEntryTimestamp verifies the SET over a log entry against the
+// log's public key, proving the entry was witnessed by the log even before
+// it has been integrated.
+func VerifySignedEntryTimestamp(entry models.LogEntryAnon, pub crypto.PublicKey) error {
+	if entry.Verification == nil || entry.Verification.SignedEntryTimestamp == nil {
+		return errors.New("signed entry timestamp missing from entry")
+	}
+
+	// the SET is computed over the canonicalized entry without verification material
+	le := &models.LogEntryAnon{
+		IntegratedTime: entry.IntegratedTime,
+		LogIndex:       entry.LogIndex,
+		Body:           entry.Body,
+		LogID:          entry.LogID,
+	}
+	payload, err := le.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	canonicalized, err := canonical.Transform(payload)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(entry.Verification.SignedEntryTimestamp), bytes.NewReader(canonicalized)); err != nil {
+		return fmt.Errorf("verifying signed entry timestamp: %w", err)
+	}
+	return nil
+}
+
+// VerifyInclusionProof verifies that the given merkle leaf hash is included in
+// the tree described by the proof's root hash and tree size.
+func VerifyInclusionProof(ip *models.InclusionProof, leafHash []byte) error {
+	if ip == nil {
+		return errors.New("inclusion proof missing")
+	}
+	rootHash, err := hex.DecodeString(swag.StringValue(ip.RootHash))
+	if err != nil {
+		return fmt.Errorf("decoding root hash: %w", err)
+	}
+	hashes := make([][]byte, len(ip.Hashes))
+	for i, h := range ip.Hashes {
+		if hashes[i], err = hex.DecodeString(h); err != nil {
+			return fmt.Errorf("decoding proof hash: %w", err)
+		}
+	}
+	return verify.VerifyInclusion(nil, uint64(swag.Int64Value(ip.LogIndex)),
+		uint64(swag.Int64Value(ip.TreeSize)), leafHash, hashes, rootHash)
+}
+
+// VerifyCheckpoint verifies the log signature on a signed checkpoint against
+// the log's public key.
+func VerifyCheckpoint(sth *util.SignedCheckpoint, pub crypto.PublicKey) error {
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	if !sth.Verify(verifier) {
+		return errors.New("checkpoint signature verification failed")
+	}
+	return nil
+}
+
+// VerifyConsistency verifies that the log rooted at the new checkpoint is an
+// append-only extension of the log rooted at the old checkpoint, using the
+// supplied consistency proof hashes.
+func VerifyConsistency(oldC, newC *util.Checkpoint, proofHashes []string) error {
+	if oldC == nil || newC == nil {
+		return errors.New("both checkpoints must be provided")
+	}
+	hashes := make([][]byte, len(proofHashes))
+	var err error
+	for i, h := range proofHashes {
+		if hashes[i], err = hex.DecodeString(h); err != nil {
+			return fmt.Errorf("decoding proof hash: %w", err)
+		}
+	}
+	return verify.VerifyConsistency(nil, oldC.Size, newC.Size, hashes, oldC.Hash, newC.Hash)
+}
+
+// VerifyInclusionPromise verifies the log's signature over an inclusion
+// promise returned with a 202 response, proving the log committed to
+// integrating the entry within its max merge delay.
+func VerifyInclusionPromise(p util.SignedInclusionPromise, pub crypto.PublicKey) error {
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	return p.Verify(verifier)
+}
+
+// ConfirmInclusionPromise checks that a previously promised entry has since
+// been integrated into the log: it verifies the promise signature, fetches
+// the entry by its promised leaf hash, and verifies the returned inclusion
+// proof against that hash. It returns the integrated entry on success so
+// callers can retain the full verification material.
+func ConfirmInclusionPromise(ctx context.Context, c *gclient.Rekor, p util.SignedInclusionPromise, pub crypto.PublicKey) (*models.LogEntryAnon, error) {
+	if err := VerifyInclusionPromise(p, pub); err != nil {
+		return nil, err
+	}
+	leafHash, err := hex.DecodeString(p.InclusionPromise.LeafHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding promised leaf hash: %w", err)
+	}
+
+	params := entries.NewGetLogEntryByUUIDParamsWithContext(ctx)
+	params.EntryUUID = p.InclusionPromise.LeafHash
+	resp, err := c.Entries.GetLogEntryByUUID(params)
+	if err != nil {
+		return nil, fmt.Errorf("entry not yet integrated: %w", err)
+	}
+	for _, entry := range resp.Payload {
+		entry := entry
+		if entry.Verification == nil || entry.Verification.InclusionProof == nil {
+			return nil, errors.New("integrated entry is missing an inclusion proof")
+		}
+		if err := VerifyInclusionProof(entry.Verification.InclusionProof, leafHash); err != nil {
+			return nil, err
+		}
+		return &entry, nil
+	}
+	return nil, errors.New("entry not found in log")
+}
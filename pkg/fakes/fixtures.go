@@ -0,0 +1,83 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakes
+
+import (
+	"encoding/base64"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// The fixtures below were captured from a dev-mode rekor-server after
+// uploading a hashedrekord entry for the artifact "hello\n". The signed entry
+// timestamp verifies against SampleLogPublicKey, and the inclusion proof is
+// valid for a tree containing only this entry.
+const (
+	// SampleArtifact is the artifact content the sample entry attests to.
+	SampleArtifact = "hello\n"
+
+	// SampleArtifactDigest is the SHA-256 digest of SampleArtifact.
+	SampleArtifactDigest = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+	// SampleEntryUUID is the sharded entry ID of the sample entry.
+	SampleEntryUUID = "0000000000000001e3f5c14f34d5322f1f179dbee6e77b9e3917b213d87e72a658d223057a69b5b6"
+
+	// SampleLogID is the SHA-256 hash of the log's DER-encoded public key.
+	SampleLogID = "45fbe45649ce9fded6a6de4f8506bc76ef61209c53014b6730e45d2d61171a6f"
+
+	// SampleLogPublicKey is the PEM-encoded public key of the log that signed
+	// the sample entry's timestamp.
+	SampleLogPublicKey = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEVAHUSx6iP7A/UiZIs8J/50g9J4CD
+mk17rsdtUcGgAXy5mWDrq9jJKWiJOFySIhIvuYjsLNf4/Go/vaxOIgJZfQ==
+-----END PUBLIC KEY-----
+`
+
+	// SampleArtifactPublicKey is the PEM-encoded public key that verifies the
+	// artifact signature embedded in the sample entry body.
+	SampleArtifactPublicKey = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEwJvo6DXvAFfBscSvx4e1WgOILgV4
+dYiL+maPmcVTGS9gXJcLtyITsufWwjqs8R5NcQmDQCdpDm6dq8IfNccySQ==
+-----END PUBLIC KEY-----
+`
+
+	sampleEntryBody = "eyJhcGlWZXJzaW9uIjoiMC4wLjEiLCJraW5kIjoiaGFzaGVkcmVrb3JkIiwic3BlYyI6eyJkYXRhIjp7Imhhc2giOnsiYWxnb3JpdGhtIjoic2hhMjU2IiwidmFsdWUiOiI1ODkxYjViNTIyZDVkZjA4NmQwZmYwYjExMGZiZDlkMjFiYjRmYzcxNjNhZjM0ZDA4Mjg2YTJlODQ2ZjZiZTAzIn19LCJzaWduYXR1cmUiOnsiY29udGVudCI6Ik1FVUNJRHhjcHA1dFBncFZWUmJnMllmRkVDcGVCSjV0a3lhandiVmVBdy9pWjh1a0FpRUEyQ2ZaT0hzb3kzZjluVk1mZU55OEdOYXJJVmVYVUxGdlhDYSs2aldPQThvPSIsInB1YmxpY0tleSI6eyJjb250ZW50IjoiTFMwdExTMUNSVWRKVGlCUVZVSk1TVU1nUzBWWkxTMHRMUzBLVFVacmQwVjNXVWhMYjFwSmVtb3dRMEZSV1VsTGIxcEplbW93UkVGUlkwUlJaMEZGZDBwMmJ6WkVXSFpCUm1aQ2MyTlRkbmcwWlRGWFowOUpUR2RXTkFwa1dXbE1LMjFoVUcxalZsUkhVemxuV0VwalRIUjVTVlJ6ZFdaWGQycHhjemhTTlU1alVXMUVVVU5rY0VSdE5tUnhPRWxtVG1OamVWTlJQVDBLTFMwdExTMUZUa1FnVUZWQ1RFbERJRXRGV1MwdExTMHRDZz09In19fX0="
+)
+
+// SampleEntry returns the canned hashedrekord log entry described by the
+// fixture constants, suitable for registering on a fake Server with
+// Server.AddEntry(SampleEntryUUID, fakes.SampleEntry()).
+func SampleEntry() models.LogEntryAnon {
+	set, _ := base64.StdEncoding.DecodeString("MEYCIQDQLc7DFSnV5tNn8lUSEldLGYybYBSxNgmyy4ASZ1e5kgIhAI15dAV2Nih5+eV1GzjBJmgI/1beqsHydQcMHq0mWF55")
+	return models.LogEntryAnon{
+		Body:           sampleEntryBody,
+		IntegratedTime: swag.Int64(1788129480),
+		LogID:          swag.String(SampleLogID),
+		LogIndex:       swag.Int64(0),
+		Verification: &models.LogEntryAnonVerification{
+			InclusionProof: &models.InclusionProof{
+				Hashes:   []string{},
+				LogIndex: swag.Int64(0),
+				RootHash: swag.String("e3f5c14f34d5322f1f179dbee6e77b9e3917b213d87e72a658d223057a69b5b6"),
+				TreeSize: swag.Int64(1),
+			},
+			SignedEntryTimestamp: strfmt.Base64(set),
+		},
+	}
+}
@@ -0,0 +1,200 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// Server is an httptest-backed fake of the Rekor REST API. It serves canned
+// entries registered with AddEntry rather than operating a real log, so
+// clients built on the generated SDK can be exercised without network access
+// or a running rekor-server. Close it when done, as with any httptest server.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.RWMutex
+	entries map[string]models.LogEntryAnon // keyed by entry UUID
+	order   []string                       // UUIDs in log index order
+	index   map[string][]string            // search index key -> UUIDs
+	pubKey  string                         // PEM-encoded log public key
+}
+
+// NewServer starts a fake Rekor server with no entries. The advertised log
+// public key is SampleLogPublicKey, matching the verification material on
+// SampleEntry.
+func NewServer() *Server {
+	s := &Server{
+		entries: make(map[string]models.LogEntryAnon),
+		index:   make(map[string][]string),
+		pubKey:  SampleLogPublicKey,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/log", s.logInfo)
+	mux.HandleFunc("/api/v1/log/publicKey", s.publicKey)
+	mux.HandleFunc("/api/v1/log/entries", s.entriesByIndex)
+	mux.HandleFunc("/api/v1/log/entries/retrieve", s.searchEntries)
+	mux.HandleFunc("/api/v1/log/entries/", s.entryByUUID)
+	mux.HandleFunc("/api/v1/index/retrieve", s.searchIndex)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AddEntry registers a canned entry under the given UUID; entries are
+// assigned log indexes in insertion order for logIndex-based lookups.
+func (s *Server) AddEntry(uuid string, entry models.LogEntryAnon) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[uuid]; !ok {
+		s.order = append(s.order, uuid)
+	}
+	s.entries[uuid] = entry
+}
+
+// AddIndex registers search index hits, mirroring what the retrieve API
+// would return for the given key (an artifact hash, email, or key hash).
+func (s *Server) AddIndex(key string, uuids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index[strings.ToLower(key)] = append(s.index[strings.ToLower(key)], uuids...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &models.Error{Code: int64(status), Message: msg})
+}
+
+func (s *Server) logInfo(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rootHash := strings.Repeat("0", 64)
+	if n := len(s.order); n > 0 {
+		if v := s.entries[s.order[n-1]].Verification; v != nil && v.InclusionProof != nil {
+			rootHash = swag.StringValue(v.InclusionProof.RootHash)
+		}
+	}
+	writeJSON(w, http.StatusOK, &models.LogInfo{
+		RootHash:       swag.String(rootHash),
+		TreeSize:       swag.Int64(int64(len(s.order))),
+		TreeID:         swag.String("1"),
+		SignedTreeHead: swag.String(""),
+	})
+}
+
+func (s *Server) publicKey(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_, _ = w.Write([]byte(s.pubKey))
+}
+
+func (s *Server) entriesByIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "the fake server only supports retrieving canned entries")
+		return
+	}
+	idx, err := strconv.Atoi(r.URL.Query().Get("logIndex"))
+	if err != nil || idx < 0 || idx >= len(s.order) {
+		writeError(w, http.StatusNotFound, "no entry at the requested log index")
+		return
+	}
+	uuid := s.order[idx]
+	writeJSON(w, http.StatusOK, models.LogEntry{uuid: s.entries[uuid]})
+}
+
+// lookup resolves an entry by UUID, accepting both bare 64-character UUIDs
+// and 80-character sharded entry IDs as the real server does.
+func (s *Server) lookup(uuid string) (string, models.LogEntryAnon, bool) {
+	if e, ok := s.entries[uuid]; ok {
+		return uuid, e, true
+	}
+	for stored, e := range s.entries {
+		if strings.HasSuffix(stored, uuid) || strings.HasSuffix(uuid, stored) {
+			return stored, e, true
+		}
+	}
+	return "", models.LogEntryAnon{}, false
+}
+
+func (s *Server) entryByUUID(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	requested := strings.TrimPrefix(r.URL.Path, "/api/v1/log/entries/")
+	uuid, entry, ok := s.lookup(requested)
+	if !ok {
+		writeError(w, http.StatusNotFound, "entry not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, models.LogEntry{uuid: entry})
+}
+
+func (s *Server) searchEntries(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var query models.SearchLogQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed search query")
+		return
+	}
+	result := []models.LogEntry{}
+	for _, requested := range query.EntryUUIDs {
+		if uuid, entry, ok := s.lookup(requested); ok {
+			result = append(result, models.LogEntry{uuid: entry})
+		}
+	}
+	for _, idx := range query.LogIndexes {
+		i := int(swag.Int64Value(idx))
+		if i >= 0 && i < len(s.order) {
+			uuid := s.order[i]
+			result = append(result, models.LogEntry{uuid: s.entries[uuid]})
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) searchIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var query models.SearchIndex
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed index query")
+		return
+	}
+	result := []string{}
+	if query.Hash != "" {
+		result = append(result, s.index[strings.ToLower(query.Hash)]...)
+	}
+	if query.Email != "" {
+		result = append(result, s.index[strings.ToLower(string(query.Email))]...)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,79 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakes
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/client/index"
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+func TestServerWithGeneratedClient(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddEntry(SampleEntryUUID, SampleEntry())
+	s.AddIndex(SampleArtifactDigest, SampleEntryUUID)
+
+	c, err := client.GetRekorClient(s.URL)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+
+	params := entries.NewGetLogEntryByUUIDParams()
+	params.EntryUUID = SampleEntryUUID
+	resp, err := c.Entries.GetLogEntryByUUID(params)
+	if err != nil {
+		t.Fatalf("fetching sample entry: %v", err)
+	}
+	entry, ok := resp.Payload[SampleEntryUUID]
+	if !ok {
+		t.Fatalf("entry %v missing from payload %v", SampleEntryUUID, resp.Payload)
+	}
+	if swag.StringValue(entry.LogID) != SampleLogID {
+		t.Errorf("logID: got %v, want %v", swag.StringValue(entry.LogID), SampleLogID)
+	}
+
+	searchParams := index.NewSearchIndexParams()
+	searchParams.Query = &models.SearchIndex{Hash: SampleArtifactDigest}
+	searchResp, err := c.Index.SearchIndex(searchParams)
+	if err != nil {
+		t.Fatalf("searching index: %v", err)
+	}
+	if len(searchResp.Payload) != 1 || searchResp.Payload[0] != SampleEntryUUID {
+		t.Errorf("index search: got %v, want [%v]", searchResp.Payload, SampleEntryUUID)
+	}
+}
+
+func TestSampleEntrySignedEntryTimestamp(t *testing.T) {
+	block, _ := pem.Decode([]byte(SampleLogPublicKey))
+	if block == nil {
+		t.Fatal("decoding sample log public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("parsing sample log public key: %v", err)
+	}
+	if err := client.VerifySignedEntryTimestamp(SampleEntry(), pub); err != nil {
+		t.Errorf("sample SET does not verify against the sample log key: %v", err)
+	}
+}
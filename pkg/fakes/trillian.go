@@ -0,0 +1,336 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakes provides in-process stand-ins for Rekor's external backends.
+// They back the server's dev mode and let downstream consumers write hermetic
+// tests without running Trillian or Redis.
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/types"
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Trillian is an in-memory implementation of the Trillian log and admin RPC
+// surfaces Rekor uses. Leaves are integrated synchronously on QueueLeaf, so
+// inclusion proofs are available as soon as the call returns. All state is
+// lost when the process exits.
+type Trillian struct {
+	hasher merkle.LogHasher
+
+	mu         sync.Mutex
+	nextTreeID int64
+	trees      map[int64]*memoryTree
+}
+
+type memoryTree struct {
+	tree   *trillian.Tree
+	merkle *testonly.Tree
+	leaves []*trillian.LogLeaf
+	byHash map[string]int64
+	inited bool
+}
+
+// NewTrillian returns an empty fake with no trees; callers create one via the
+// admin client, exactly as they would against a real Trillian instance. A nil
+// hasher selects the RFC 6962 SHA-256 default.
+func NewTrillian(hasher merkle.LogHasher) *Trillian {
+	if hasher == nil {
+		hasher = rfc6962.DefaultHasher
+	}
+	return &Trillian{
+		hasher:     hasher,
+		nextTreeID: 1,
+		trees:      make(map[int64]*memoryTree),
+	}
+}
+
+// LogClient returns a client speaking to the fake log server.
+func (t *Trillian) LogClient() trillian.TrillianLogClient {
+	return &fakeLogClient{t: t}
+}
+
+// AdminClient returns a client speaking to the fake admin server.
+func (t *Trillian) AdminClient() trillian.TrillianAdminClient {
+	return &fakeAdminClient{t: t}
+}
+
+func (t *Trillian) get(logID int64) (*memoryTree, error) {
+	if mt, ok := t.trees[logID]; ok {
+		return mt, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "tree %d not found", logID)
+}
+
+func (mt *memoryTree) signedLogRoot() (*trillian.SignedLogRoot, error) {
+	root := types.LogRootV1{
+		TreeSize:       mt.merkle.Size(),
+		RootHash:       mt.merkle.Hash(),
+		TimestampNanos: uint64(time.Now().UnixNano()),
+	}
+	b, err := root.MarshalBinary()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshalling log root: %v", err)
+	}
+	return &trillian.SignedLogRoot{LogRoot: b}, nil
+}
+
+// fakeLogClient implements the subset of trillian.TrillianLogClient that
+// Rekor calls; the embedded interface panics on anything else.
+type fakeLogClient struct {
+	trillian.TrillianLogClient
+	t *Trillian
+}
+
+func (c *fakeLogClient) QueueLeaf(_ context.Context, in *trillian.QueueLeafRequest, _ ...grpc.CallOption) (*trillian.QueueLeafResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	if mt.tree.TreeState == trillian.TreeState_FROZEN {
+		return nil, status.Errorf(codes.FailedPrecondition, "tree %d is frozen", in.LogId)
+	}
+
+	leafHash := c.t.hasher.HashLeaf(in.Leaf.LeafValue)
+	if idx, ok := mt.byHash[string(leafHash)]; ok {
+		return &trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{
+			Leaf:   mt.leaves[idx],
+			Status: &rpcstatus.Status{Code: int32(code.Code_ALREADY_EXISTS), Message: "leaf already exists"},
+		}}, nil
+	}
+
+	now := timestamppb.Now()
+	leaf := &trillian.LogLeaf{
+		MerkleLeafHash:     leafHash,
+		LeafValue:          in.Leaf.LeafValue,
+		ExtraData:          in.Leaf.ExtraData,
+		LeafIndex:          int64(mt.merkle.Size()),
+		LeafIdentityHash:   leafHash,
+		QueueTimestamp:     now,
+		IntegrateTimestamp: now,
+	}
+	mt.merkle.Append(leafHash)
+	mt.byHash[string(leafHash)] = leaf.LeafIndex
+	mt.leaves = append(mt.leaves, leaf)
+
+	return &trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: leaf}}, nil
+}
+
+func (c *fakeLogClient) GetLatestSignedLogRoot(_ context.Context, in *trillian.GetLatestSignedLogRootRequest, _ ...grpc.CallOption) (*trillian.GetLatestSignedLogRootResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	slr, err := mt.signedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	resp := &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: slr}
+	if first := uint64(in.FirstTreeSize); first > 0 && first <= mt.merkle.Size() {
+		hashes, err := mt.merkle.ConsistencyProof(first, mt.merkle.Size())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "building consistency proof: %v", err)
+		}
+		resp.Proof = &trillian.Proof{Hashes: hashes}
+	}
+	return resp, nil
+}
+
+func (c *fakeLogClient) GetInclusionProofByHash(_ context.Context, in *trillian.GetInclusionProofByHashRequest, _ ...grpc.CallOption) (*trillian.GetInclusionProofByHashResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	idx, ok := mt.byHash[string(in.LeafHash)]
+	if !ok || idx >= in.TreeSize {
+		return nil, status.Errorf(codes.NotFound, "leaf not found in tree of size %d", in.TreeSize)
+	}
+	hashes, err := mt.merkle.InclusionProof(uint64(idx), uint64(in.TreeSize))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "building inclusion proof: %v", err)
+	}
+	slr, err := mt.signedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.GetInclusionProofByHashResponse{
+		Proof:         []*trillian.Proof{{LeafIndex: idx, Hashes: hashes}},
+		SignedLogRoot: slr,
+	}, nil
+}
+
+func (c *fakeLogClient) GetEntryAndProof(_ context.Context, in *trillian.GetEntryAndProofRequest, _ ...grpc.CallOption) (*trillian.GetEntryAndProofResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	if in.LeafIndex < 0 || in.LeafIndex >= int64(len(mt.leaves)) || in.LeafIndex >= in.TreeSize {
+		return nil, status.Errorf(codes.OutOfRange, "leaf index %d out of range for tree of size %d", in.LeafIndex, in.TreeSize)
+	}
+	hashes, err := mt.merkle.InclusionProof(uint64(in.LeafIndex), uint64(in.TreeSize))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "building inclusion proof: %v", err)
+	}
+	slr, err := mt.signedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.GetEntryAndProofResponse{
+		Proof:         &trillian.Proof{LeafIndex: in.LeafIndex, Hashes: hashes},
+		Leaf:          mt.leaves[in.LeafIndex],
+		SignedLogRoot: slr,
+	}, nil
+}
+
+func (c *fakeLogClient) GetConsistencyProof(_ context.Context, in *trillian.GetConsistencyProofRequest, _ ...grpc.CallOption) (*trillian.GetConsistencyProofResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	if in.FirstTreeSize < 0 || in.SecondTreeSize > int64(mt.merkle.Size()) || in.FirstTreeSize > in.SecondTreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid consistency proof range [%d, %d]", in.FirstTreeSize, in.SecondTreeSize)
+	}
+	hashes, err := mt.merkle.ConsistencyProof(uint64(in.FirstTreeSize), uint64(in.SecondTreeSize))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "building consistency proof: %v", err)
+	}
+	slr, err := mt.signedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.GetConsistencyProofResponse{
+		Proof:         &trillian.Proof{Hashes: hashes},
+		SignedLogRoot: slr,
+	}, nil
+}
+
+func (c *fakeLogClient) GetLeavesByRange(_ context.Context, in *trillian.GetLeavesByRangeRequest, _ ...grpc.CallOption) (*trillian.GetLeavesByRangeResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	if in.StartIndex < 0 || in.StartIndex >= int64(len(mt.leaves)) {
+		return nil, status.Errorf(codes.OutOfRange, "start index %d out of range for tree of size %d", in.StartIndex, len(mt.leaves))
+	}
+	end := in.StartIndex + in.Count
+	if end > int64(len(mt.leaves)) {
+		end = int64(len(mt.leaves))
+	}
+	slr, err := mt.signedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.GetLeavesByRangeResponse{
+		Leaves:        mt.leaves[in.StartIndex:end],
+		SignedLogRoot: slr,
+	}, nil
+}
+
+func (c *fakeLogClient) InitLog(_ context.Context, in *trillian.InitLogRequest, _ ...grpc.CallOption) (*trillian.InitLogResponse, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.LogId)
+	if err != nil {
+		return nil, err
+	}
+	if mt.inited {
+		return nil, status.Errorf(codes.AlreadyExists, "log %d already initialized", in.LogId)
+	}
+	mt.inited = true
+	slr, err := mt.signedLogRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.InitLogResponse{Created: slr}, nil
+}
+
+// fakeAdminClient implements the subset of trillian.TrillianAdminClient that
+// Rekor calls; the embedded interface panics on anything else.
+type fakeAdminClient struct {
+	trillian.TrillianAdminClient
+	t *Trillian
+}
+
+func (c *fakeAdminClient) CreateTree(_ context.Context, in *trillian.CreateTreeRequest, _ ...grpc.CallOption) (*trillian.Tree, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	tree := &trillian.Tree{
+		TreeId:          c.t.nextTreeID,
+		TreeType:        in.Tree.TreeType,
+		TreeState:       in.Tree.TreeState,
+		MaxRootDuration: in.Tree.MaxRootDuration,
+		CreateTime:      timestamppb.Now(),
+	}
+	c.t.nextTreeID++
+	c.t.trees[tree.TreeId] = &memoryTree{
+		tree:   tree,
+		merkle: testonly.New(c.t.hasher),
+		byHash: make(map[string]int64),
+	}
+	return tree, nil
+}
+
+func (c *fakeAdminClient) GetTree(_ context.Context, in *trillian.GetTreeRequest, _ ...grpc.CallOption) (*trillian.Tree, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	return mt.tree, nil
+}
+
+func (c *fakeAdminClient) UpdateTree(_ context.Context, in *trillian.UpdateTreeRequest, _ ...grpc.CallOption) (*trillian.Tree, error) {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	mt, err := c.t.get(in.Tree.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range in.UpdateMask.GetPaths() {
+		switch path {
+		case "tree_state":
+			mt.tree.TreeState = in.Tree.TreeState
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported update mask path %q", path)
+		}
+	}
+	return mt.tree, nil
+}
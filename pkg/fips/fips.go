@@ -0,0 +1,32 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fips gates the algorithms a Rekor instance accepts when built for
+// regulated deployments. Building with -tags fips (typically combined with
+// GOEXPERIMENT=boringcrypto so the Go crypto primitives themselves are the
+// certified ones) restricts both the server's own signing key and the key
+// material accepted in entries to a FIPS-approved set; the default build is
+// unaffected.
+package fips
+
+// MinRSAKeySize is the smallest RSA modulus accepted in FIPS mode.
+const MinRSAKeySize = 2048
+
+// KeyAlgorithms returns the key algorithm names (as used by the policy
+// engine's allowed_key_algorithms rule) that are acceptable in FIPS mode.
+// Ed25519 is excluded: it is not implemented by boringcrypto.
+func KeyAlgorithms() []string {
+	return []string{"rsa", "ecdsa-p256", "ecdsa-p384", "ecdsa-p521", "ecdsa"}
+}
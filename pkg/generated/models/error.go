@@ -38,6 +38,18 @@ type Error struct {
 
 	// message
 	Message string `json:"message,omitempty"`
+
+	// A URI reference identifying the problem type (RFC 7807)
+	Type string `json:"type,omitempty"`
+
+	// A short, human-readable summary of the problem type (RFC 7807)
+	Title string `json:"title,omitempty"`
+
+	// A human-readable explanation specific to this occurrence (RFC 7807)
+	Detail string `json:"detail,omitempty"`
+
+	// The ID of the request this occurrence relates to (RFC 7807 instance)
+	Instance string `json:"instance,omitempty"`
 }
 
 // Validate validates this error
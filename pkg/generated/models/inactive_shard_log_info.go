@@ -35,6 +35,9 @@ import (
 // swagger:model InactiveShardLogInfo
 type InactiveShardLogInfo struct {
 
+	// The PEM-encoded public key for this shard, if it differs from the active shard's key
+	PublicKey string `json:"publicKey,omitempty"`
+
 	// The current hash value stored at the root of the merkle tree
 	// Required: true
 	// Pattern: ^[0-9a-fA-F]{64}$
@@ -36,6 +36,9 @@ import (
 // swagger:model InclusionProof
 type InclusionProof struct {
 
+	// The signed checkpoint over the merkle tree state the proof was generated against
+	Checkpoint string `json:"checkpoint,omitempty"`
+
 	// A list of hashes required to compute the inclusion proof, sorted in order from leaf to root
 	// Required: true
 	Hashes []string `json:"hashes"`
@@ -47,7 +50,7 @@ type InclusionProof struct {
 
 	// The hash value stored at the root of the merkle tree at the time the proof was generated
 	// Required: true
-	// Pattern: ^[0-9a-fA-F]{64}$
+	// Pattern: ^[0-9a-fA-F]{64,128}$
 	RootHash *string `json:"rootHash"`
 
 	// The size of the merkle tree at the time the inclusion proof was generated
@@ -90,7 +93,7 @@ func (m *InclusionProof) validateHashes(formats strfmt.Registry) error {
 
 	for i := 0; i < len(m.Hashes); i++ {
 
-		if err := validate.Pattern("hashes"+"."+strconv.Itoa(i), "body", m.Hashes[i], `^[0-9a-fA-F]{64}$`); err != nil {
+		if err := validate.Pattern("hashes"+"."+strconv.Itoa(i), "body", m.Hashes[i], `^[0-9a-fA-F]{64,128}$`); err != nil {
 			return err
 		}
 
@@ -118,7 +121,7 @@ func (m *InclusionProof) validateRootHash(formats strfmt.Registry) error {
 		return err
 	}
 
-	if err := validate.Pattern("rootHash", "body", *m.RootHash, `^[0-9a-fA-F]{64}$`); err != nil {
+	if err := validate.Pattern("rootHash", "body", *m.RootHash, `^[0-9a-fA-F]{64,128}$`); err != nil {
 		return err
 	}
 
@@ -33,7 +33,7 @@ import (
 
 // IntotoV001Schema intoto v0.0.1 Schema
 //
-// Schema for intoto object
+// # Schema for intoto object
 //
 // swagger:model intotoV001Schema
 type IntotoV001Schema struct {
@@ -46,6 +46,13 @@ type IntotoV001Schema struct {
 	// Required: true
 	// Format: byte
 	PublicKey *strfmt.Base64 `json:"publicKey"`
+
+	// Additional public keys or certificates that can verify signatures over the envelope
+	PublicKeys []strfmt.Base64 `json:"publicKeys"`
+
+	// The minimum number of distinct supplied keys that must verify a signature over the envelope
+	// Minimum: 1
+	Threshold int64 `json:"threshold,omitempty"`
 }
 
 // Validate validates this intoto v001 schema
@@ -60,6 +67,10 @@ func (m *IntotoV001Schema) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateThreshold(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -95,6 +106,18 @@ func (m *IntotoV001Schema) validatePublicKey(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *IntotoV001Schema) validateThreshold(formats strfmt.Registry) error {
+	if swag.IsZero(m.Threshold) { // not required
+		return nil
+	}
+
+	if err := validate.MinimumInt("threshold", "body", m.Threshold, 1, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ContextValidate validate this intoto v001 schema based on the context it is used
 func (m *IntotoV001Schema) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	var res []error
@@ -36,18 +36,25 @@ import (
 // swagger:model LogInfo
 type LogInfo struct {
 
+	// The hash algorithm used by the merkle tree
+	// Enum: [sha256 sha384 sha512]
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+
 	// inactive shards
 	InactiveShards []*InactiveShardLogInfo `json:"inactiveShards"`
 
 	// The current hash value stored at the root of the merkle tree
 	// Required: true
-	// Pattern: ^[0-9a-fA-F]{64}$
+	// Pattern: ^[0-9a-fA-F]{64,128}$
 	RootHash *string `json:"rootHash"`
 
 	// The current signed tree head
 	// Required: true
 	SignedTreeHead *string `json:"signedTreeHead"`
 
+	// set to true when the latest signed log root is older than the operator-configured maximum age, indicating the log is not advancing
+	Stale bool `json:"stale,omitempty"`
+
 	// The current treeID
 	// Required: true
 	// Pattern: ^[0-9]+$
@@ -121,7 +128,7 @@ func (m *LogInfo) validateRootHash(formats strfmt.Registry) error {
 		return err
 	}
 
-	if err := validate.Pattern("rootHash", "body", *m.RootHash, `^[0-9a-fA-F]{64}$`); err != nil {
+	if err := validate.Pattern("rootHash", "body", *m.RootHash, `^[0-9a-fA-F]{64,128}$`); err != nil {
 		return err
 	}
 
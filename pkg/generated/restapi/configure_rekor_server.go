@@ -18,14 +18,21 @@ limitations under the License.
 package restapi
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	// using embed to add the static html page duing build time
 	_ "embed"
 
+	"github.com/ghodss/yaml"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/runtime"
@@ -110,6 +117,11 @@ func configureAPI(api *operations.RekorServerAPI) http.Handler {
 	// cache forever
 	api.AddMiddlewareFor("GET", "/api/v1/log/publicKey", cacheForever)
 	api.AddMiddlewareFor("GET", "/api/v1/log/timestamp/certchain", cacheForever)
+	// entries and proofs pinned to explicit tree state never change; serve
+	// them with validators so CDNs and proxies can absorb read load
+	api.AddMiddlewareFor("GET", "/api/v1/log/entries/{entryUUID}", cacheImmutable)
+	api.AddMiddlewareFor("GET", "/api/v1/log/entries", cacheImmutable)
+	api.AddMiddlewareFor("GET", "/api/v1/log/proof", cacheImmutable)
 
 	return setupGlobalMiddleware(api.Serve(setupMiddlewares))
 }
@@ -129,7 +141,21 @@ func configureServer(s *http.Server, scheme, addr string) {
 // The middleware configuration is for the handler executors. These do not apply to the swagger.json document.
 // The middleware executes after routing but before authentication, binding and validation
 func setupMiddlewares(handler http.Handler) http.Handler {
-	return handler
+	return pkgapi.ReadThrottleMiddleware(problemJSON(handler))
+}
+
+// problemJSON relabels JSON error bodies as RFC 7807 problem documents so
+// clients can detect them by content type.
+func problemJSON(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := negroni.NewResponseWriter(w)
+		ww.Before(func(w negroni.ResponseWriter) {
+			if w.Status() >= 400 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+				w.Header().Set("Content-Type", "application/problem+json")
+			}
+		})
+		handler.ServeHTTP(ww, r)
+	})
 }
 
 // We need this type to act as an adapter between zap and the middleware request logger.
@@ -149,6 +175,19 @@ func setupGlobalMiddleware(handler http.Handler) http.Handler {
 	returnHandler = middleware.Recoverer(returnHandler)
 	returnHandler = middleware.Heartbeat("/ping")(returnHandler)
 	returnHandler = serveStaticContent(returnHandler)
+	returnHandler = serveTrustedRoot(returnHandler)
+	returnHandler = serveIndexLookup(returnHandler)
+	returnHandler = serveAttestationSearch(returnHandler)
+	returnHandler = serveFieldQuery(returnHandler)
+	returnHandler = serveStableEndpoints(returnHandler)
+	returnHandler = serveLogStats(returnHandler)
+	returnHandler = serveSupportedTypes(returnHandler)
+	returnHandler = serveCheckpointHistory(returnHandler)
+	returnHandler = serveCheckpointWitnessing(returnHandler)
+	returnHandler = serveEntryStream(returnHandler)
+	returnHandler = translateYAML(returnHandler)
+	returnHandler = translateMultipart(returnHandler)
+	returnHandler = pkgapi.RequestTimeoutMiddleware(returnHandler)
 
 	handleCORS := cors.Default().Handler
 	returnHandler = handleCORS(returnHandler)
@@ -157,6 +196,7 @@ func setupGlobalMiddleware(handler http.Handler) http.Handler {
 
 	return middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
+		ctx = pkgapi.WithTraceContext(ctx, r.Header)
 		r = r.WithContext(log.WithRequestID(ctx, middleware.GetReqID(ctx)))
 		defer func() {
 			_ = log.RequestIDLogger(r).Sync()
@@ -195,6 +235,38 @@ func cacheForever(handler http.Handler) http.Handler {
 	})
 }
 
+// cacheImmutable marks entry and proof reads as immutable and answers
+// conditional GETs with a validator derived from the request identity rather
+// than the body: the embedded ECDSA signatures differ on every response, so a
+// content hash would never match twice even though the underlying resource
+// cannot change.
+func cacheImmutable(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// consistency proofs are only immutable once both sizes are pinned
+		if r.URL.Path == "/api/v1/log/proof" && r.URL.Query().Get("lastSize") == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		sum := sha256.Sum256([]byte(r.URL.Path + "?" + r.URL.Query().Encode()))
+		etag := `"` + hex.EncodeToString(sum[:]) + `"`
+		// any previously served representation of an immutable resource is
+		// still valid, so a matching validator short-circuits the backend
+		if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		ww := negroni.NewResponseWriter(w)
+		ww.Before(func(w negroni.ResponseWriter) {
+			if w.Status() >= 200 && w.Status() <= 299 {
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Cache-Control", "s-maxage=31536000, max-age=31536000, immutable")
+			}
+		})
+		handler.ServeHTTP(ww, r)
+	})
+}
+
 func logAndServeError(w http.ResponseWriter, r *http.Request, err error) {
 	if apiErr, ok := err.(errors.Error); ok && apiErr.Code() == http.StatusNotFound {
 		log.RequestIDLogger(r).Warn(err)
@@ -222,3 +294,241 @@ func serveStaticContent(handler http.Handler) http.Handler {
 		handler.ServeHTTP(w, r)
 	})
 }
+
+// serveTrustedRoot intercepts the trusted root endpoint, which is served
+// outside the swagger spec like the home page above.
+func serveTrustedRoot(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/log/trustedRoot" {
+			pkgapi.TrustedRootHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// maxYAMLBodySize bounds how much of a YAML request body is buffered for
+// conversion to JSON.
+const maxYAMLBodySize = 10 * 1024 * 1024
+
+// translateYAML converts application/yaml request bodies to JSON before
+// routing, so YAML-native supply-chain tooling can POST manifests directly
+// while the swagger runtime keeps consuming a single wire format.
+func translateYAML(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		if r.Method == http.MethodPost && r.Body != nil &&
+			(strings.HasPrefix(contentType, "application/yaml") || strings.HasPrefix(contentType, "text/yaml")) {
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxYAMLBodySize))
+			if err != nil {
+				http.Error(w, "unable to read request body", http.StatusBadRequest)
+				return
+			}
+			jsonBody, err := yaml.YAMLToJSON(body)
+			if err != nil {
+				http.Error(w, "malformed YAML request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(jsonBody))
+			r.ContentLength = int64(len(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// maxMultipartMemory bounds in-memory buffering while parsing multipart
+// upload bodies; larger parts spill to temporary files.
+const maxMultipartMemory = 32 << 20
+
+// translateMultipart converts multipart/form-data submissions (artifact,
+// signature, and publicKey file parts, plus an optional pkiFormat field) into
+// the equivalent JSON rekord proposed entry before routing, so binary
+// artifacts and signatures can be sent directly without base64 inflation.
+func translateMultipart(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/api/v1/log/entries" &&
+			strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+				http.Error(w, "malformed multipart request body", http.StatusBadRequest)
+				return
+			}
+			readPart := func(name string) ([]byte, bool) {
+				f, _, err := r.FormFile(name)
+				if err != nil {
+					return nil, false
+				}
+				defer f.Close()
+				content, err := ioutil.ReadAll(f)
+				if err != nil {
+					return nil, false
+				}
+				return content, true
+			}
+			artifact, ok := readPart("artifact")
+			if !ok {
+				http.Error(w, "multipart upload requires an artifact part", http.StatusBadRequest)
+				return
+			}
+			sig, ok := readPart("signature")
+			if !ok {
+				http.Error(w, "multipart upload requires a signature part", http.StatusBadRequest)
+				return
+			}
+			key, ok := readPart("publicKey")
+			if !ok {
+				http.Error(w, "multipart upload requires a publicKey part", http.StatusBadRequest)
+				return
+			}
+			pkiFormat := r.FormValue("pkiFormat")
+			if pkiFormat == "" {
+				pkiFormat = "pgp"
+			}
+			// []byte fields marshal to base64, matching the rekord schema
+			jsonBody, err := json.Marshal(map[string]interface{}{
+				"kind":       "rekord",
+				"apiVersion": "0.0.1",
+				"spec": map[string]interface{}{
+					"data": map[string]interface{}{"content": artifact},
+					"signature": map[string]interface{}{
+						"format":    pkiFormat,
+						"content":   sig,
+						"publicKey": map[string]interface{}{"content": key},
+					},
+				},
+			})
+			if err != nil {
+				http.Error(w, "unable to construct entry from multipart request", http.StatusInternalServerError)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(jsonBody))
+			r.ContentLength = int64(len(jsonBody))
+			r.Header.Set("Content-Type", "application/json")
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveEntryStream intercepts the NDJSON entry stream and the SSE tail used
+// by monitors, which sit outside the swagger spec because go-swagger buffers
+// whole responses.
+func serveEntryStream(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/log/entries/stream" {
+			pkgapi.StreamEntriesHandler(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/log/entries/tail" {
+			pkgapi.TailEntriesHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveLogStats intercepts the public log statistics endpoint, which sits
+// outside the swagger spec.
+func serveLogStats(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/log/stats" {
+			pkgapi.StatsHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveCheckpointWitnessing intercepts the raw checkpoint served to
+// witnesses and the witnessed checkpoint exchange, which sit outside the
+// swagger spec.
+func serveCheckpointWitnessing(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/log/checkpoint":
+			if r.Method == http.MethodGet {
+				pkgapi.RawCheckpointHandler(w, r)
+				return
+			}
+		case "/api/v1/log/checkpoint/witnessed":
+			pkgapi.WitnessedCheckpointHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveCheckpointHistory intercepts lookups of recent signed checkpoints,
+// which sit outside the swagger spec.
+func serveCheckpointHistory(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/log/checkpoints" {
+			pkgapi.CheckpointHistoryHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveSupportedTypes intercepts the supported-types discovery endpoint,
+// which sits outside the swagger spec.
+func serveSupportedTypes(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/types" {
+			pkgapi.TypesHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveFieldQuery intercepts compound field queries against the search
+// index, which sit outside the swagger spec.
+func serveFieldQuery(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/index/query" {
+			pkgapi.FieldQueryHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveStableEndpoints intercepts the tree-size-parameterized read endpoints,
+// whose immutable responses are designed for long-TTL CDN caching.
+func serveStableEndpoints(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/log/stable/") {
+			pkgapi.StableEndpointsHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveAttestationSearch intercepts attestation subject searches, which sit
+// outside the swagger spec.
+func serveAttestationSearch(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v1/attestations" {
+			pkgapi.SearchAttestationsHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveIndexLookup intercepts GET digest lookups and batch searches against
+// the search index, which sit outside the swagger spec.
+func serveIndexLookup(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/index/entries/") {
+			pkgapi.LookupIndexHandler(w, r)
+			return
+		}
+		if r.Method == http.MethodPost && r.URL.Path == "/api/v1/index/retrieve/batch" {
+			pkgapi.BatchSearchIndexHandler(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -36,6 +36,7 @@ import (
 type Signature struct {
 	isArmored bool
 	signature []byte
+	signer    *openpgp.Entity
 }
 
 // NewSignature creates and validates a PGP signature object
@@ -131,8 +132,10 @@ func (s Signature) CanonicalValue() ([]byte, error) {
 	return canonicalBuffer.Bytes(), nil
 }
 
-// Verify implements the pki.Signature interface
-func (s Signature) Verify(r io.Reader, k interface{}, opts ...sigsig.VerifyOption) error {
+// Verify implements the pki.Signature interface. When the public key holds a
+// multi-key keyring, the signature is accepted if any key in the ring produced
+// it; the matching entity is retained and can be retrieved with Signer.
+func (s *Signature) Verify(r io.Reader, k interface{}, opts ...sigsig.VerifyOption) error {
 	if len(s.signature) == 0 {
 		return fmt.Errorf("PGP signature has not been initialized")
 	}
@@ -150,13 +153,23 @@ func (s Signature) Verify(r io.Reader, k interface{}, opts ...sigsig.VerifyOptio
 		verifyFn = openpgp.CheckArmoredDetachedSignature
 	}
 
-	if _, err := verifyFn(key.key, r, bytes.NewReader(s.signature)); err != nil {
+	signer, err := verifyFn(key.key, r, bytes.NewReader(s.signature))
+	if err != nil {
 		return err
 	}
+	s.signer = signer
 
 	return nil
 }
 
+// Signer returns the entity from the keyring that produced the signature on
+// the most recent successful call to Verify, or nil if the signature has not
+// been verified. Keyrings bundling several signers (e.g. distro keyrings) use
+// this to record which key actually matched.
+func (s *Signature) Signer() *openpgp.Entity {
+	return s.signer
+}
+
 // PublicKey Public Key that follows the PGP standard; supports both armored & binary detached signatures
 type PublicKey struct {
 	key openpgp.EntityList
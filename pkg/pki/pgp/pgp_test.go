@@ -456,3 +456,49 @@ func TestVerifySignature(t *testing.T) {
 		t.Errorf("expected error when using empty key to verify")
 	}
 }
+
+func TestVerifyMultiKeyKeyring(t *testing.T) {
+	// bundle the matching key with an unrelated keyring, as distro keyrings do
+	matchingKey, err := ioutil.ReadFile("testdata/valid_armored_public.pgp")
+	if err != nil {
+		t.Fatalf("error reading matching key: %v", err)
+	}
+	otherKeys, err := ioutil.ReadFile("testdata/valid_armored_complex_public.pgp")
+	if err != nil {
+		t.Fatalf("error reading complex keyring: %v", err)
+	}
+	ring, err := NewPublicKey(bytes.NewReader(append(otherKeys, matchingKey...)))
+	if err != nil {
+		t.Fatalf("error reading combined keyring: %v", err)
+	}
+
+	sigFile, err := os.Open("testdata/hello_world.txt.sig")
+	if err != nil {
+		t.Fatalf("error reading sigfile: %v", err)
+	}
+	s, err := NewSignature(sigFile)
+	if err != nil {
+		t.Fatalf("error reading signature: %v", err)
+	}
+
+	dataFile, err := os.Open("testdata/hello_world.txt")
+	if err != nil {
+		t.Fatalf("error reading datafile: %v", err)
+	}
+	if err := s.Verify(dataFile, ring); err != nil {
+		t.Fatalf("error verifying against multi-key keyring: %v", err)
+	}
+
+	signer := s.Signer()
+	if signer == nil {
+		t.Fatal("expected matched signer to be recorded after successful verification")
+	}
+	expected, err := NewPublicKey(bytes.NewReader(matchingKey))
+	if err != nil {
+		t.Fatalf("error reading matching key: %v", err)
+	}
+	if signer.PrimaryKey.Fingerprint != expected.key[0].PrimaryKey.Fingerprint {
+		t.Errorf("recorded signer %x does not match expected key %x",
+			signer.PrimaryKey.Fingerprint, expected.key[0].PrimaryKey.Fingerprint)
+	}
+}
@@ -0,0 +1,140 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCIssuerOID is the Fulcio extension carrying the OIDC issuer that
+// authenticated the certificate subject (1.3.6.1.4.1.57264.1.1).
+var OIDCIssuerOID asn1.ObjectIdentifier = []int{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+// fulcioRoots holds the operator-configured trust anchors for short-lived
+// Fulcio-issued certificates; nil means no Fulcio-specific validation.
+var fulcioRoots struct {
+	sync.RWMutex
+	pool *x509.CertPool
+}
+
+// SetFulcioRoots installs the trust anchors that certificates presented as
+// verification material must chain to. Passing nil disables the check.
+func SetFulcioRoots(pool *x509.CertPool) {
+	fulcioRoots.Lock()
+	defer fulcioRoots.Unlock()
+	fulcioRoots.pool = pool
+}
+
+// FulcioRoots returns the configured trust anchors, or nil if none are set.
+func FulcioRoots() *x509.CertPool {
+	fulcioRoots.RLock()
+	defer fulcioRoots.RUnlock()
+	return fulcioRoots.pool
+}
+
+// LoadFulcioRoots reads PEM-encoded CA certificates from the given paths
+// into a pool suitable for SetFulcioRoots.
+func LoadFulcioRoots(paths []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading Fulcio root %s: %w", path, err)
+		}
+		if !pool.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("no certificates found in Fulcio root %s", path)
+		}
+	}
+	return pool, nil
+}
+
+// verifyChainAtTime checks that the leaf certificate chains to the given
+// roots, evaluated at signingTime. Short-lived certificates are accepted as
+// long as the signing happened inside their validity window.
+func verifyChainAtTime(leaf *x509.Certificate, intermediates []*x509.Certificate, roots *x509.CertPool, signingTime time.Time) error {
+	if signingTime.Before(leaf.NotBefore) || signingTime.After(leaf.NotAfter) {
+		return fmt.Errorf("signing time %s is outside certificate validity [%s, %s]",
+			signingTime.Format(time.RFC3339), leaf.NotBefore.Format(time.RFC3339), leaf.NotAfter.Format(time.RFC3339))
+	}
+	subPool := x509.NewCertPool()
+	for _, c := range intermediates {
+		subPool.AddCert(c)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: subPool,
+		CurrentTime:   signingTime,
+		// Allow any key usage
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fmt.Errorf("certificate does not chain to a configured Fulcio root: %w", err)
+	}
+	return nil
+}
+
+// leafCert returns the end-entity certificate held by this key, if any.
+func (k PublicKey) leafCert() (*x509.Certificate, []*x509.Certificate) {
+	switch {
+	case k.cert != nil:
+		return k.cert.c, nil
+	case len(k.certs) > 0:
+		return k.certs[0], k.certs[1:]
+	}
+	return nil, nil
+}
+
+// VerifyFulcioChain validates this key's certificate chain against the
+// configured Fulcio roots at the given signing time. It is a no-op when no
+// roots are configured or the key holds no certificate.
+func (k PublicKey) VerifyFulcioChain(signingTime time.Time) error {
+	roots := FulcioRoots()
+	if roots == nil {
+		return nil
+	}
+	leaf, intermediates := k.leafCert()
+	if leaf == nil {
+		return nil
+	}
+	return verifyChainAtTime(leaf, intermediates, roots, signingTime)
+}
+
+// OIDCIdentities returns the identity values Fulcio embeds in certificates:
+// URI subject alternative names and the OIDC issuer extension. Email SANs
+// are reported separately via EmailAddresses.
+func (k PublicKey) OIDCIdentities() []string {
+	leaf, _ := k.leafCert()
+	if leaf == nil {
+		return nil
+	}
+	var identities []string
+	for _, uri := range leaf.URIs {
+		identities = append(identities, strings.ToLower(uri.String()))
+	}
+	for _, ext := range leaf.Extensions {
+		if ext.Id.Equal(OIDCIssuerOID) {
+			if issuer := string(ext.Value); issuer != "" {
+				identities = append(identities, issuer)
+			}
+		}
+	}
+	return identities
+}
@@ -0,0 +1,105 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/sigstore/rekor/pkg/pki/x509/testutils"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+func TestVerifyFulcioChain(t *testing.T) {
+	rootCert, rootKey, err := testutils.GenerateRootCa()
+	if err != nil {
+		t.Fatalf("generating root CA: %v", err)
+	}
+	leafCert, _, err := testutils.GenerateLeafCert("subject@example.com", "oidc-issuer", rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("generating leaf cert: %v", err)
+	}
+	otherRoot, _, err := testutils.GenerateRootCa()
+	if err != nil {
+		t.Fatalf("generating other root CA: %v", err)
+	}
+
+	leafPEM, err := cryptoutils.MarshalCertificateToPEM(leafCert)
+	if err != nil {
+		t.Fatalf("marshalling leaf cert: %v", err)
+	}
+	key, err := NewPublicKey(bytes.NewReader(leafPEM))
+	if err != nil {
+		t.Fatalf("creating public key: %v", err)
+	}
+
+	// no roots configured: chain validation is skipped
+	SetFulcioRoots(nil)
+	if err := key.VerifyFulcioChain(time.Now()); err != nil {
+		t.Errorf("expected no-op with no roots configured, got: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(rootCert)
+	SetFulcioRoots(pool)
+	defer SetFulcioRoots(nil)
+
+	if err := key.VerifyFulcioChain(time.Now()); err != nil {
+		t.Errorf("expected chain to verify against issuing root, got: %v", err)
+	}
+	if err := key.VerifyFulcioChain(leafCert.NotAfter.Add(time.Hour)); err == nil {
+		t.Error("expected error for signing time after certificate expiry")
+	}
+
+	otherPool := x509.NewCertPool()
+	otherPool.AddCert(otherRoot)
+	SetFulcioRoots(otherPool)
+	if err := key.VerifyFulcioChain(time.Now()); err == nil {
+		t.Error("expected error for chain anchored to an unconfigured root")
+	}
+}
+
+func TestOIDCIdentities(t *testing.T) {
+	rootCert, rootKey, err := testutils.GenerateRootCa()
+	if err != nil {
+		t.Fatalf("generating root CA: %v", err)
+	}
+	leafCert, _, err := testutils.GenerateLeafCert("subject@example.com", "https://oidc.example.com", rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("generating leaf cert: %v", err)
+	}
+	leafPEM, err := cryptoutils.MarshalCertificateToPEM(leafCert)
+	if err != nil {
+		t.Fatalf("marshalling leaf cert: %v", err)
+	}
+	key, err := NewPublicKey(bytes.NewReader(leafPEM))
+	if err != nil {
+		t.Fatalf("creating public key: %v", err)
+	}
+
+	identities := key.OIDCIdentities()
+	found := false
+	for _, id := range identities {
+		if id == "https://oidc.example.com" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected OIDC issuer in identities, got %v", identities)
+	}
+}
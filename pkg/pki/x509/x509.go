@@ -26,6 +26,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	validator "github.com/go-playground/validator/v10"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
@@ -80,6 +81,11 @@ func (s Signature) Verify(r io.Reader, k interface{}, opts ...sigsig.VerifyOptio
 		default:
 			return errors.New("no public key found")
 		}
+		// when Fulcio roots are configured, certificates must additionally
+		// chain to them and have been valid at signing time
+		if err := key.VerifyFulcioChain(time.Now()); err != nil {
+			return err
+		}
 	}
 
 	verifier, err := sigsig.LoadVerifier(p, crypto.SHA256)
@@ -0,0 +1,282 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy implements configurable admission checks that run before an
+// entry is accepted into the log. Operators of private instances can use it
+// to keep weak cryptography (SHA-1 digests, short RSA keys, expired
+// certificates) out of their log entirely, rather than recording it and
+// relying on verifiers to notice.
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/fips"
+)
+
+// weakDigestAlgorithms are digest algorithm names rejected by the
+// reject_weak_digests rule.
+var weakDigestAlgorithms = map[string]bool{
+	"sha1": true,
+	"md5":  true,
+}
+
+// Violation describes a single admission policy failure.
+type Violation struct {
+	// Rule is the name of the policy rule that failed.
+	Rule string `json:"rule"`
+	// Field is the JSON path within the entry spec that triggered the rule.
+	Field string `json:"field"`
+	// Reason is a human-readable description of the failure.
+	Reason string `json:"reason"`
+}
+
+// Error aggregates all policy violations found in an entry so a client can
+// fix every problem in one pass.
+type Error struct {
+	Violations []Violation `json:"violations"`
+}
+
+func (e *Error) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, fmt.Sprintf("%s (%s): %s", v.Rule, v.Field, v.Reason))
+	}
+	return "entry rejected by admission policy: " + strings.Join(reasons, "; ")
+}
+
+// Engine evaluates the configured admission rules against proposed entries.
+type Engine struct {
+	rejectWeakDigests  bool
+	minRSAKeySize      int
+	rejectExpiredCerts bool
+	allowedEntryKinds  map[string]bool
+	allowedKeyAlgos    map[string]bool
+
+	now func() time.Time
+}
+
+// NewEngineFromConfig builds an Engine from the policy.* viper keys. In a
+// FIPS build the engine additionally enforces the FIPS floor regardless of
+// configuration: weak digests are always rejected, RSA keys must meet the
+// FIPS minimum size, and only FIPS-approved key algorithms are accepted.
+func NewEngineFromConfig() *Engine {
+	e := &Engine{
+		rejectWeakDigests:  viper.GetBool("policy.reject_weak_digests"),
+		minRSAKeySize:      viper.GetInt("policy.min_rsa_key_size"),
+		rejectExpiredCerts: viper.GetBool("policy.reject_expired_certificates"),
+		allowedEntryKinds:  stringSet(viper.GetStringSlice("policy.allowed_entry_kinds")),
+		allowedKeyAlgos:    stringSet(viper.GetStringSlice("policy.allowed_key_algorithms")),
+		now:                time.Now,
+	}
+	if fips.Enabled {
+		e.rejectWeakDigests = true
+		if e.minRSAKeySize < fips.MinRSAKeySize {
+			e.minRSAKeySize = fips.MinRSAKeySize
+		}
+		approved := stringSet(fips.KeyAlgorithms())
+		if len(e.allowedKeyAlgos) == 0 {
+			e.allowedKeyAlgos = approved
+		} else {
+			// the configured set may only narrow the approved one
+			for algo := range e.allowedKeyAlgos {
+				if !approved[algo] {
+					delete(e.allowedKeyAlgos, algo)
+				}
+			}
+		}
+	}
+	return e
+}
+
+func stringSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+	return set
+}
+
+// Enabled reports whether any rule is configured; with no rules the engine
+// can be skipped entirely on the upload path.
+func (e *Engine) Enabled() bool {
+	return e.rejectWeakDigests || e.minRSAKeySize > 0 || e.rejectExpiredCerts ||
+		len(e.allowedEntryKinds) > 0 || len(e.allowedKeyAlgos) > 0
+}
+
+// CheckKind reports whether this log accepts entries of the given kind.
+func (e *Engine) CheckKind(kind string) error {
+	if len(e.allowedEntryKinds) == 0 || e.allowedEntryKinds[strings.ToLower(kind)] {
+		return nil
+	}
+	return &Error{Violations: []Violation{{
+		Rule:   "allowed_entry_kinds",
+		Field:  "kind",
+		Reason: fmt.Sprintf("entry kind %q is not accepted by this log", kind),
+	}}}
+}
+
+// CheckEntry evaluates the policy against a proposed entry, which must
+// marshal to JSON. It walks the entry structure generically so the same
+// rules apply to every entry kind, including ones added later. A nil return
+// means the entry is admissible.
+func (e *Engine) CheckEntry(entry interface{}) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshalling entry for policy evaluation: %w", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return fmt.Errorf("unmarshalling entry for policy evaluation: %w", err)
+	}
+
+	var violations []Violation
+	e.walk("", "", decoded, &violations)
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+func (e *Engine) walk(path, key string, value interface{}, violations *[]Violation) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			e.walk(childPath, k, child, violations)
+		}
+	case []interface{}:
+		for i, child := range v {
+			e.walk(fmt.Sprintf("%s[%d]", path, i), key, child, violations)
+		}
+	case string:
+		e.checkString(path, key, v, violations)
+	}
+}
+
+func (e *Engine) checkString(path, key, value string, violations *[]Violation) {
+	if e.rejectWeakDigests && key == "algorithm" && weakDigestAlgorithms[strings.ToLower(value)] {
+		*violations = append(*violations, Violation{
+			Rule:   "reject_weak_digests",
+			Field:  path,
+			Reason: fmt.Sprintf("digest algorithm %q is not accepted by this log", value),
+		})
+		return
+	}
+
+	// key material and certificates arrive as base64-encoded PEM
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || !strings.Contains(string(decoded), "-----BEGIN") {
+		return
+	}
+	rest := decoded
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+			if e.rejectExpiredCerts && cert.NotAfter.Before(e.now()) {
+				*violations = append(*violations, Violation{
+					Rule:   "reject_expired_certificates",
+					Field:  path,
+					Reason: fmt.Sprintf("certificate expired %s", cert.NotAfter.Format(time.RFC3339)),
+				})
+			}
+			e.checkPublicKey(path, cert.PublicKey, violations)
+		case "PUBLIC KEY":
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				continue
+			}
+			e.checkPublicKey(path, pub, violations)
+		case "RSA PUBLIC KEY":
+			pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+			if err != nil {
+				continue
+			}
+			e.checkPublicKey(path, pub, violations)
+		}
+	}
+}
+
+func (e *Engine) checkPublicKey(path string, pub interface{}, violations *[]Violation) {
+	if rsaKey, ok := pub.(*rsa.PublicKey); ok && e.minRSAKeySize > 0 {
+		if size := rsaKey.N.BitLen(); size < e.minRSAKeySize {
+			*violations = append(*violations, Violation{
+				Rule:   "min_rsa_key_size",
+				Field:  path,
+				Reason: fmt.Sprintf("RSA key is %d bits, below the required minimum of %d", size, e.minRSAKeySize),
+			})
+		}
+	}
+	if len(e.allowedKeyAlgos) == 0 {
+		return
+	}
+	algo := keyAlgorithmName(pub)
+	if !e.allowedKeyAlgos[algo] {
+		*violations = append(*violations, Violation{
+			Rule:   "allowed_key_algorithms",
+			Field:  path,
+			Reason: fmt.Sprintf("key algorithm %q is not accepted by this log", algo),
+		})
+	}
+}
+
+// keyAlgorithmName maps a parsed public key to the name operators use in
+// policy.allowed_key_algorithms.
+func keyAlgorithmName(pub interface{}) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "rsa"
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return "ecdsa-p256"
+		case elliptic.P384():
+			return "ecdsa-p384"
+		case elliptic.P521():
+			return "ecdsa-p521"
+		}
+		return "ecdsa"
+	case ed25519.PublicKey:
+		return "ed25519"
+	}
+	return "unknown"
+}
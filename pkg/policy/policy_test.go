@@ -0,0 +1,207 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func pemBase64(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+func rsaPublicKeyBase64(t *testing.T, bits int) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling RSA key: %v", err)
+	}
+	return pemBase64(t, "PUBLIC KEY", der)
+}
+
+func certificateBase64(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "policy-test"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	return pemBase64(t, "CERTIFICATE", der)
+}
+
+func TestCheckEntry(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	engine := &Engine{
+		rejectWeakDigests:  true,
+		minRSAKeySize:      2048,
+		rejectExpiredCerts: true,
+		now:                func() time.Time { return now },
+	}
+
+	tests := []struct {
+		name         string
+		entry        interface{}
+		expectedRule string
+	}{
+		{
+			name: "valid entry",
+			entry: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"data":      map[string]interface{}{"hash": map[string]interface{}{"algorithm": "sha256", "value": "deadbeef"}},
+					"signature": map[string]interface{}{"publicKey": map[string]interface{}{"content": rsaPublicKeyBase64(t, 2048)}},
+				},
+			},
+		},
+		{
+			name: "sha1 digest",
+			entry: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"data": map[string]interface{}{"hash": map[string]interface{}{"algorithm": "sha1", "value": "deadbeef"}},
+				},
+			},
+			expectedRule: "reject_weak_digests",
+		},
+		{
+			name: "short RSA key",
+			entry: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"signature": map[string]interface{}{"publicKey": map[string]interface{}{"content": rsaPublicKeyBase64(t, 1024)}},
+				},
+			},
+			expectedRule: "min_rsa_key_size",
+		},
+		{
+			name: "expired certificate",
+			entry: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"signature": map[string]interface{}{"publicKey": map[string]interface{}{"content": certificateBase64(t, now.Add(-time.Hour))}},
+				},
+			},
+			expectedRule: "reject_expired_certificates",
+		},
+		{
+			name: "unexpired certificate",
+			entry: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"signature": map[string]interface{}{"publicKey": map[string]interface{}{"content": certificateBase64(t, now.Add(time.Hour))}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.CheckEntry(tt.entry)
+			if tt.expectedRule == "" {
+				if err != nil {
+					t.Fatalf("expected entry to be admitted, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected violation of %s, entry was admitted", tt.expectedRule)
+			}
+			var policyErr *Error
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("expected *policy.Error, got %T: %v", err, err)
+			}
+			if len(policyErr.Violations) != 1 || policyErr.Violations[0].Rule != tt.expectedRule {
+				t.Fatalf("expected single violation of %s, got %+v", tt.expectedRule, policyErr.Violations)
+			}
+		})
+	}
+}
+
+func TestCheckKind(t *testing.T) {
+	engine := &Engine{now: time.Now}
+	if err := engine.CheckKind("rekord"); err != nil {
+		t.Errorf("engine with no kind restriction should accept any kind, got: %v", err)
+	}
+
+	engine.allowedEntryKinds = map[string]bool{"hashedrekord": true}
+	if err := engine.CheckKind("hashedrekord"); err != nil {
+		t.Errorf("expected hashedrekord to be accepted, got: %v", err)
+	}
+	if err := engine.CheckKind("rekord"); err == nil {
+		t.Error("expected rekord to be rejected")
+	}
+}
+
+func TestAllowedKeyAlgorithms(t *testing.T) {
+	engine := &Engine{
+		allowedKeyAlgos: map[string]bool{"ecdsa-p256": true},
+		now:             time.Now,
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+	entry := func(content string) interface{} {
+		return map[string]interface{}{
+			"spec": map[string]interface{}{
+				"signature": map[string]interface{}{"publicKey": map[string]interface{}{"content": content}},
+			},
+		}
+	}
+
+	if err := engine.CheckEntry(entry(pemBase64(t, "PUBLIC KEY", der))); err != nil {
+		t.Errorf("expected ECDSA P-256 key to be accepted, got: %v", err)
+	}
+	if err := engine.CheckEntry(entry(rsaPublicKeyBase64(t, 2048))); err == nil {
+		t.Error("expected RSA key to be rejected when only ecdsa-p256 is allowed")
+	}
+}
+
+func TestEngineDisabled(t *testing.T) {
+	engine := &Engine{now: time.Now}
+	if engine.Enabled() {
+		t.Error("engine with no rules configured should be disabled")
+	}
+	engine.minRSAKeySize = 2048
+	if !engine.Enabled() {
+		t.Error("engine with a rule configured should be enabled")
+	}
+}
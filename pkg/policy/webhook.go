@@ -0,0 +1,144 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/log"
+)
+
+// maxWebhookResponseSize bounds how much of an admission response is read
+const maxWebhookResponseSize = 1 << 20
+
+// WebhookRequest is the JSON document POSTed to the admission endpoint for
+// each proposed entry.
+type WebhookRequest struct {
+	Kind       string          `json:"kind"`
+	APIVersion string          `json:"apiVersion,omitempty"`
+	Entry      json.RawMessage `json:"entry"`
+}
+
+// WebhookResponse is the admission decision returned by the endpoint.
+type WebhookResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// DeniedError is returned when the webhook explicitly denies an entry; the
+// reason is surfaced to the client that submitted it.
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	return "entry denied by admission webhook: " + e.Reason
+}
+
+// Webhook calls an external HTTP endpoint with each proposed entry before it
+// is queued, letting platform teams enforce organization-specific admission
+// policy without modifying the server. The rules in Engine run first; the
+// webhook sees only entries that already passed them.
+type Webhook struct {
+	url      string
+	failOpen bool
+	client   *http.Client
+}
+
+// NewWebhookFromConfig builds a Webhook from the policy.webhook.* viper
+// keys, or returns nil when no endpoint is configured.
+func NewWebhookFromConfig() *Webhook {
+	url := viper.GetString("policy.webhook.url")
+	if url == "" {
+		return nil
+	}
+	timeout := viper.GetDuration("policy.webhook.timeout")
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Webhook{
+		url:      url,
+		failOpen: viper.GetBool("policy.webhook.fail_open"),
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Admit asks the configured endpoint whether the proposed entry may be
+// queued. A nil return admits the entry; a DeniedError carries the reason
+// the endpoint gave. When the endpoint cannot be reached or answers
+// unintelligibly, the entry is admitted if fail-open is configured and the
+// transport error is returned otherwise.
+func (w *Webhook) Admit(ctx context.Context, kind, apiVersion string, entry interface{}) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshalling entry for admission webhook: %w", err)
+	}
+	body, err := json.Marshal(WebhookRequest{
+		Kind:       kind,
+		APIVersion: apiVersion,
+		Entry:      raw,
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling admission webhook request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building admission webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return w.failure(fmt.Errorf("calling admission webhook: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return w.failure(fmt.Errorf("admission webhook returned status %d", resp.StatusCode))
+	}
+	decision := WebhookResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxWebhookResponseSize)).Decode(&decision); err != nil {
+		return w.failure(fmt.Errorf("decoding admission webhook response: %w", err))
+	}
+
+	if !decision.Allowed {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		return &DeniedError{Reason: reason}
+	}
+	return nil
+}
+
+// failure applies the fail-open setting to an error reaching or reading the
+// endpoint; explicit denials never fail open.
+func (w *Webhook) failure(err error) error {
+	if w.failOpen {
+		log.Logger.Warnf("admitting entry without webhook decision: %v", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func webhookForTest(url string, failOpen bool) *Webhook {
+	return &Webhook{
+		url:      url,
+		failOpen: failOpen,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func TestWebhookAdmit(t *testing.T) {
+	var received WebhookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decoding webhook request: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(WebhookResponse{Allowed: true})
+	}))
+	defer srv.Close()
+
+	entry := map[string]string{"payload": "aGVsbG8="}
+	if err := webhookForTest(srv.URL, false).Admit(context.Background(), "hashedrekord", "0.0.1", entry); err != nil {
+		t.Fatalf("unexpected error admitting entry: %v", err)
+	}
+	if received.Kind != "hashedrekord" || received.APIVersion != "0.0.1" {
+		t.Errorf("unexpected request metadata: kind=%v apiVersion=%v", received.Kind, received.APIVersion)
+	}
+	if string(received.Entry) != `{"payload":"aGVsbG8="}` {
+		t.Errorf("unexpected entry document: %s", received.Entry)
+	}
+}
+
+func TestWebhookDeny(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(WebhookResponse{Allowed: false, Reason: "unsigned artifacts are not accepted"})
+	}))
+	defer srv.Close()
+
+	// denials never fail open
+	err := webhookForTest(srv.URL, true).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{})
+	var denied *DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("expected DeniedError, got %v", err)
+	}
+	if denied.Reason != "unsigned artifacts are not accepted" {
+		t.Errorf("unexpected denial reason: %v", denied.Reason)
+	}
+
+	srvNoReason := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(WebhookResponse{Allowed: false})
+	}))
+	defer srvNoReason.Close()
+
+	err = webhookForTest(srvNoReason.URL, false).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{})
+	if !errors.As(err, &denied) || denied.Reason != "no reason given" {
+		t.Errorf("expected default denial reason, got %v", err)
+	}
+}
+
+func TestWebhookFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	if err := webhookForTest(srv.URL, false).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{}); err == nil {
+		t.Error("expected error when webhook returns 500 and fail-open is off")
+	}
+	if err := webhookForTest(srv.URL, true).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{}); err != nil {
+		t.Errorf("unexpected error with fail-open on: %v", err)
+	}
+
+	unreachable := "http://127.0.0.1:1/admission"
+	if err := webhookForTest(unreachable, false).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{}); err == nil {
+		t.Error("expected error when webhook is unreachable and fail-open is off")
+	}
+	if err := webhookForTest(unreachable, true).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{}); err != nil {
+		t.Errorf("unexpected error with fail-open on: %v", err)
+	}
+
+	srvMalformed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer srvMalformed.Close()
+
+	if err := webhookForTest(srvMalformed.URL, false).Admit(context.Background(), "hashedrekord", "0.0.1", map[string]string{}); err == nil {
+		t.Error("expected error for malformed webhook response")
+	}
+}
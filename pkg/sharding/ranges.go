@@ -42,6 +42,11 @@ type LogRange struct {
 	TreeLength       int64  `yaml:"treeLength"`
 	EncodedPublicKey string `yaml:"encodedPublicKey"`
 	decodedPublicKey string
+	// NotBefore/NotAfter optionally record when this shard accepted entries,
+	// in RFC 3339 form; they are surfaced to clients via the trusted root
+	// endpoint and are not otherwise interpreted by the server.
+	NotBefore string `yaml:"notBefore"`
+	NotAfter  string `yaml:"notAfter"`
 }
 
 func NewLogRanges(ctx context.Context, logClient trillian.TrillianLogClient, path string, treeID uint) (LogRanges, error) {
@@ -57,6 +62,9 @@ func NewLogRanges(ctx context.Context, logClient trillian.TrillianLogClient, pat
 	if err != nil {
 		return LogRanges{}, fmt.Errorf("log ranges from path: %w", err)
 	}
+	if err := validateRanges(ranges, int64(treeID)); err != nil {
+		return LogRanges{}, fmt.Errorf("validating sharding config: %w", err)
+	}
 	for i, r := range ranges {
 		r, err := updateRange(ctx, logClient, r)
 		if err != nil {
@@ -64,7 +72,7 @@ func NewLogRanges(ctx context.Context, logClient trillian.TrillianLogClient, pat
 		}
 		ranges[i] = r
 	}
-	log.Logger.Info("Ranges: %v", ranges)
+	log.Logger.Infof("Ranges: %v", ranges)
 	return LogRanges{
 		inactive: ranges,
 		active:   int64(treeID),
@@ -87,6 +95,29 @@ func logRangesFromPath(path string) (Ranges, error) {
 	return ranges, nil
 }
 
+// validateRanges ensures the sharding config is usable before serving reads
+// from it: tree IDs must be present and unique, tree lengths must not be
+// negative, and the active tree must not be listed as an inactive shard.
+func validateRanges(ranges Ranges, activeTreeID int64) error {
+	seen := map[int64]struct{}{}
+	for _, r := range ranges {
+		if r.TreeID == 0 {
+			return errors.New("treeID must be specified for each shard")
+		}
+		if r.TreeID == activeTreeID {
+			return fmt.Errorf("active tree %d cannot also be listed as an inactive shard", activeTreeID)
+		}
+		if r.TreeLength < 0 {
+			return fmt.Errorf("treeLength for tree %d must not be negative", r.TreeID)
+		}
+		if _, ok := seen[r.TreeID]; ok {
+			return fmt.Errorf("duplicate tree ID %d in sharding config", r.TreeID)
+		}
+		seen[r.TreeID] = struct{}{}
+	}
+	return nil
+}
+
 // updateRange fills in any missing information about the range
 func updateRange(ctx context.Context, logClient trillian.TrillianLogClient, r LogRange) (LogRange, error) {
 	// If a tree length wasn't passed in, get it ourselves
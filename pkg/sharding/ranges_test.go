@@ -193,3 +193,47 @@ func TestPublicKey(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRanges(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		ranges       Ranges
+		activeTreeID int64
+		wantErr      bool
+	}{
+		{
+			name:         "valid",
+			ranges:       Ranges{{TreeID: 1, TreeLength: 3}, {TreeID: 2, TreeLength: 4}},
+			activeTreeID: 3,
+		},
+		{
+			name:         "missing tree ID",
+			ranges:       Ranges{{TreeLength: 3}},
+			activeTreeID: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "duplicate tree ID",
+			ranges:       Ranges{{TreeID: 1, TreeLength: 3}, {TreeID: 1, TreeLength: 4}},
+			activeTreeID: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "active tree listed as inactive",
+			ranges:       Ranges{{TreeID: 3, TreeLength: 3}},
+			activeTreeID: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "negative tree length",
+			ranges:       Ranges{{TreeID: 1, TreeLength: -1}},
+			activeTreeID: 3,
+			wantErr:      true,
+		},
+	} {
+		err := validateRanges(tt.ranges, tt.activeTreeID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateRanges() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
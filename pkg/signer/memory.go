@@ -25,6 +25,7 @@ import (
 )
 
 const MemoryScheme = "memory"
+const MemoryEd25519Scheme = "memory-ed25519"
 
 // returns an in-memory signer and verify, used for spinning up local instances
 type Memory struct {
@@ -41,3 +42,20 @@ func NewMemory() (*Memory, error) {
 		ECDSASignerVerifier: *sv,
 	}, nil
 }
+
+// MemoryEd25519 is an in-memory Ed25519 signer; Ed25519 signatures are
+// smaller than ECDSA ones and widely supported by note verification tooling
+type MemoryEd25519 struct {
+	signature.ED25519SignerVerifier
+}
+
+func NewMemoryEd25519() (*MemoryEd25519, error) {
+	// generate a keypair
+	sv, _, err := signature.NewED25519SignerVerifier(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryEd25519{
+		ED25519SignerVerifier: *sv,
+	}, nil
+}
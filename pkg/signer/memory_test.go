@@ -19,6 +19,8 @@ package signer
 import (
 	"context"
 	"testing"
+
+	"github.com/sigstore/rekor/pkg/fips"
 )
 
 func TestMemory(t *testing.T) {
@@ -33,3 +35,22 @@ func TestMemory(t *testing.T) {
 		t.Fatalf("unexpected error creating public key")
 	}
 }
+
+func TestMemoryEd25519(t *testing.T) {
+	ctx := context.Background()
+
+	m, err := New(ctx, "memory-ed25519")
+	if fips.Enabled {
+		if err == nil {
+			t.Fatal("expected memory-ed25519 to be rejected in a FIPS build")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("new memory-ed25519: %v", err)
+	}
+	_, err = m.PublicKey()
+	if err != nil {
+		t.Fatalf("unexpected error creating public key")
+	}
+}
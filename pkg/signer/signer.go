@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/sigstore/rekor/pkg/fips"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/kms/gcp"
 )
@@ -31,6 +32,12 @@ func New(ctx context.Context, signer string) (signature.Signer, error) {
 		return gcp.LoadSignerVerifier(ctx, signer)
 	case signer == MemoryScheme:
 		return NewMemory()
+	case signer == MemoryEd25519Scheme:
+		// Ed25519 is not implemented by boringcrypto
+		if fips.Enabled {
+			return nil, fmt.Errorf("signer %v is not available in a FIPS build", signer)
+		}
+		return NewMemoryEd25519()
 	default:
 		return nil, fmt.Errorf("please provide a valid signer, %v is not valid", signer)
 	}
@@ -18,23 +18,35 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/sigstore/rekor/pkg/log"
 
 	"github.com/spf13/viper"
 	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
 
 	// Blank imports to register storage
+	_ "gocloud.dev/blob/azureblob"
 	_ "gocloud.dev/blob/fileblob"
 	_ "gocloud.dev/blob/gcsblob"
 	_ "gocloud.dev/blob/memblob"
+	_ "gocloud.dev/blob/s3blob"
 )
 
 type AttestationStorage interface {
 	StoreAttestation(ctx context.Context, key string, attestation []byte) error
 	FetchAttestation(ctx context.Context, key string) ([]byte, error)
+	ReleaseAttestation(ctx context.Context, key string) error
 }
 
+// NewAttestationStorage opens the bucket named by the attestation_storage_bucket
+// URL. The scheme selects the backend: file://, gs://, mem://, azblob://, or
+// s3://. S3-compatible stores such as MinIO and Ceph are reached through the
+// s3 scheme with an endpoint override, e.g.
+// s3://bucket?endpoint=minio.example.com:9000&s3ForcePathStyle=true&region=us-east-1.
 func NewAttestationStorage() (AttestationStorage, error) {
 	if url := viper.GetString("attestation_storage_bucket"); url != "" {
 		log.Logger.Infof("Configuring attestation storage at %s", url)
@@ -53,16 +65,44 @@ type Blob struct {
 	bucket *blob.Bucket
 }
 
+// refCountSuffix names the sidecar object holding the number of log entries
+// referencing an attestation body. Attestation keys are content digests
+// (sha256:...), so the suffix cannot collide with another attestation.
+//
+// The count is advisory: the portable blob API offers no conditional writes,
+// so concurrent submissions of the same attestation can lose increments, and
+// multiple server replicas share the bucket. It serves as a hint for
+// retention tooling and must never be the sole basis for deleting a body.
+const refCountSuffix = ".refs"
+
+// StoreAttestation writes the attestation body only if no object exists at
+// key yet; keys are content digests, so an identical attestation attached to
+// many entries is stored once and its reference count incremented instead.
 func (b *Blob) StoreAttestation(ctx context.Context, key string, attestation []byte) error {
-	log.Logger.Infof("storing attestation at %s", key)
-	w, err := b.bucket.NewWriter(ctx, key, nil)
+	exists, err := b.bucket.Exists(ctx, key)
 	if err != nil {
 		return err
 	}
-	if _, err := w.Write(attestation); err != nil {
+	refs, err := b.readRefCount(ctx, key)
+	if err != nil {
 		return err
 	}
-	return w.Close()
+	if exists {
+		log.Logger.Infof("attestation already stored at %s, adding reference", key)
+	} else {
+		log.Logger.Infof("storing attestation at %s", key)
+		w, err := b.bucket.NewWriter(ctx, key, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(attestation); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+	return b.writeRefCount(ctx, key, refs+1)
 }
 
 func (b *Blob) FetchAttestation(ctx context.Context, key string) ([]byte, error) {
@@ -81,3 +121,59 @@ func (b *Blob) FetchAttestation(ctx context.Context, key string) ([]byte, error)
 	}
 	return data, nil
 }
+
+// ReleaseAttestation drops one reference to the attestation stored at key.
+// Nothing in the serving path calls this; it exists for retention tooling
+// working against the bucket. Because the count is advisory (see
+// refCountSuffix), the body is never deleted here, even at zero references:
+// an undercounted attestation may still back a live entry, and deleting it
+// would silently break FetchAttestation for that entry. Tooling must
+// cross-check candidates against the log before removing objects.
+func (b *Blob) ReleaseAttestation(ctx context.Context, key string) error {
+	refs, err := b.readRefCount(ctx, key)
+	if err != nil {
+		return err
+	}
+	if refs <= 0 {
+		return nil
+	}
+	if refs == 1 {
+		log.Logger.Infof("attestation at %s has no known references; leaving body for retention tooling", key)
+	}
+	return b.writeRefCount(ctx, key, refs-1)
+}
+
+func (b *Blob) readRefCount(ctx context.Context, key string) (int64, error) {
+	data, err := b.bucket.ReadAll(ctx, key+refCountSuffix)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			// attestations written before reference counting carry an
+			// implicit single reference
+			exists, err := b.bucket.Exists(ctx, key)
+			if err != nil {
+				return 0, err
+			}
+			if exists {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		return 0, err
+	}
+	refs, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing reference count for %s: %w", key, err)
+	}
+	return refs, nil
+}
+
+func (b *Blob) writeRefCount(ctx context.Context, key string, refs int64) error {
+	w, err := b.bucket.NewWriter(ctx, key+refCountSuffix, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(strconv.FormatInt(refs, 10))); err != nil {
+		return err
+	}
+	return w.Close()
+}
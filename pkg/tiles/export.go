@@ -0,0 +1,135 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/trillian"
+	trilliantypes "github.com/google/trillian/types"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob" // fileblob
+	_ "gocloud.dev/blob/gcsblob"
+	"golang.org/x/mod/sumdb/tlog"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// Export streams every leaf from the given Trillian tree and writes the log
+// to bucketURL as hash tiles, entry bundles, and a checkpoint. The recomputed
+// root is checked against the root Trillian publishes before anything beyond
+// entry bundles is written, so a successful export is self-consistent.
+func Export(ctx context.Context, logClient trillian.TrillianLogClient, treeID, batchSize int64, bucketURL string) error {
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
+	if err != nil {
+		return fmt.Errorf("opening bucket %s: %w", bucketURL, err)
+	}
+	defer bucket.Close()
+
+	rootResp, err := logClient.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: treeID})
+	if err != nil {
+		return fmt.Errorf("getting latest root: %w", err)
+	}
+	var root trilliantypes.LogRootV1
+	if err := root.UnmarshalBinary(rootResp.SignedLogRoot.LogRoot); err != nil {
+		return fmt.Errorf("unmarshalling root: %w", err)
+	}
+	treeSize := int64(root.TreeSize)
+	log.Logger.Infof("exporting tree %d at size %d to %s", treeID, treeSize, bucketURL)
+
+	store := &hashStore{}
+	bundle := EntryBundle{}
+	for start := int64(0); start < treeSize; start += batchSize {
+		count := batchSize
+		if remaining := treeSize - start; remaining < count {
+			count = remaining
+		}
+		resp, err := logClient.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+			LogId:      treeID,
+			StartIndex: start,
+			Count:      count,
+		})
+		if err != nil {
+			return fmt.Errorf("getting leaves [%d, %d): %w", start, start+count, err)
+		}
+		for _, leaf := range resp.Leaves {
+			if err := store.appendLeaf(leaf.LeafIndex, leaf.LeafValue); err != nil {
+				return fmt.Errorf("hashing leaf %d: %w", leaf.LeafIndex, err)
+			}
+			if len(bundle.Entries) == 0 {
+				bundle.FirstIndex = leaf.LeafIndex
+			}
+			bundle.Entries = append(bundle.Entries, leaf.LeafValue)
+			if len(bundle.Entries) == EntryBundleWidth {
+				if err := writeEntryBundle(ctx, bucket, bundle); err != nil {
+					return err
+				}
+				bundle = EntryBundle{}
+			}
+		}
+	}
+	if len(bundle.Entries) > 0 {
+		if err := writeEntryBundle(ctx, bucket, bundle); err != nil {
+			return err
+		}
+	}
+
+	if treeSize > 0 {
+		computedRoot, err := tlog.TreeHash(treeSize, store)
+		if err != nil {
+			return fmt.Errorf("computing root: %w", err)
+		}
+		if !bytes.Equal(computedRoot[:], root.RootHash) {
+			return fmt.Errorf("root mismatch at size %d: recomputed %x, log published %x", treeSize, computedRoot, root.RootHash)
+		}
+	}
+
+	for _, t := range tlog.NewTiles(Height, 0, treeSize) {
+		data, err := tlog.ReadTileData(t, store)
+		if err != nil {
+			return fmt.Errorf("building tile %s: %w", t.Path(), err)
+		}
+		if err := bucket.WriteAll(ctx, t.Path(), data, nil); err != nil {
+			return fmt.Errorf("writing tile %s: %w", t.Path(), err)
+		}
+	}
+
+	checkpoint := util.Checkpoint{
+		Origin: "Rekor",
+		Size:   root.TreeSize,
+		Hash:   root.RootHash,
+	}
+	if err := bucket.WriteAll(ctx, CheckpointPath, []byte(checkpoint.String()), nil); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	log.Logger.Infof("export complete: %d leaves, root %x", treeSize, root.RootHash)
+	return nil
+}
+
+func writeEntryBundle(ctx context.Context, bucket *blob.Bucket, bundle EntryBundle) error {
+	contents, err := bundle.marshal()
+	if err != nil {
+		return err
+	}
+	path := EntryBundlePath(bundle.FirstIndex/EntryBundleWidth, len(bundle.Entries))
+	if err := bucket.WriteAll(ctx, path, contents, nil); err != nil {
+		return fmt.Errorf("writing entry bundle %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiles
+
+import (
+	"context"
+	"fmt"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob" // fileblob
+	_ "gocloud.dev/blob/gcsblob"
+	"golang.org/x/mod/sumdb/tlog"
+
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// Reader answers entry and proof requests from an exported tile set instead
+// of Trillian. All hashes read from tiles are authenticated against the
+// checkpoint root before they are used in a proof.
+type Reader struct {
+	bucket     *blob.Bucket
+	checkpoint util.Checkpoint
+	tree       tlog.Tree
+}
+
+// NewReader opens the tile set at bucketURL and loads its checkpoint.
+func NewReader(ctx context.Context, bucketURL string) (*Reader, error) {
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("opening bucket %s: %w", bucketURL, err)
+	}
+	contents, err := bucket.ReadAll(ctx, CheckpointPath)
+	if err != nil {
+		bucket.Close()
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	var checkpoint util.Checkpoint
+	if err := checkpoint.UnmarshalCheckpoint(contents); err != nil {
+		bucket.Close()
+		return nil, fmt.Errorf("parsing checkpoint: %w", err)
+	}
+	var rootHash tlog.Hash
+	copy(rootHash[:], checkpoint.Hash)
+	return &Reader{
+		bucket:     bucket,
+		checkpoint: checkpoint,
+		tree:       tlog.Tree{N: int64(checkpoint.Size), Hash: rootHash},
+	}, nil
+}
+
+// Close releases the underlying bucket connection.
+func (r *Reader) Close() error {
+	return r.bucket.Close()
+}
+
+// Checkpoint returns the tree head the tile set was exported at.
+func (r *Reader) Checkpoint() util.Checkpoint {
+	return r.checkpoint
+}
+
+// GetEntry returns the raw leaf value at the given log index.
+func (r *Reader) GetEntry(ctx context.Context, index int64) ([]byte, error) {
+	if index < 0 || index >= r.tree.N {
+		return nil, fmt.Errorf("index %d out of range [0, %d)", index, r.tree.N)
+	}
+	bundleIndex := index / EntryBundleWidth
+	width := EntryBundleWidth
+	if remaining := r.tree.N - bundleIndex*EntryBundleWidth; remaining < int64(width) {
+		width = int(remaining)
+	}
+	contents, err := r.bucket.ReadAll(ctx, EntryBundlePath(bundleIndex, width))
+	if err != nil {
+		return nil, fmt.Errorf("reading entry bundle %d: %w", bundleIndex, err)
+	}
+	bundle, err := unmarshalEntryBundle(contents)
+	if err != nil {
+		return nil, fmt.Errorf("parsing entry bundle %d: %w", bundleIndex, err)
+	}
+	offset := index - bundle.FirstIndex
+	if offset < 0 || offset >= int64(len(bundle.Entries)) {
+		return nil, fmt.Errorf("index %d not covered by bundle starting at %d with %d entries", index, bundle.FirstIndex, len(bundle.Entries))
+	}
+	return bundle.Entries[offset], nil
+}
+
+// InclusionProof returns a proof that the record at the given index is
+// included in the checkpoint tree.
+func (r *Reader) InclusionProof(ctx context.Context, index int64) (tlog.RecordProof, error) {
+	return tlog.ProveRecord(r.tree.N, index, tlog.TileHashReader(r.tree, &bucketTileReader{ctx: ctx, bucket: r.bucket}))
+}
+
+// ConsistencyProof returns a proof that the checkpoint tree extends a
+// previous tree of the given size.
+func (r *Reader) ConsistencyProof(ctx context.Context, oldSize int64) (tlog.TreeProof, error) {
+	return tlog.ProveTree(r.tree.N, oldSize, tlog.TileHashReader(r.tree, &bucketTileReader{ctx: ctx, bucket: r.bucket}))
+}
+
+// bucketTileReader fetches hash tiles from the bucket on demand.
+type bucketTileReader struct {
+	ctx    context.Context
+	bucket *blob.Bucket
+}
+
+func (b *bucketTileReader) Height() int {
+	return Height
+}
+
+func (b *bucketTileReader) ReadTiles(requested []tlog.Tile) ([][]byte, error) {
+	data := make([][]byte, len(requested))
+	for i, t := range requested {
+		contents, err := b.bucket.ReadAll(b.ctx, t.Path())
+		if err != nil {
+			return nil, fmt.Errorf("reading tile %s: %w", t.Path(), err)
+		}
+		data[i] = contents
+	}
+	return data, nil
+}
+
+// SaveTiles is a no-op; tiles are already durable in the bucket.
+func (b *bucketTileReader) SaveTiles([]tlog.Tile, [][]byte) {}
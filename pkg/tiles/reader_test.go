@@ -0,0 +1,147 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"gocloud.dev/blob"
+	"golang.org/x/mod/sumdb/tlog"
+
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// writeTileSet builds a tile set for the given leaves directly through the
+// storage helpers, standing in for an export from Trillian.
+func writeTileSet(ctx context.Context, t *testing.T, bucket *blob.Bucket, leaves [][]byte) tlog.Hash {
+	t.Helper()
+	store := &hashStore{}
+	bundle := EntryBundle{}
+	for i, leaf := range leaves {
+		if err := store.appendLeaf(int64(i), leaf); err != nil {
+			t.Fatalf("appending leaf %d: %v", i, err)
+		}
+		if len(bundle.Entries) == 0 {
+			bundle.FirstIndex = int64(i)
+		}
+		bundle.Entries = append(bundle.Entries, leaf)
+		if len(bundle.Entries) == EntryBundleWidth {
+			if err := writeEntryBundle(ctx, bucket, bundle); err != nil {
+				t.Fatalf("writing entry bundle: %v", err)
+			}
+			bundle = EntryBundle{}
+		}
+	}
+	if len(bundle.Entries) > 0 {
+		if err := writeEntryBundle(ctx, bucket, bundle); err != nil {
+			t.Fatalf("writing entry bundle: %v", err)
+		}
+	}
+	treeSize := int64(len(leaves))
+	for _, tile := range tlog.NewTiles(Height, 0, treeSize) {
+		data, err := tlog.ReadTileData(tile, store)
+		if err != nil {
+			t.Fatalf("building tile %s: %v", tile.Path(), err)
+		}
+		if err := bucket.WriteAll(ctx, tile.Path(), data, nil); err != nil {
+			t.Fatalf("writing tile %s: %v", tile.Path(), err)
+		}
+	}
+	root, err := tlog.TreeHash(treeSize, store)
+	if err != nil {
+		t.Fatalf("computing root: %v", err)
+	}
+	checkpoint := util.Checkpoint{Origin: "Rekor", Size: uint64(treeSize), Hash: root[:]}
+	if err := bucket.WriteAll(ctx, CheckpointPath, []byte(checkpoint.String()), nil); err != nil {
+		t.Fatalf("writing checkpoint: %v", err)
+	}
+	return root
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	bucketURL := "file://" + t.TempDir()
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
+	if err != nil {
+		t.Fatalf("opening bucket: %v", err)
+	}
+	defer bucket.Close()
+
+	// an odd size exercises partial tiles and a partial entry bundle
+	leaves := make([][]byte, EntryBundleWidth+57)
+	for i := range leaves {
+		leaves[i] = []byte(fmt.Sprintf("leaf data %d", i))
+	}
+	root := writeTileSet(ctx, t, bucket, leaves)
+
+	reader, err := NewReader(ctx, bucketURL)
+	if err != nil {
+		t.Fatalf("opening reader: %v", err)
+	}
+	defer reader.Close()
+
+	if got := reader.Checkpoint().Size; got != uint64(len(leaves)) {
+		t.Fatalf("checkpoint size %d, expected %d", got, len(leaves))
+	}
+
+	for _, index := range []int64{0, 1, EntryBundleWidth - 1, EntryBundleWidth, int64(len(leaves)) - 1} {
+		entry, err := reader.GetEntry(ctx, index)
+		if err != nil {
+			t.Fatalf("getting entry %d: %v", index, err)
+		}
+		if !bytes.Equal(entry, leaves[index]) {
+			t.Errorf("entry %d = %q, expected %q", index, entry, leaves[index])
+		}
+
+		proof, err := reader.InclusionProof(ctx, index)
+		if err != nil {
+			t.Fatalf("getting inclusion proof for %d: %v", index, err)
+		}
+		if err := tlog.CheckRecord(proof, int64(len(leaves)), root, index, tlog.RecordHash(entry)); err != nil {
+			t.Errorf("inclusion proof for %d failed verification: %v", index, err)
+		}
+	}
+
+	if _, err := reader.GetEntry(ctx, int64(len(leaves))); err == nil {
+		t.Error("expected error getting entry past the checkpoint size")
+	}
+
+	oldRoot, err := tlog.TreeHash(10, mustHashStore(t, leaves[:10]))
+	if err != nil {
+		t.Fatalf("computing old root: %v", err)
+	}
+	proof, err := reader.ConsistencyProof(ctx, 10)
+	if err != nil {
+		t.Fatalf("getting consistency proof: %v", err)
+	}
+	if err := tlog.CheckTree(proof, int64(len(leaves)), root, 10, oldRoot); err != nil {
+		t.Errorf("consistency proof failed verification: %v", err)
+	}
+}
+
+func mustHashStore(t *testing.T, leaves [][]byte) *hashStore {
+	t.Helper()
+	store := &hashStore{}
+	for i, leaf := range leaves {
+		if err := store.appendLeaf(int64(i), leaf); err != nil {
+			t.Fatalf("appending leaf %d: %v", i, err)
+		}
+	}
+	return store
+}
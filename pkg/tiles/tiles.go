@@ -0,0 +1,98 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tiles exports the log as tiled Merkle tree data that can be served
+// as static files, and answers read requests from a previously exported tile
+// set. Hash tiles follow the golang.org/x/mod/sumdb/tlog layout; entries are
+// grouped into fixed-width bundles alongside them.
+package tiles
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+const (
+	// Height is the tile height used for all exported hash tiles; 2^8 hashes
+	// per tile matches the sum database and keeps tile counts manageable.
+	Height = 8
+
+	// EntryBundleWidth is the number of leaves stored per entry bundle.
+	EntryBundleWidth = 1 << Height
+
+	// CheckpointPath is the object name of the current tree head.
+	CheckpointPath = "checkpoint"
+)
+
+// EntryBundlePath returns the object name for the bundle holding the given
+// bundle index. A partial bundle (fewer than EntryBundleWidth leaves, only
+// ever the last one) is stored under a distinct name so a later full bundle
+// never needs to overwrite it in immutable storage.
+func EntryBundlePath(bundleIndex int64, width int) string {
+	if width < EntryBundleWidth {
+		return fmt.Sprintf("entries/%08d.p%d", bundleIndex, width)
+	}
+	return fmt.Sprintf("entries/%08d", bundleIndex)
+}
+
+// EntryBundle is the stored form of a run of consecutive leaves.
+type EntryBundle struct {
+	// FirstIndex is the log index of the first leaf in the bundle.
+	FirstIndex int64 `json:"firstIndex"`
+	// Entries holds the raw leaf values, base64-encoded by encoding/json.
+	Entries [][]byte `json:"entries"`
+}
+
+func (b EntryBundle) marshal() ([]byte, error) {
+	return json.Marshal(b)
+}
+
+func unmarshalEntryBundle(data []byte) (*EntryBundle, error) {
+	var b EntryBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// hashStore is an in-memory tlog.HashReader over the stored hashes of a tree,
+// populated leaf by leaf during an export.
+type hashStore struct {
+	hashes []tlog.Hash
+}
+
+// appendLeaf incorporates the next leaf's stored hashes; leaves must be
+// appended in log order.
+func (s *hashStore) appendLeaf(n int64, data []byte) error {
+	hashes, err := tlog.StoredHashes(n, data, s)
+	if err != nil {
+		return err
+	}
+	s.hashes = append(s.hashes, hashes...)
+	return nil
+}
+
+func (s *hashStore) ReadHashes(indexes []int64) ([]tlog.Hash, error) {
+	out := make([]tlog.Hash, 0, len(indexes))
+	for _, i := range indexes {
+		if i < 0 || i >= int64(len(s.hashes)) {
+			return nil, fmt.Errorf("hash index %d out of range [0, %d)", i, len(s.hashes))
+		}
+		out = append(out, s.hashes[i])
+	}
+	return out, nil
+}
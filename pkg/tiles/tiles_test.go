@@ -0,0 +1,74 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiles
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+func TestEntryBundlePath(t *testing.T) {
+	tests := []struct {
+		bundleIndex int64
+		width       int
+		expected    string
+	}{
+		{0, EntryBundleWidth, "entries/00000000"},
+		{12, EntryBundleWidth, "entries/00000012"},
+		{3, 17, "entries/00000003.p17"},
+	}
+	for _, tt := range tests {
+		if got := EntryBundlePath(tt.bundleIndex, tt.width); got != tt.expected {
+			t.Errorf("EntryBundlePath(%d, %d) = %q, expected %q", tt.bundleIndex, tt.width, got, tt.expected)
+		}
+	}
+}
+
+// TestHashStoreMatchesRFC6962 checks that the tree head computed over the
+// stored hashes agrees with an independent RFC 6962 implementation, i.e. that
+// exported tiles will verify against roots published by Trillian.
+func TestHashStoreMatchesRFC6962(t *testing.T) {
+	store := &hashStore{}
+	rf := compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	cr := rf.NewEmptyRange(0)
+
+	for i := int64(0); i < 1000; i++ {
+		leaf := []byte(fmt.Sprintf("leaf data %d", i))
+		if err := store.appendLeaf(i, leaf); err != nil {
+			t.Fatalf("appending leaf %d: %v", i, err)
+		}
+		if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(leaf), nil); err != nil {
+			t.Fatalf("appending to compact range: %v", err)
+		}
+
+		tlogRoot, err := tlog.TreeHash(i+1, store)
+		if err != nil {
+			t.Fatalf("computing tree hash at size %d: %v", i+1, err)
+		}
+		compactRoot, err := cr.GetRootHash(nil)
+		if err != nil {
+			t.Fatalf("computing compact range root at size %d: %v", i+1, err)
+		}
+		if !bytes.Equal(tlogRoot[:], compactRoot) {
+			t.Fatalf("root mismatch at size %d: tlog %x, compact range %x", i+1, tlogRoot, compactRoot)
+		}
+	}
+}
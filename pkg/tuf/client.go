@@ -0,0 +1,134 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tuf fetches Rekor trust material — the trusted root document
+// carrying log public keys and shard metadata — from a TUF repository, so
+// verifiers can bootstrap and refresh keys without out-of-band distribution.
+package tuf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/theupdateframework/go-tuf/client"
+	"github.com/theupdateframework/go-tuf/data"
+
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// DefaultTrustedRootTarget is the target name the trusted root document is
+// published under when no other name is configured.
+const DefaultTrustedRootTarget = "rekor.trustedRoot.json"
+
+// Client wraps a TUF client rooted in operator-supplied root metadata. All
+// downloads are verified against the repository's signed metadata, including
+// rollback and freeze protection from the TUF workflow.
+type Client struct {
+	tuf *client.Client
+}
+
+// New returns a Client for the TUF repository at repositoryURL, trusting only
+// the root role keys found in rootJSON (an initial, out-of-band obtained
+// root.json). The initial root is the sole trust anchor; everything else is
+// fetched and verified from the repository.
+func New(repositoryURL string, rootJSON []byte) (*Client, error) {
+	remote, err := client.HTTPRemoteStore(repositoryURL, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("configuring TUF remote store: %w", err)
+	}
+	c := client.NewClient(client.MemoryLocalStore(), remote)
+
+	keys, threshold, err := parseRootKeys(rootJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Init(keys, threshold); err != nil {
+		return nil, fmt.Errorf("initializing TUF client: %w", err)
+	}
+	if _, err := c.Update(); err != nil {
+		return nil, fmt.Errorf("updating TUF metadata: %w", err)
+	}
+	return &Client{tuf: c}, nil
+}
+
+// Update refreshes the TUF metadata from the repository.
+func (c *Client) Update() error {
+	if _, err := c.tuf.Update(); err != nil {
+		return fmt.Errorf("updating TUF metadata: %w", err)
+	}
+	return nil
+}
+
+// DownloadTarget fetches the named target file and verifies it against the
+// repository metadata.
+func (c *Client) DownloadTarget(name string) ([]byte, error) {
+	dest := &bufferDestination{}
+	if err := c.tuf.Download(name, dest); err != nil {
+		return nil, fmt.Errorf("downloading target %v: %w", name, err)
+	}
+	return dest.Bytes(), nil
+}
+
+// TrustedRoot fetches and parses the trusted root document published under
+// target; an empty target selects DefaultTrustedRootTarget.
+func (c *Client) TrustedRoot(target string) (*util.TrustedRoot, error) {
+	if target == "" {
+		target = DefaultTrustedRootTarget
+	}
+	contents, err := c.DownloadTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	return util.ParseTrustedRoot(contents)
+}
+
+// parseRootKeys extracts the root role's keys and signing threshold from an
+// initial root.json document.
+func parseRootKeys(rootJSON []byte) ([]*data.PublicKey, int, error) {
+	s := &data.Signed{}
+	if err := json.Unmarshal(rootJSON, s); err != nil {
+		return nil, 0, fmt.Errorf("parsing initial root metadata: %w", err)
+	}
+	root := &data.Root{}
+	if err := json.Unmarshal(s.Signed, root); err != nil {
+		return nil, 0, fmt.Errorf("parsing initial root metadata: %w", err)
+	}
+	rootRole, ok := root.Roles["root"]
+	if !ok {
+		return nil, 0, errors.New("initial root metadata declares no root role")
+	}
+	var keys []*data.PublicKey
+	for _, id := range rootRole.KeyIDs {
+		if key, ok := root.Keys[id]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) < rootRole.Threshold {
+		return nil, 0, fmt.Errorf("initial root metadata carries %d root key(s), below its threshold of %d", len(keys), rootRole.Threshold)
+	}
+	return keys, rootRole.Threshold, nil
+}
+
+// bufferDestination collects a downloaded target in memory.
+type bufferDestination struct {
+	bytes.Buffer
+}
+
+func (d *bufferDestination) Delete() error {
+	d.Reset()
+	return nil
+}
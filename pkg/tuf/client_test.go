@@ -0,0 +1,127 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"fmt"
+	"testing"
+)
+
+const rootTemplate = `{
+  "signed": {
+    "_type": "root",
+    "spec_version": "1.0",
+    "version": 1,
+    "expires": "2032-01-01T00:00:00Z",
+    "keys": {
+      "k1": {
+        "keytype": "ed25519",
+        "scheme": "ed25519",
+        "keyval": {"public": "5811ae211e2f45bb7c24e60c5d225a79a02ee50ac10c927a1d50ae1b2cbbbcba"}
+      },
+      "k2": {
+        "keytype": "ed25519",
+        "scheme": "ed25519",
+        "keyval": {"public": "e2f0e0ab6da662b9b2648df258a1f42226fcba32ab8a3c129980e4c3afba43ee"}
+      }
+    },
+    "roles": {
+      "root": {"keyids": [%s], "threshold": %d},
+      "snapshot": {"keyids": ["k1"], "threshold": 1},
+      "targets": {"keyids": ["k1"], "threshold": 1},
+      "timestamp": {"keyids": ["k1"], "threshold": 1}
+    },
+    "consistent_snapshot": false
+  },
+  "signatures": []
+}`
+
+func TestParseRootKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		rootJSON  string
+		wantKeys  int
+		wantErr   bool
+		threshold int
+	}{
+		{
+			name:      "single key",
+			rootJSON:  fmt.Sprintf(rootTemplate, `"k1"`, 1),
+			wantKeys:  1,
+			threshold: 1,
+		},
+		{
+			name:      "two keys threshold two",
+			rootJSON:  fmt.Sprintf(rootTemplate, `"k1", "k2"`, 2),
+			wantKeys:  2,
+			threshold: 2,
+		},
+		{
+			name:     "keys below threshold",
+			rootJSON: fmt.Sprintf(rootTemplate, `"k1"`, 2),
+			wantErr:  true,
+		},
+		{
+			name:     "unknown key ID ignored",
+			rootJSON: fmt.Sprintf(rootTemplate, `"k1", "missing"`, 2),
+			wantErr:  true,
+		},
+		{
+			name:     "not json",
+			rootJSON: "not json",
+			wantErr:  true,
+		},
+		{
+			name:     "no root role",
+			rootJSON: `{"signed": {"_type": "root", "roles": {}}, "signatures": []}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, threshold, err := parseRootKeys([]byte(tt.rootJSON))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRootKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(keys) != tt.wantKeys {
+				t.Errorf("parseRootKeys() returned %d keys, want %d", len(keys), tt.wantKeys)
+			}
+			if threshold != tt.threshold {
+				t.Errorf("parseRootKeys() returned threshold %d, want %d", threshold, tt.threshold)
+			}
+		})
+	}
+}
+
+func TestBufferDestination(t *testing.T) {
+	d := &bufferDestination{}
+	if _, err := d.Write([]byte("target contents")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(d.Bytes()); got != "target contents" {
+		t.Errorf("unexpected contents: %v", got)
+	}
+	if err := d.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 0 {
+		t.Errorf("Delete() did not reset the buffer")
+	}
+}
@@ -33,7 +33,9 @@ type BaseAlpineType struct {
 }
 
 func init() {
-	types.TypeMap.Store(KIND, New)
+	if err := types.RegisterType(KIND, New); err != nil {
+		panic(err)
+	}
 }
 
 func New() types.TypeImpl {
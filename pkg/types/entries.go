@@ -23,9 +23,9 @@ import (
 	"net/url"
 	"reflect"
 
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/go-openapi/strfmt"
 	"github.com/mitchellh/mapstructure"
+	"github.com/sigstore/rekor/pkg/canonical"
 	"github.com/sigstore/rekor/pkg/generated/models"
 )
 
@@ -115,7 +115,7 @@ func CanonicalizeEntry(ctx context.Context, entry EntryImpl) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return jsoncanonicalizer.Transform(canonicalEntry)
+	return canonical.Transform(canonicalEntry)
 }
 
 // ArtifactProperties provide a consistent struct for passing values from
@@ -23,7 +23,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -37,6 +39,7 @@ import (
 	"github.com/sigstore/rekor/pkg/pki/x509"
 	"github.com/sigstore/rekor/pkg/types"
 	hashedrekord "github.com/sigstore/rekor/pkg/types/hashedrekord"
+	"github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
@@ -74,6 +77,7 @@ func (v V001Entry) IndexKeys() ([]string, error) {
 		return nil, err
 	}
 	result = append(result, pub.EmailAddresses()...)
+	result = append(result, pub.OIDCIdentities()...)
 
 	if v.HashedRekordObj.Data.Hash != nil {
 		hashKey := strings.ToLower(fmt.Sprintf("%s:%s", *v.HashedRekordObj.Data.Hash.Algorithm, *v.HashedRekordObj.Data.Hash.Value))
@@ -230,9 +234,41 @@ func (v V001Entry) CreateFromArtifactProperties(ctx context.Context, props types
 	}
 	re.HashedRekordObj.Signature.PublicKey.Content = strfmt.Base64(publicKeyBytes)
 
+	// if a precomputed digest wasn't provided, hash the artifact as it streams
+	// in rather than buffering it in memory, so large artifacts stay cheap
+	artifactHash := props.ArtifactHash
+	if artifactHash == "" {
+		if props.ArtifactBytes != nil {
+			hasher := sha256.New()
+			if _, err := io.Copy(hasher, bytes.NewReader(props.ArtifactBytes)); err != nil {
+				return nil, fmt.Errorf("error hashing artifact: %w", err)
+			}
+			artifactHash = hex.EncodeToString(hasher.Sum(nil))
+		} else if props.ArtifactPath != nil {
+			var artifactReader io.ReadCloser
+			if props.ArtifactPath.IsAbs() {
+				artifactReader, err = util.FileOrURLReadCloser(ctx, props.ArtifactPath.String(), nil)
+				if err != nil {
+					return nil, fmt.Errorf("error reading artifact: %w", err)
+				}
+			} else {
+				artifactReader, err = os.Open(filepath.Clean(props.ArtifactPath.Path))
+				if err != nil {
+					return nil, fmt.Errorf("error opening artifact file: %w", err)
+				}
+			}
+			defer artifactReader.Close()
+			hasher := sha256.New()
+			if _, err := io.Copy(hasher, artifactReader); err != nil {
+				return nil, fmt.Errorf("error hashing artifact: %w", err)
+			}
+			artifactHash = hex.EncodeToString(hasher.Sum(nil))
+		}
+	}
+
 	re.HashedRekordObj.Data.Hash = &models.HashedrekordV001SchemaDataHash{
 		Algorithm: swag.String(models.HashedrekordV001SchemaDataHashAlgorithmSha256),
-		Value:     swag.String(props.ArtifactHash),
+		Value:     swag.String(artifactHash),
 	}
 
 	if _, _, err := re.validate(); err != nil {
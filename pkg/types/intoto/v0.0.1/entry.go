@@ -57,9 +57,10 @@ func init() {
 }
 
 type V001Entry struct {
-	IntotoObj models.IntotoV001Schema
-	keyObj    pki.PublicKey
-	env       dsse.Envelope
+	IntotoObj    models.IntotoV001Schema
+	keyObj       pki.PublicKey
+	extraKeyObjs []*x509.PublicKey
+	env          dsse.Envelope
 }
 
 func (v V001Entry) APIVersion() string {
@@ -77,6 +78,20 @@ func (v V001Entry) IndexKeys() ([]string, error) {
 	payloadKey := "sha256:" + hex.EncodeToString(h[:])
 	result = append(result, payloadKey)
 
+	// if the entry carries a certificate, index who signed it: the subject
+	// identities plus the OIDC issuer that attested to them, so keyless
+	// signers can be searched for directly
+	if v.keyObj != nil {
+		result = append(result, v.keyObj.EmailAddresses()...)
+		if certKey, ok := v.keyObj.(*x509.PublicKey); ok {
+			result = append(result, certKey.OIDCIdentities()...)
+		}
+	}
+	for _, k := range v.extraKeyObjs {
+		result = append(result, k.EmailAddresses()...)
+		result = append(result, k.OIDCIdentities()...)
+	}
+
 	switch v.env.PayloadType {
 	case in_toto.PayloadType:
 		if v.IntotoObj.Content == nil || v.IntotoObj.Content.Hash == nil {
@@ -106,6 +121,14 @@ func (v V001Entry) IndexKeys() ([]string, error) {
 					}
 				}
 			}
+			// index the builder identity and the source the build was
+			// configured from, so provenance can be queried by either
+			if id := predicate.Predicate.Builder.ID; id != "" {
+				result = append(result, strings.ToLower(id))
+			}
+			if uri := predicate.Predicate.Invocation.ConfigSource.URI; uri != "" {
+				result = append(result, strings.ToLower(uri))
+			}
 		}
 	default:
 		log.Logger.Infof("Unknown in_toto Statement Type: %s", v.env.PayloadType)
@@ -158,6 +181,15 @@ func (v *V001Entry) Unmarshal(pe models.ProposedEntry) error {
 		return err
 	}
 
+	v.extraKeyObjs = nil
+	for i, pk := range v.IntotoObj.PublicKeys {
+		keyObj, err := x509.NewPublicKey(bytes.NewReader(pk))
+		if err != nil {
+			return fmt.Errorf("parsing publicKeys[%d]: %w", i, err)
+		}
+		v.extraKeyObjs = append(v.extraKeyObjs, keyObj)
+	}
+
 	return v.validate()
 }
 
@@ -175,6 +207,7 @@ func (v *V001Entry) Canonicalize(ctx context.Context) ([]byte, error) {
 
 	canonicalEntry := models.IntotoV001Schema{
 		PublicKey: &pkb,
+		Threshold: v.IntotoObj.Threshold,
 		Content: &models.IntotoV001SchemaContent{
 			Hash: &models.IntotoV001SchemaContentHash{
 				Algorithm: swag.String(models.IntotoV001SchemaContentHashAlgorithmSha256),
@@ -182,6 +215,13 @@ func (v *V001Entry) Canonicalize(ctx context.Context) ([]byte, error) {
 			},
 		},
 	}
+	for _, k := range v.extraKeyObjs {
+		cpk, err := k.CanonicalValue()
+		if err != nil {
+			return nil, err
+		}
+		canonicalEntry.PublicKeys = append(canonicalEntry.PublicKeys, strfmt.Base64(cpk))
+	}
 	attKey, attValue := v.AttestationKeyValue()
 	if attValue != nil {
 		canonicalEntry.Content.PayloadHash = &models.IntotoV001SchemaContentPayloadHash{
@@ -199,29 +239,40 @@ func (v *V001Entry) Canonicalize(ctx context.Context) ([]byte, error) {
 
 // validate performs cross-field validation for fields in object
 func (v *V001Entry) validate() error {
-	// TODO handle multiple
 	pk := v.keyObj.(*x509.PublicKey)
+	keys := append([]*x509.PublicKey{pk}, v.extraKeyObjs...)
+
+	threshold := int(v.IntotoObj.Threshold)
+	if threshold == 0 {
+		threshold = 1
+	}
+	if threshold > len(keys) {
+		return fmt.Errorf("threshold %d exceeds the %d key(s) supplied", threshold, len(keys))
+	}
 
 	// This also gets called in the CLI, where we won't have this data
 	if v.IntotoObj.Content.Envelope == "" {
 		return nil
 	}
-	vfr, err := signature.LoadVerifier(pk.CryptoPubKey(), crypto.SHA256)
-	if err != nil {
-		return err
+
+	providers := make([]dsse.SignVerifier, 0, len(keys))
+	for _, k := range keys {
+		vfr, err := signature.LoadVerifier(k.CryptoPubKey(), crypto.SHA256)
+		if err != nil {
+			return err
+		}
+		providers = append(providers, &verifier{
+			v:   vfr,
+			pub: k.CryptoPubKey(),
+		})
 	}
-	dsseVerifier, err := dsse.NewEnvelopeSigner(&verifier{
-		v:   vfr,
-		pub: pk,
-	})
+	// threshold semantics match in-toto layouts: at least `threshold` distinct
+	// keys from the supplied set must have produced a verified signature
+	dsseVerifier, err := dsse.NewMultiEnvelopeSigner(threshold, providers...)
 	if err != nil {
 		return err
 	}
 
-	if v.IntotoObj.Content.Envelope == "" {
-		return nil
-	}
-
 	if err := json.Unmarshal([]byte(v.IntotoObj.Content.Envelope), &v.env); err != nil {
 		return err
 	}
@@ -245,6 +245,105 @@ func TestV001Entry_Unmarshal(t *testing.T) {
 	}
 }
 
+func multiEnvelope(t *testing.T, payload string, keys ...*ecdsa.PrivateKey) string {
+	providers := make([]dsse.SignVerifier, 0, len(keys))
+	for _, k := range keys {
+		s, err := signature.LoadECDSASigner(k, crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		providers = append(providers, &verifier{
+			s:   s,
+			pub: k.Public(),
+		})
+	}
+	signer, err := dsse.NewEnvelopeSigner(providers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsseEnv, err := signer.SignPayload(in_toto.PayloadType, []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(dsseEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestV001Entry_Threshold(t *testing.T) {
+	pems := make([][]byte, 2)
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		pems[i] = pem.EncodeToMemory(&pem.Block{
+			Bytes: der,
+			Type:  "PUBLIC KEY",
+		})
+	}
+
+	tests := []struct {
+		name      string
+		envelope  string
+		threshold int64
+		wantErr   bool
+	}{
+		{
+			name:      "2-of-2 with both signatures",
+			envelope:  multiEnvelope(t, "payload", keys...),
+			threshold: 2,
+		},
+		{
+			name:      "2-of-2 with a single signature",
+			envelope:  multiEnvelope(t, "payload", keys[0]),
+			threshold: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "threshold exceeds supplied keys",
+			envelope:  multiEnvelope(t, "payload", keys...),
+			threshold: 3,
+			wantErr:   true,
+		},
+		{
+			name:     "1-of-2 signed only by the additional key",
+			envelope: multiEnvelope(t, "payload", keys[1]),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := sha256.Sum256([]byte(tt.envelope))
+			it := &models.Intoto{
+				Spec: &models.IntotoV001Schema{
+					PublicKey:  p(pems[0]),
+					PublicKeys: []strfmt.Base64{strfmt.Base64(pems[1])},
+					Threshold:  tt.threshold,
+					Content: &models.IntotoV001SchemaContent{
+						Envelope: tt.envelope,
+						Hash: &models.IntotoV001SchemaContentHash{
+							Algorithm: swag.String(models.IntotoV001SchemaContentHashAlgorithmSha256),
+							Value:     swag.String(hex.EncodeToString(h[:])),
+						},
+					},
+				},
+			}
+			v := &V001Entry{}
+			if err := v.Unmarshal(it); (err != nil) != tt.wantErr {
+				t.Errorf("V001Entry.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestV001Entry_IndexKeys(t *testing.T) {
 	h := sha256.Sum256([]byte("foo"))
 	dataSHA := hex.EncodeToString(h[:])
@@ -294,6 +393,22 @@ func TestV001Entry_IndexKeys(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "slsa builder and config source",
+			want: []string{"https://tekton.dev/chains/v2", "git+https://github.com/foo/bar", hashkey},
+			statement: in_toto.Statement{
+				Predicate: slsa.ProvenancePredicate{
+					Builder: slsa.ProvenanceBuilder{
+						ID: "https://tekton.dev/chains/v2",
+					},
+					Invocation: slsa.ProvenanceInvocation{
+						ConfigSource: slsa.ConfigSource{
+							URI: "git+https://github.com/foo/bar",
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,142 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-openapi/strfmt"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// ProposedEntry is the generic proposed entry document for plugin-backed
+// kinds; the spec is opaque to the server and interpreted by the plugin.
+type ProposedEntry struct {
+	EntryKind  string          `json:"kind"`
+	APIVersion string          `json:"apiVersion"`
+	Spec       json.RawMessage `json:"spec"`
+}
+
+// Kind gets the kind of this polymorphic type
+func (p *ProposedEntry) Kind() string {
+	return p.EntryKind
+}
+
+// SetKind sets the kind of this polymorphic type
+func (p *ProposedEntry) SetKind(kind string) {
+	p.EntryKind = kind
+}
+
+// EntryAPIVersion reports the API version of the proposed entry
+func (p *ProposedEntry) EntryAPIVersion() string {
+	return p.APIVersion
+}
+
+// Validate validates this proposed entry
+func (p *ProposedEntry) Validate(_ strfmt.Registry) error {
+	if p.EntryKind == "" {
+		return errors.New("kind in body is required")
+	}
+	if p.APIVersion == "" {
+		return errors.New("apiVersion in body is required")
+	}
+	if len(p.Spec) == 0 {
+		return errors.New("spec in body is required")
+	}
+	return nil
+}
+
+// ContextValidate validates this proposed entry based on context it is used
+func (p *ProposedEntry) ContextValidate(_ context.Context, _ strfmt.Registry) error {
+	return nil
+}
+
+// entry delegates the versioned entry behavior to the plugin binary
+type entry struct {
+	plugin     *Plugin
+	apiVersion string
+	raw        json.RawMessage
+}
+
+func (e *entry) APIVersion() string {
+	return e.apiVersion
+}
+
+func (e *entry) Unmarshal(pe models.ProposedEntry) error {
+	if pe == nil {
+		return errors.New("proposed entry cannot be nil")
+	}
+	if pe.Kind() != e.plugin.kind {
+		return fmt.Errorf("cannot unmarshal %v entries as kind '%v'", pe.Kind(), e.plugin.kind)
+	}
+	raw, err := json.Marshal(pe)
+	if err != nil {
+		return fmt.Errorf("marshalling proposed %v entry: %w", e.plugin.kind, err)
+	}
+	if vpe, ok := pe.(types.VersionedProposedEntry); ok {
+		e.apiVersion = vpe.EntryAPIVersion()
+	}
+	// the plugin performs validation and signature verification here
+	if _, err := e.plugin.run(context.Background(), "unmarshal", &request{Entry: raw}); err != nil {
+		return err
+	}
+	e.raw = raw
+	return nil
+}
+
+func (e *entry) Canonicalize(ctx context.Context) ([]byte, error) {
+	if e.raw == nil {
+		return nil, errors.New("entry has not been unmarshalled")
+	}
+	resp, err := e.plugin.run(ctx, "canonicalize", &request{Entry: e.raw})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Canonical) == 0 {
+		return nil, fmt.Errorf("plugin for kind '%v' returned no canonical form", e.plugin.kind)
+	}
+	// normalize whatever the plugin emitted so leaf bytes stay RFC 8785
+	return canonical.Transform(resp.Canonical)
+}
+
+func (e *entry) IndexKeys() ([]string, error) {
+	if e.raw == nil {
+		return nil, errors.New("entry has not been unmarshalled")
+	}
+	resp, err := e.plugin.run(context.Background(), "indexKeys", &request{Entry: e.raw})
+	if err != nil {
+		return nil, err
+	}
+	return resp.IndexKeys, nil
+}
+
+func (e *entry) AttestationKey() string {
+	return ""
+}
+
+func (e *entry) AttestationKeyValue() (string, []byte) {
+	return "", nil
+}
+
+func (e *entry) CreateFromArtifactProperties(_ context.Context, _ types.ArtifactProperties) (models.ProposedEntry, error) {
+	return nil, fmt.Errorf("creating proposed entries is not supported for plugin-backed kind '%v'; submit a fully-formed entry", e.plugin.kind)
+}
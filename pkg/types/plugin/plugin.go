@@ -0,0 +1,167 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin delegates entry types to out-of-process binaries, so new
+// kinds can be deployed without rebuilding rekor-server.
+//
+// A plugin is any executable implementing a small exec-based protocol: the
+// binary is invoked with the command as its only argument, reads a JSON
+// request from stdin, and writes a JSON response to stdout. The commands are:
+//
+//	describe     report the kind, default version, and supported semver
+//	             version ranges the plugin implements
+//	unmarshal    validate and verify a proposed entry document
+//	canonicalize return the canonical form of a proposed entry document
+//	indexKeys    return the search index keys for a proposed entry document
+//
+// A non-zero exit code or a non-empty "error" field in the response marks the
+// invocation as failed. Canonical output is additionally passed through the
+// RFC 8785 transform on the server side, so plugins only need to emit valid
+// JSON. Plugins registered at startup participate in the types registry
+// exactly like compiled-in kinds.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// invokeTimeout bounds a single plugin invocation
+const invokeTimeout = 30 * time.Second
+
+// request is the JSON document written to the plugin's stdin
+type request struct {
+	// Entry holds the proposed entry document for entry-scoped commands
+	Entry json.RawMessage `json:"entry,omitempty"`
+}
+
+// response is the JSON document read from the plugin's stdout
+type response struct {
+	Error          string          `json:"error,omitempty"`
+	Kind           string          `json:"kind,omitempty"`
+	DefaultVersion string          `json:"defaultVersion,omitempty"`
+	Versions       []string        `json:"versions,omitempty"`
+	Canonical      json.RawMessage `json:"canonical,omitempty"`
+	IndexKeys      []string        `json:"indexKeys,omitempty"`
+}
+
+// Plugin wraps a single plugin binary and the type description it reported
+type Plugin struct {
+	path           string
+	kind           string
+	defaultVersion string
+	versions       []string
+
+	// run is swapped out in tests to avoid spawning processes
+	run func(ctx context.Context, command string, req *request) (*response, error)
+}
+
+// New probes the binary at path with the describe command and returns a
+// Plugin ready to be registered.
+func New(ctx context.Context, path string) (*Plugin, error) {
+	p := &Plugin{path: path}
+	p.run = p.invoke
+
+	resp, err := p.run(ctx, "describe", &request{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Kind == "" {
+		return nil, fmt.Errorf("plugin %v did not report a kind", filepath.Base(path))
+	}
+	if resp.DefaultVersion == "" || len(resp.Versions) == 0 {
+		return nil, fmt.Errorf("plugin %v did not report its supported versions", filepath.Base(path))
+	}
+	p.kind = resp.Kind
+	p.defaultVersion = resp.DefaultVersion
+	p.versions = resp.Versions
+	return p, nil
+}
+
+// Kind returns the kind string the plugin implements
+func (p *Plugin) Kind() string {
+	return p.kind
+}
+
+// Register adds the plugin's kind to the types registry; every entry
+// operation for the kind is delegated to the plugin binary from then on.
+func (p *Plugin) Register() error {
+	versions := make(map[string]types.EntryFactory, len(p.versions))
+	for _, constraint := range p.versions {
+		versions[constraint] = p.newEntry
+	}
+	return types.RegisterVersionedType(p.kind, p.defaultVersion, versions)
+}
+
+func (p *Plugin) newEntry() types.EntryImpl {
+	return &entry{plugin: p, apiVersion: p.defaultVersion}
+}
+
+// invoke runs the plugin binary once for the given command
+func (p *Plugin) invoke(ctx context.Context, command string, req *request) (*response, error) {
+	ctx, cancel := context.WithTimeout(ctx, invokeTimeout)
+	defer cancel()
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %v request: %w", command, err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.path, command)
+	cmd.Stdin = bytes.NewReader(reqBytes)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %v %v: %w: %v", filepath.Base(p.path), command, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %v %v: %w", filepath.Base(p.path), command, err)
+	}
+
+	resp := response{}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("plugin %v returned malformed %v response: %w", filepath.Base(p.path), command, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %v %v: %v", filepath.Base(p.path), command, resp.Error)
+	}
+	return &resp, nil
+}
+
+// RegisterPlugins probes and registers each configured plugin binary; it is
+// called once at server startup.
+func RegisterPlugins(ctx context.Context, paths []string) error {
+	for _, path := range paths {
+		p, err := New(ctx, path)
+		if err != nil {
+			return fmt.Errorf("loading type plugin %v: %w", path, err)
+		}
+		if err := p.Register(); err != nil {
+			return fmt.Errorf("registering type plugin %v: %w", path, err)
+		}
+		log.Logger.Infof("registered type plugin %v for kind '%v'", path, p.kind)
+	}
+	return nil
+}
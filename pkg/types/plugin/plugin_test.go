@@ -0,0 +1,156 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// testPluginScript implements the plugin protocol as a shell script; the
+// unmarshal command rejects any entry whose document contains "invalid".
+const testPluginScript = `#!/bin/sh
+case "$1" in
+describe)
+	echo '{"kind":"test-widget","defaultVersion":"0.0.1","versions":[">=0.0.1"]}'
+	;;
+unmarshal)
+	if grep -q invalid; then
+		echo '{"error":"signature verification failed"}'
+	else
+		echo '{}'
+	fi
+	;;
+canonicalize)
+	echo '{"canonical":{"b":2,"a":1}}'
+	;;
+indexKeys)
+	echo '{"indexKeys":["sha256:abc"]}'
+	;;
+*)
+	echo '{"error":"unknown command"}'
+	;;
+esac
+`
+
+func writeTestPlugin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	p, err := New(ctx, writeTestPlugin(t, testPluginScript))
+	if err != nil {
+		t.Fatalf("unexpected error probing plugin: %v", err)
+	}
+	if p.Kind() != "test-widget" {
+		t.Fatalf("unexpected kind: %v", p.Kind())
+	}
+	if err := p.Register(); err != nil {
+		t.Fatalf("unexpected error registering plugin: %v", err)
+	}
+
+	pe := &ProposedEntry{
+		EntryKind:  "test-widget",
+		APIVersion: "0.0.1",
+		Spec:       json.RawMessage(`{"payload":"aGVsbG8="}`),
+	}
+	entry, err := types.NewEntry(pe)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshalling plugin entry: %v", err)
+	}
+	if entry.APIVersion() != "0.0.1" {
+		t.Errorf("unexpected API version: %v", entry.APIVersion())
+	}
+
+	canonicalized, err := entry.Canonicalize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error canonicalizing plugin entry: %v", err)
+	}
+	// the server applies the RFC 8785 transform to whatever the plugin emits
+	if string(canonicalized) != `{"a":1,"b":2}` {
+		t.Errorf("unexpected canonical form: %s", canonicalized)
+	}
+
+	keys, err := entry.IndexKeys()
+	if err != nil {
+		t.Fatalf("unexpected error fetching index keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "sha256:abc" {
+		t.Errorf("unexpected index keys: %v", keys)
+	}
+
+	if _, err := entry.CreateFromArtifactProperties(ctx, types.ArtifactProperties{}); err == nil {
+		t.Error("expected error creating proposed entry for plugin-backed kind")
+	}
+
+	rejected := &ProposedEntry{
+		EntryKind:  "test-widget",
+		APIVersion: "0.0.1",
+		Spec:       json.RawMessage(`{"payload":"invalid"}`),
+	}
+	if _, err := types.NewEntry(rejected); err == nil {
+		t.Error("expected error unmarshalling entry the plugin rejects")
+	}
+}
+
+func TestPluginDescribeValidation(t *testing.T) {
+	ctx := context.Background()
+	if _, err := New(ctx, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error probing missing binary")
+	}
+	if _, err := New(ctx, writeTestPlugin(t, "#!/bin/sh\necho '{\"defaultVersion\":\"0.0.1\",\"versions\":[\">=0.0.1\"]}'\n")); err == nil {
+		t.Error("expected error for describe response without kind")
+	}
+	if _, err := New(ctx, writeTestPlugin(t, "#!/bin/sh\necho '{\"kind\":\"test-widget2\"}'\n")); err == nil {
+		t.Error("expected error for describe response without versions")
+	}
+	if _, err := New(ctx, writeTestPlugin(t, "#!/bin/sh\necho 'not json'\n")); err == nil {
+		t.Error("expected error for malformed describe response")
+	}
+	if _, err := New(ctx, writeTestPlugin(t, "#!/bin/sh\necho '{\"error\":\"boom\"}'\n")); err == nil {
+		t.Error("expected error for describe response reporting an error")
+	}
+}
+
+func TestProposedEntryValidate(t *testing.T) {
+	pe := &ProposedEntry{}
+	if err := pe.Validate(nil); err == nil {
+		t.Error("expected error validating empty proposed entry")
+	}
+	pe.EntryKind = "test-widget"
+	if err := pe.Validate(nil); err == nil {
+		t.Error("expected error validating proposed entry without apiVersion")
+	}
+	pe.APIVersion = "0.0.1"
+	if err := pe.Validate(nil); err == nil {
+		t.Error("expected error validating proposed entry without spec")
+	}
+	pe.Spec = json.RawMessage(`{}`)
+	if err := pe.Validate(nil); err != nil {
+		t.Errorf("unexpected error validating proposed entry: %v", err)
+	}
+}
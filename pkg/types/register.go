@@ -0,0 +1,124 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// RegisterType makes a type implementation available under the given kind
+// string. It is intended to be called from the init function of packages
+// implementing entry types, including packages compiled in from outside this
+// repository; once registered, a kind participates in entry creation and
+// unmarshalling everywhere the registry is consulted, without changes to
+// pkg/api. An error is returned if another type is already registered under
+// kind.
+func RegisterType(kind string, tf func() TypeImpl) error {
+	if kind == "" {
+		return errors.New("kind cannot be empty")
+	}
+	if tf == nil {
+		return fmt.Errorf("nil type factory for kind '%v'", kind)
+	}
+	if _, loaded := TypeMap.LoadOrStore(kind, tf); loaded {
+		return fmt.Errorf("type '%v' is already registered", kind)
+	}
+	return nil
+}
+
+// VersionedProposedEntry is implemented by proposed entry models that report
+// which API version of their kind they describe. Models for kinds registered
+// through RegisterVersionedType must implement this interface so the registry
+// can route proposed entries to the correct versioned implementation.
+type VersionedProposedEntry interface {
+	models.ProposedEntry
+	EntryAPIVersion() string
+}
+
+// RegisterVersionedType registers a kind along with factories for each of its
+// supported versions, without requiring the caller to provide a TypeImpl of
+// their own. versions maps semver-compliant range strings to the factory for
+// that range; defaultVersion selects the implementation used when a version
+// is not explicitly requested and must match one of the provided ranges.
+// Proposed entries for kinds registered this way must implement
+// VersionedProposedEntry.
+func RegisterVersionedType(kind, defaultVersion string, versions map[string]EntryFactory) error {
+	if defaultVersion == "" {
+		return fmt.Errorf("default version for kind '%v' cannot be empty", kind)
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions provided for kind '%v'", kind)
+	}
+	vm := NewSemVerEntryFactoryMap()
+	for constraint, ef := range versions {
+		if ef == nil {
+			return fmt.Errorf("nil entry factory for kind '%v' version range '%v'", kind, constraint)
+		}
+		if err := vm.SetEntryFactory(constraint, ef); err != nil {
+			return fmt.Errorf("invalid version range '%v' for kind '%v': %w", constraint, kind, err)
+		}
+	}
+	if _, err := vm.GetEntryFactory(defaultVersion); err != nil {
+		return fmt.Errorf("default version '%v' for kind '%v' does not match any registered range: %w", defaultVersion, kind, err)
+	}
+	return RegisterType(kind, func() TypeImpl {
+		gt := genericType{defaultVersion: defaultVersion}
+		gt.Kind = kind
+		gt.VersionMap = vm
+		return &gt
+	})
+}
+
+// genericType adapts a version map registered through RegisterVersionedType
+// into a TypeImpl, standing in for the per-kind base type the built-in
+// implementations declare by hand.
+type genericType struct {
+	RekorType
+	defaultVersion string
+}
+
+func (g *genericType) UnmarshalEntry(pe models.ProposedEntry) (EntryImpl, error) {
+	if pe == nil {
+		return nil, errors.New("proposed entry cannot be nil")
+	}
+	if pe.Kind() != g.Kind {
+		return nil, fmt.Errorf("cannot unmarshal %v entries as kind '%v'", pe.Kind(), g.Kind)
+	}
+	vpe, ok := pe.(VersionedProposedEntry)
+	if !ok {
+		return nil, fmt.Errorf("proposed %v entry does not report its API version", g.Kind)
+	}
+	return g.VersionedUnmarshal(pe, vpe.EntryAPIVersion())
+}
+
+func (g *genericType) CreateProposedEntry(ctx context.Context, version string, props ArtifactProperties) (models.ProposedEntry, error) {
+	if version == "" {
+		version = g.defaultVersion
+	}
+	ei, err := g.VersionedUnmarshal(nil, version)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %v version implementation: %w", g.Kind, err)
+	}
+	return ei.CreateFromArtifactProperties(ctx, props)
+}
+
+func (g genericType) DefaultVersion() string {
+	return g.defaultVersion
+}
@@ -0,0 +1,137 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+)
+
+// VersionedTestEntry is a proposed entry for an externally registered kind;
+// it reports its API version through the VersionedProposedEntry interface.
+type VersionedTestEntry struct {
+	kind    string
+	version string
+}
+
+func (e VersionedTestEntry) Kind() string {
+	return e.kind
+}
+
+func (e VersionedTestEntry) SetKind(string) {}
+
+func (e VersionedTestEntry) Validate(_ strfmt.Registry) error {
+	return nil
+}
+
+func (e VersionedTestEntry) ContextValidate(_ context.Context, _ strfmt.Registry) error {
+	return nil
+}
+
+func (e VersionedTestEntry) EntryAPIVersion() string {
+	return e.version
+}
+
+// UnversionedTestEntry carries a registered kind but does not implement
+// VersionedProposedEntry.
+type UnversionedTestEntry struct {
+	kind string
+}
+
+func (e UnversionedTestEntry) Kind() string {
+	return e.kind
+}
+
+func (e UnversionedTestEntry) SetKind(string) {}
+
+func (e UnversionedTestEntry) Validate(_ strfmt.Registry) error {
+	return nil
+}
+
+func (e UnversionedTestEntry) ContextValidate(_ context.Context, _ strfmt.Registry) error {
+	return nil
+}
+
+func TestRegisterType(t *testing.T) {
+	tf := func() TypeImpl { return nil }
+
+	if err := RegisterType("", tf); err == nil {
+		t.Error("expected error registering empty kind")
+	}
+	if err := RegisterType("register-test", nil); err == nil {
+		t.Error("expected error registering nil factory")
+	}
+	if err := RegisterType("register-test", tf); err != nil {
+		t.Errorf("unexpected error registering type: %v", err)
+	}
+	if err := RegisterType("register-test", tf); err == nil {
+		t.Error("expected error registering duplicate kind")
+	}
+}
+
+func TestRegisterVersionedType(t *testing.T) {
+	factory := BaseUnmarshalTester{}.NewEntry
+
+	if err := RegisterVersionedType("versioned-test", "", map[string]EntryFactory{">=0.0.1": factory}); err == nil {
+		t.Error("expected error registering empty default version")
+	}
+	if err := RegisterVersionedType("versioned-test", "0.0.1", nil); err == nil {
+		t.Error("expected error registering with no versions")
+	}
+	if err := RegisterVersionedType("versioned-test", "0.0.1", map[string]EntryFactory{">=0.0.1": nil}); err == nil {
+		t.Error("expected error registering nil entry factory")
+	}
+	if err := RegisterVersionedType("versioned-test", "0.0.1", map[string]EntryFactory{"not-semver": factory}); err == nil {
+		t.Error("expected error registering invalid version range")
+	}
+	if err := RegisterVersionedType("versioned-test", "2.0.0", map[string]EntryFactory{"<1.0.0": factory}); err == nil {
+		t.Error("expected error registering default version outside registered ranges")
+	}
+
+	if err := RegisterVersionedType("versioned-test", "0.0.1", map[string]EntryFactory{">=0.0.1": factory}); err != nil {
+		t.Fatalf("unexpected error registering versioned type: %v", err)
+	}
+
+	tf, found := TypeMap.Load("versioned-test")
+	if !found {
+		t.Fatal("versioned type not present in registry")
+	}
+	ti := tf.(func() TypeImpl)()
+	if ti.DefaultVersion() != "0.0.1" {
+		t.Errorf("unexpected default version: %v", ti.DefaultVersion())
+	}
+	if versions := ti.SupportedVersions(); len(versions) != 1 || versions[0] != ">=0.0.1" {
+		t.Errorf("unexpected supported versions: %v", versions)
+	}
+
+	if _, err := NewEntry(VersionedTestEntry{kind: "versioned-test", version: "0.0.1"}); err != nil {
+		t.Errorf("unexpected error unmarshalling registered entry: %v", err)
+	}
+	if _, err := NewEntry(VersionedTestEntry{kind: "versioned-test", version: "not-semver"}); err == nil {
+		t.Error("expected error unmarshalling entry with unparseable version")
+	}
+	if _, err := ti.UnmarshalEntry(nil); err == nil {
+		t.Error("expected error unmarshalling nil entry")
+	}
+	if _, err := ti.UnmarshalEntry(InvalidEntry{}); err == nil {
+		t.Error("expected error unmarshalling entry of a different kind")
+	}
+	if _, err := ti.UnmarshalEntry(UnversionedTestEntry{kind: "versioned-test"}); err == nil {
+		t.Error("expected error unmarshalling entry without a reported API version")
+	}
+}
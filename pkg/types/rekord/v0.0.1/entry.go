@@ -37,6 +37,7 @@ import (
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/log"
 	"github.com/sigstore/rekor/pkg/pki"
+	x509pki "github.com/sigstore/rekor/pkg/pki/x509"
 	"github.com/sigstore/rekor/pkg/types"
 	"github.com/sigstore/rekor/pkg/types/rekord"
 	"github.com/sigstore/rekor/pkg/util"
@@ -85,6 +86,9 @@ func (v V001Entry) IndexKeys() ([]string, error) {
 	}
 
 	result = append(result, keyObj.EmailAddresses()...)
+	if x509Key, ok := keyObj.(*x509pki.PublicKey); ok {
+		result = append(result, x509Key.OIDCIdentities()...)
+	}
 
 	if v.RekordObj.Data.Hash != nil {
 		hashKey := strings.ToLower(fmt.Sprintf("%s:%s", *v.RekordObj.Data.Hash.Algorithm, *v.RekordObj.Data.Hash.Value))
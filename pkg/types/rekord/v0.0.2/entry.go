@@ -0,0 +1,351 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rekord
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/pki"
+	x509pki "github.com/sigstore/rekor/pkg/pki/x509"
+	"github.com/sigstore/rekor/pkg/types"
+	"github.com/sigstore/rekor/pkg/types/rekord"
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+const (
+	APIVERSION = "0.0.2"
+)
+
+func init() {
+	if err := rekord.VersionMap.SetEntryFactory(APIVERSION, NewEntry); err != nil {
+		log.Logger.Panic(err)
+	}
+}
+
+// V002Entry carries one or more detached signatures over the same artifact in
+// a single leaf, so multi-party sign-offs (e.g. two release managers) do not
+// require one entry per signature.
+type V002Entry struct {
+	RekordObj models.RekordV002Schema
+}
+
+func (v V002Entry) APIVersion() string {
+	return APIVERSION
+}
+
+func NewEntry() types.EntryImpl {
+	return &V002Entry{}
+}
+
+func (v V002Entry) IndexKeys() ([]string, error) {
+	var result []string
+
+	// every signer is indexed so any one of them resolves to this entry
+	for _, sig := range v.RekordObj.Signatures {
+		af, err := pki.NewArtifactFactory(pki.Format(*sig.Format))
+		if err != nil {
+			return nil, err
+		}
+		keyObj, err := af.NewPublicKey(bytes.NewReader(*sig.PublicKey.Content))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := keyObj.CanonicalValue()
+		if err != nil {
+			log.Logger.Error(err)
+		} else {
+			keyHash := sha256.Sum256(key)
+			result = append(result, strings.ToLower(hex.EncodeToString(keyHash[:])))
+		}
+
+		result = append(result, keyObj.EmailAddresses()...)
+		if x509Key, ok := keyObj.(*x509pki.PublicKey); ok {
+			result = append(result, x509Key.OIDCIdentities()...)
+		}
+	}
+
+	if v.RekordObj.Data.Hash != nil {
+		hashKey := strings.ToLower(fmt.Sprintf("%s:%s", *v.RekordObj.Data.Hash.Algorithm, *v.RekordObj.Data.Hash.Value))
+		result = append(result, hashKey)
+	}
+
+	return result, nil
+}
+
+func (v *V002Entry) Unmarshal(pe models.ProposedEntry) error {
+	rekord, ok := pe.(*models.Rekord)
+	if !ok {
+		return errors.New("cannot unmarshal non Rekord v0.0.2 type")
+	}
+
+	if err := types.DecodeEntry(rekord.Spec, &v.RekordObj); err != nil {
+		return err
+	}
+
+	// field validation
+	if err := v.RekordObj.Validate(strfmt.Default); err != nil {
+		return err
+	}
+
+	// cross field validation
+	return v.validate()
+}
+
+// verifySignatures checks every signature against the artifact content and
+// returns the verified key and signature objects in input order.
+func (v *V002Entry) verifySignatures(_ context.Context) ([]pki.PublicKey, []pki.Signature, error) {
+	content := v.RekordObj.Data.Content
+
+	oldSHA := ""
+	if v.RekordObj.Data.Hash != nil && v.RekordObj.Data.Hash.Value != nil {
+		oldSHA = swag.StringValue(v.RekordObj.Data.Hash.Value)
+	}
+
+	hash := sha256.Sum256(content)
+	computedSHA := hex.EncodeToString(hash[:])
+	if oldSHA != "" && computedSHA != oldSHA {
+		return nil, nil, types.ValidationError(fmt.Errorf("SHA mismatch: %s != %s", computedSHA, oldSHA))
+	}
+
+	keys := make([]pki.PublicKey, len(v.RekordObj.Signatures))
+	sigs := make([]pki.Signature, len(v.RekordObj.Signatures))
+	for i, sig := range v.RekordObj.Signatures {
+		af, err := pki.NewArtifactFactory(pki.Format(*sig.Format))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sigObj, err := af.NewSignature(bytes.NewReader(*sig.Content))
+		if err != nil {
+			return nil, nil, types.ValidationError(err)
+		}
+		keyObj, err := af.NewPublicKey(bytes.NewReader(*sig.PublicKey.Content))
+		if err != nil {
+			return nil, nil, types.ValidationError(err)
+		}
+
+		if err := sigObj.Verify(bytes.NewReader(content), keyObj); err != nil {
+			return nil, nil, types.ValidationError(fmt.Errorf("verifying signature %d: %w", i, err))
+		}
+
+		keys[i] = keyObj
+		sigs[i] = sigObj
+	}
+
+	if oldSHA == "" {
+		v.RekordObj.Data.Hash = &models.RekordV002SchemaDataHash{}
+		v.RekordObj.Data.Hash.Algorithm = swag.String(models.RekordV002SchemaDataHashAlgorithmSha256)
+		v.RekordObj.Data.Hash.Value = swag.String(computedSHA)
+	}
+
+	return keys, sigs, nil
+}
+
+func (v *V002Entry) Canonicalize(ctx context.Context) ([]byte, error) {
+	keys, sigs, err := v.verifySignatures(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	canonicalEntry := models.RekordV002Schema{}
+
+	// need to canonicalize each signature & key content; input order is kept
+	canonicalEntry.Signatures = make([]*models.RekordV002SchemaSignaturesItems0, len(sigs))
+	for i := range sigs {
+		canonicalSig := &models.RekordV002SchemaSignaturesItems0{}
+		canonicalSig.Format = v.RekordObj.Signatures[i].Format
+
+		sigContent, err := sigs[i].CanonicalValue()
+		if err != nil {
+			return nil, err
+		}
+		canonicalSig.Content = (*strfmt.Base64)(&sigContent)
+
+		pubKeyContent, err := keys[i].CanonicalValue()
+		if err != nil {
+			return nil, err
+		}
+		canonicalSig.PublicKey = &models.RekordV002SchemaSignaturesItems0PublicKey{}
+		canonicalSig.PublicKey.Content = (*strfmt.Base64)(&pubKeyContent)
+
+		canonicalEntry.Signatures[i] = canonicalSig
+	}
+
+	canonicalEntry.Data = &models.RekordV002SchemaData{}
+	canonicalEntry.Data.Hash = v.RekordObj.Data.Hash
+	// data content is not set deliberately
+
+	// wrap in valid object with kind and apiVersion set
+	rekordObj := models.Rekord{}
+	rekordObj.APIVersion = swag.String(APIVERSION)
+	rekordObj.Spec = &canonicalEntry
+
+	v.RekordObj = canonicalEntry
+
+	bytes, err := json.Marshal(&rekordObj)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes, nil
+}
+
+// validate performs cross-field validation for fields in object
+func (v V002Entry) validate() error {
+	if len(v.RekordObj.Signatures) == 0 {
+		return errors.New("at least one signature must be specified")
+	}
+	for i, sig := range v.RekordObj.Signatures {
+		if sig == nil {
+			return fmt.Errorf("missing signature at index %d", i)
+		}
+		if sig.Content == nil || len(*sig.Content) == 0 {
+			return fmt.Errorf("'content' must be specified for signature at index %d", i)
+		}
+
+		key := sig.PublicKey
+		if key == nil {
+			return fmt.Errorf("missing public key for signature at index %d", i)
+		}
+		if key.Content == nil || len(*key.Content) == 0 {
+			return fmt.Errorf("'content' must be specified for publicKey at index %d", i)
+		}
+	}
+
+	data := v.RekordObj.Data
+	if data == nil {
+		return errors.New("missing data")
+	}
+
+	hash := data.Hash
+	if hash != nil {
+		if !govalidator.IsHash(swag.StringValue(hash.Value), swag.StringValue(hash.Algorithm)) {
+			return errors.New("invalid value for hash")
+		}
+	} else if len(data.Content) == 0 {
+		return errors.New("'content' must be specified for data")
+	}
+
+	return nil
+}
+
+func (v V002Entry) AttestationKey() string {
+	return ""
+}
+
+func (v V002Entry) AttestationKeyValue() (string, []byte) {
+	return "", nil
+}
+
+func (v V002Entry) CreateFromArtifactProperties(ctx context.Context, props types.ArtifactProperties) (models.ProposedEntry, error) {
+	returnVal := models.Rekord{}
+	re := V002Entry{}
+
+	// we will need artifact, public-key, signature; the CLI supplies a single
+	// signature, additional ones are submitted via the API directly
+	re.RekordObj.Data = &models.RekordV002SchemaData{}
+
+	var err error
+	artifactBytes := props.ArtifactBytes
+	if artifactBytes == nil {
+		var artifactReader io.ReadCloser
+		if props.ArtifactPath.IsAbs() {
+			artifactReader, err = util.FileOrURLReadCloser(ctx, props.ArtifactPath.String(), nil)
+			if err != nil {
+				return nil, fmt.Errorf("error reading artifact file: %w", err)
+			}
+		} else {
+			artifactReader, err = os.Open(filepath.Clean(props.ArtifactPath.Path))
+			if err != nil {
+				return nil, fmt.Errorf("error opening artifact file: %w", err)
+			}
+		}
+		artifactBytes, err = ioutil.ReadAll(artifactReader)
+		if err != nil {
+			return nil, fmt.Errorf("error reading artifact file: %w", err)
+		}
+	}
+	re.RekordObj.Data.Content = strfmt.Base64(artifactBytes)
+
+	sig := &models.RekordV002SchemaSignaturesItems0{}
+	switch props.PKIFormat {
+	case "pgp":
+		sig.Format = swag.String(models.RekordV002SchemaSignaturesItems0FormatPgp)
+	case "minisign":
+		sig.Format = swag.String(models.RekordV002SchemaSignaturesItems0FormatMinisign)
+	case "x509":
+		sig.Format = swag.String(models.RekordV002SchemaSignaturesItems0FormatX509)
+	case "ssh":
+		sig.Format = swag.String(models.RekordV002SchemaSignaturesItems0FormatSSH)
+	}
+	sigBytes := props.SignatureBytes
+	if sigBytes == nil {
+		if props.SignaturePath == nil {
+			return nil, errors.New("a detached signature must be provided")
+		}
+		sigBytes, err = ioutil.ReadFile(filepath.Clean(props.SignaturePath.Path))
+		if err != nil {
+			return nil, fmt.Errorf("error reading signature file: %w", err)
+		}
+	}
+	sig.Content = (*strfmt.Base64)(&sigBytes)
+
+	sig.PublicKey = &models.RekordV002SchemaSignaturesItems0PublicKey{}
+	publicKeyBytes := props.PublicKeyBytes
+	if publicKeyBytes == nil {
+		if props.PublicKeyPath == nil {
+			return nil, errors.New("public key must be provided to verify detached signature")
+		}
+		publicKeyBytes, err = ioutil.ReadFile(filepath.Clean(props.PublicKeyPath.Path))
+		if err != nil {
+			return nil, fmt.Errorf("error reading public key file: %w", err)
+		}
+	}
+	sig.PublicKey.Content = (*strfmt.Base64)(&publicKeyBytes)
+
+	re.RekordObj.Signatures = []*models.RekordV002SchemaSignaturesItems0{sig}
+
+	if err := re.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, _, err := re.verifySignatures(ctx); err != nil {
+		return nil, fmt.Errorf("error verifying signatures: %v", err)
+	}
+
+	returnVal.APIVersion = swag.String(re.APIVersion())
+	returnVal.Spec = re.RekordObj
+
+	return &returnVal, nil
+}
@@ -0,0 +1,288 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rekord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"go.uber.org/goleak"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestNewEntryReturnType(t *testing.T) {
+	entry := NewEntry()
+	if reflect.TypeOf(entry) != reflect.ValueOf(&V002Entry{}).Type() {
+		t.Errorf("invalid type returned from NewEntry: %T", entry)
+	}
+}
+
+// x509SignatureOver returns an ECDSA signature over data and the matching PEM
+// public key, standing in for a second, independent release manager.
+func x509SignatureOver(t *testing.T, data []byte) ([]byte, []byte) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling public key: %v", err)
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return sig, pub
+}
+
+func TestCrossFieldValidation(t *testing.T) {
+	type TestCase struct {
+		caseDesc                  string
+		entry                     V002Entry
+		expectUnmarshalSuccess    bool
+		expectCanonicalizeSuccess bool
+	}
+
+	pgpSigBytes, _ := ioutil.ReadFile("../../../../tests/test_file.sig")
+	pgpKeyBytes, _ := ioutil.ReadFile("../../../../tests/test_public_key.key")
+	dataBytes, _ := ioutil.ReadFile("../../../../tests/test_file.txt")
+	x509SigBytes, x509KeyBytes := x509SignatureOver(t, dataBytes)
+
+	pgpSignature := func() *models.RekordV002SchemaSignaturesItems0 {
+		return &models.RekordV002SchemaSignaturesItems0{
+			Format:  swag.String("pgp"),
+			Content: (*strfmt.Base64)(&pgpSigBytes),
+			PublicKey: &models.RekordV002SchemaSignaturesItems0PublicKey{
+				Content: (*strfmt.Base64)(&pgpKeyBytes),
+			},
+		}
+	}
+	x509Signature := func() *models.RekordV002SchemaSignaturesItems0 {
+		return &models.RekordV002SchemaSignaturesItems0{
+			Format:  swag.String("x509"),
+			Content: (*strfmt.Base64)(&x509SigBytes),
+			PublicKey: &models.RekordV002SchemaSignaturesItems0PublicKey{
+				Content: (*strfmt.Base64)(&x509KeyBytes),
+			},
+		}
+	}
+
+	testCases := []TestCase{
+		{
+			caseDesc:               "empty obj",
+			entry:                  V002Entry{},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "no signatures",
+			entry: V002Entry{
+				RekordObj: models.RekordV002Schema{
+					Data: &models.RekordV002SchemaData{
+						Content: strfmt.Base64(dataBytes),
+					},
+				},
+			},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "signature without public key",
+			entry: V002Entry{
+				RekordObj: models.RekordV002Schema{
+					Signatures: []*models.RekordV002SchemaSignaturesItems0{
+						{
+							Format:  swag.String("pgp"),
+							Content: (*strfmt.Base64)(&pgpSigBytes),
+						},
+					},
+					Data: &models.RekordV002SchemaData{
+						Content: strfmt.Base64(dataBytes),
+					},
+				},
+			},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "signatures without data",
+			entry: V002Entry{
+				RekordObj: models.RekordV002Schema{
+					Signatures: []*models.RekordV002SchemaSignaturesItems0{pgpSignature()},
+				},
+			},
+			expectUnmarshalSuccess: false,
+		},
+		{
+			caseDesc: "single valid signature with data with content",
+			entry: V002Entry{
+				RekordObj: models.RekordV002Schema{
+					Signatures: []*models.RekordV002SchemaSignaturesItems0{pgpSignature()},
+					Data: &models.RekordV002SchemaData{
+						Content: strfmt.Base64(dataBytes),
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
+		{
+			caseDesc: "two valid signatures from different signers",
+			entry: V002Entry{
+				RekordObj: models.RekordV002Schema{
+					Signatures: []*models.RekordV002SchemaSignaturesItems0{pgpSignature(), x509Signature()},
+					Data: &models.RekordV002SchemaData{
+						Content: strfmt.Base64(dataBytes),
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: true,
+		},
+		{
+			caseDesc: "second signature does not verify",
+			entry: V002Entry{
+				RekordObj: models.RekordV002Schema{
+					Signatures: []*models.RekordV002SchemaSignaturesItems0{
+						pgpSignature(),
+						{
+							Format:  swag.String("x509"),
+							Content: (*strfmt.Base64)(&x509SigBytes),
+							PublicKey: &models.RekordV002SchemaSignaturesItems0PublicKey{
+								Content: (*strfmt.Base64)(&pgpKeyBytes),
+							},
+						},
+					},
+					Data: &models.RekordV002SchemaData{
+						Content: strfmt.Base64(dataBytes),
+					},
+				},
+			},
+			expectUnmarshalSuccess:    true,
+			expectCanonicalizeSuccess: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &V002Entry{}
+		r := models.Rekord{
+			APIVersion: swag.String(tc.entry.APIVersion()),
+			Spec:       tc.entry.RekordObj,
+		}
+
+		if err := v.Unmarshal(&r); (err == nil) != tc.expectUnmarshalSuccess {
+			t.Fatalf("unexpected result in '%v': %v", tc.caseDesc, err)
+		}
+		// No need to continue here if we didn't unmarshal
+		if !tc.expectUnmarshalSuccess {
+			continue
+		}
+
+		b, err := v.Canonicalize(context.TODO())
+		if (err == nil) != tc.expectCanonicalizeSuccess {
+			t.Errorf("unexpected result from Canonicalize for '%v': %v", tc.caseDesc, err)
+		} else if err != nil {
+			if _, ok := err.(types.ValidationError); !ok {
+				t.Errorf("canonicalize returned an unexpected error that isn't of type types.ValidationError: %v", err)
+			}
+		}
+		if b != nil {
+			pe, err := models.UnmarshalProposedEntry(bytes.NewReader(b), runtime.JSONConsumer())
+			if err != nil {
+				t.Errorf("unexpected err from Unmarshalling canonicalized entry for '%v': %v", tc.caseDesc, err)
+			}
+			if _, err := types.NewEntry(pe); err != nil {
+				t.Errorf("unexpected err from type-specific unmarshalling for '%v': %v", tc.caseDesc, err)
+			}
+		}
+	}
+}
+
+func TestIndexKeysCoverEachSigner(t *testing.T) {
+	pgpSigBytes, _ := ioutil.ReadFile("../../../../tests/test_file.sig")
+	pgpKeyBytes, _ := ioutil.ReadFile("../../../../tests/test_public_key.key")
+	dataBytes, _ := ioutil.ReadFile("../../../../tests/test_file.txt")
+	x509SigBytes, x509KeyBytes := x509SignatureOver(t, dataBytes)
+
+	v := &V002Entry{
+		RekordObj: models.RekordV002Schema{
+			Signatures: []*models.RekordV002SchemaSignaturesItems0{
+				{
+					Format:  swag.String("pgp"),
+					Content: (*strfmt.Base64)(&pgpSigBytes),
+					PublicKey: &models.RekordV002SchemaSignaturesItems0PublicKey{
+						Content: (*strfmt.Base64)(&pgpKeyBytes),
+					},
+				},
+				{
+					Format:  swag.String("x509"),
+					Content: (*strfmt.Base64)(&x509SigBytes),
+					PublicKey: &models.RekordV002SchemaSignaturesItems0PublicKey{
+						Content: (*strfmt.Base64)(&x509KeyBytes),
+					},
+				},
+			},
+			Data: &models.RekordV002SchemaData{
+				Content: strfmt.Base64(dataBytes),
+			},
+		},
+	}
+	if _, err := v.Canonicalize(context.TODO()); err != nil {
+		t.Fatalf("error canonicalizing entry: %v", err)
+	}
+
+	keys, err := v.IndexKeys()
+	if err != nil {
+		t.Fatalf("error getting index keys: %v", err)
+	}
+
+	// one key hash per signer plus the artifact hash; the PGP key also
+	// contributes email identities
+	hashKeys := 0
+	artifactHashKey := false
+	for _, k := range keys {
+		if strings.HasPrefix(k, "sha256:") {
+			artifactHashKey = true
+		} else if len(k) == 64 && !strings.Contains(k, "@") {
+			hashKeys++
+		}
+	}
+	if hashKeys != 2 {
+		t.Errorf("expected 2 public key hash index keys, found %d in %v", hashKeys, keys)
+	}
+	if !artifactHashKey {
+		t.Errorf("expected artifact hash index key, found none in %v", keys)
+	}
+}
@@ -0,0 +1,42 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SchemaMap stores mapping between "kind:version" strings and the JSON schema
+// describing that version's proposed entry shape; like TypeMap, entries are
+// written once at process initialization and read afterwards
+var SchemaMap sync.Map
+
+// RegisterSchema records the JSON schema for a versioned type; it is called
+// from the init functions of version implementations alongside their entry
+// factory registration.
+func RegisterSchema(kind, version string, schema []byte) {
+	SchemaMap.Store(fmt.Sprintf("%v:%v", kind, version), schema)
+}
+
+// SchemaForVersion returns the JSON schema registered for the given kind and
+// version, or nil if none was registered.
+func SchemaForVersion(kind, version string) []byte {
+	if schema, ok := SchemaMap.Load(fmt.Sprintf("%v:%v", kind, version)); ok {
+		return schema.([]byte)
+	}
+	return nil
+}
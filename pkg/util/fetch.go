@@ -21,7 +21,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // FileOrURLReadCloser Note: caller is responsible for closing ReadCloser returned from method!
@@ -48,3 +53,147 @@ func FileOrURLReadCloser(ctx context.Context, url string, content []byte) (io.Re
 	}
 	return dataReader, nil
 }
+
+// FetchOptions constrain a URL fetch performed on behalf of a remote client.
+// The zero value permits http and https to any publicly routable host with no
+// size cap or deadline beyond the supplied context.
+type FetchOptions struct {
+	// AllowedSchemes lists acceptable URL schemes; empty permits http and https.
+	AllowedSchemes []string
+	// AllowedHosts lists acceptable hostnames; empty permits any host.
+	AllowedHosts []string
+	// MaxSize caps the number of response body bytes that may be read; 0 applies no cap.
+	MaxSize int64
+	// Timeout bounds the entire fetch including body reads; 0 applies no bound.
+	Timeout time.Duration
+}
+
+// SafeFileOrURLReadCloser is FileOrURLReadCloser for URLs supplied by
+// untrusted clients: the scheme and host are checked against the options,
+// connections to loopback, private, and link-local addresses (including cloud
+// metadata endpoints) are refused at dial time so DNS answers cannot redirect
+// the fetch inside the network, and the body is capped at opts.MaxSize.
+// Callers are responsible for closing the returned ReadCloser.
+func SafeFileOrURLReadCloser(ctx context.Context, fetchURL string, content []byte, opts FetchOptions) (io.ReadCloser, error) {
+	if fetchURL == "" {
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	parsed, err := url.Parse(fetchURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching artifact '%v': %w", fetchURL, err)
+	}
+	if err := opts.checkURL(parsed); err != nil {
+		return nil, fmt.Errorf("fetching artifact '%v': %w", fetchURL, err)
+	}
+
+	client := RestrictedClient(opts.Timeout)
+	client.CheckRedirect = func(req *http.Request, _ []*http.Request) error {
+		// redirects must satisfy the same constraints as the original URL
+		return opts.checkURL(req.URL)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fetchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching artifact '%v': %w", fetchURL, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching artifact '%v': %w", fetchURL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error received while fetching artifact '%v': %v", fetchURL, resp.Status)
+	}
+	if opts.MaxSize > 0 {
+		if resp.ContentLength > opts.MaxSize {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetching artifact '%v': response of %d bytes exceeds the maximum fetch size of %d bytes", fetchURL, resp.ContentLength, opts.MaxSize)
+		}
+		return &limitedReadCloser{rc: resp.Body, max: opts.MaxSize, remaining: opts.MaxSize}, nil
+	}
+	return resp.Body, nil
+}
+
+func (o FetchOptions) checkURL(u *url.URL) error {
+	schemes := o.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	schemeOK := false
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			schemeOK = true
+			break
+		}
+	}
+	if !schemeOK {
+		return fmt.Errorf("URL scheme '%v' is not permitted for remote fetches", u.Scheme)
+	}
+	if len(o.AllowedHosts) > 0 {
+		for _, h := range o.AllowedHosts {
+			if strings.EqualFold(u.Hostname(), h) {
+				return nil
+			}
+		}
+		return fmt.Errorf("host '%v' is not in the remote fetch allowlist", u.Hostname())
+	}
+	return nil
+}
+
+// RestrictedClient returns an HTTP client for requests to URLs supplied by
+// untrusted clients: connections to loopback, private, and link-local
+// addresses are refused at dial time, including on redirects, so the server
+// cannot be steered at hosts inside the network. A timeout of 0 applies no
+// bound beyond the request context.
+func RestrictedClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Control: rejectRestrictedAddresses,
+			}).DialContext,
+		},
+	}
+}
+
+// rejectRestrictedAddresses runs against the address actually being dialed,
+// after DNS resolution, so a hostname cannot be used to reach loopback,
+// RFC 1918, or link-local ranges (which include the 169.254.169.254 cloud
+// metadata service).
+func rejectRestrictedAddresses(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unable to parse dialed address '%v'", host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("connections to restricted address '%v' are not permitted", ip)
+	}
+	return nil
+}
+
+// limitedReadCloser errors once more than max bytes have been read, rather
+// than silently truncating as io.LimitReader would.
+type limitedReadCloser struct {
+	rc             io.ReadCloser
+	max, remaining int64
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.rc.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, fmt.Errorf("response body exceeds the maximum fetch size of %d bytes", l.max)
+	}
+	return n, err
+}
+
+func (l *limitedReadCloser) Close() error {
+	return l.rc.Close()
+}
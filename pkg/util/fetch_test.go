@@ -0,0 +1,125 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		opts    FetchOptions
+		wantErr bool
+	}{
+		{
+			name: "https permitted by default",
+			url:  "https://example.com/artifact",
+		},
+		{
+			name: "http permitted by default",
+			url:  "http://example.com/artifact",
+		},
+		{
+			name:    "file scheme rejected",
+			url:     "file:///etc/passwd",
+			wantErr: true,
+		},
+		{
+			name:    "gopher scheme rejected",
+			url:     "gopher://example.com/",
+			wantErr: true,
+		},
+		{
+			name:    "scheme allowlist excludes http",
+			url:     "http://example.com/artifact",
+			opts:    FetchOptions{AllowedSchemes: []string{"https"}},
+			wantErr: true,
+		},
+		{
+			name: "host in allowlist",
+			url:  "https://artifacts.example.com/a",
+			opts: FetchOptions{AllowedHosts: []string{"artifacts.example.com"}},
+		},
+		{
+			name: "host allowlist is case-insensitive",
+			url:  "https://Artifacts.Example.COM/a",
+			opts: FetchOptions{AllowedHosts: []string{"artifacts.example.com"}},
+		},
+		{
+			name:    "host not in allowlist",
+			url:     "https://evil.example.net/a",
+			opts:    FetchOptions{AllowedHosts: []string{"artifacts.example.com"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatalf("parsing %v: %v", tc.url, err)
+			}
+			err = tc.opts.checkURL(u)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkURL(%v) = %v, wantErr %v", tc.url, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRejectRestrictedAddresses(t *testing.T) {
+	restricted := []string{
+		"127.0.0.1:80",
+		"10.0.0.5:443",
+		"172.16.1.1:8080",
+		"192.168.1.1:443",
+		"169.254.169.254:80", // cloud metadata service
+		"0.0.0.0:80",
+		"[::1]:443",
+		"[fe80::1]:443",
+	}
+	for _, addr := range restricted {
+		if err := rejectRestrictedAddresses("tcp", addr, nil); err == nil {
+			t.Errorf("expected dialing %v to be rejected", addr)
+		}
+	}
+	allowed := []string{
+		"93.184.216.34:443",
+		"[2606:2800:220:1:248:1893:25c8:1946]:443",
+	}
+	for _, addr := range allowed {
+		if err := rejectRestrictedAddresses("tcp", addr, nil); err != nil {
+			t.Errorf("expected dialing %v to be permitted, got %v", addr, err)
+		}
+	}
+}
+
+func TestLimitedReadCloser(t *testing.T) {
+	within := &limitedReadCloser{rc: ioutil.NopCloser(strings.NewReader("under the cap")), max: 64, remaining: 64}
+	if _, err := io.ReadAll(within); err != nil {
+		t.Errorf("reading body under the cap: %v", err)
+	}
+
+	over := &limitedReadCloser{rc: ioutil.NopCloser(strings.NewReader(strings.Repeat("a", 65))), max: 64, remaining: 64}
+	if _, err := io.ReadAll(over); err == nil {
+		t.Error("expected error reading body over the cap")
+	}
+}
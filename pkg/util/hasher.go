@@ -0,0 +1,52 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto"
+	_ "crypto/sha256" // registered so LogHasher can construct SHA-256 hashers
+	_ "crypto/sha512" // registered so LogHasher can construct SHA-384/512 hashers
+	"fmt"
+	"strings"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// LogHasher returns the RFC 6962 tree hasher for the named algorithm. An
+// empty algorithm selects SHA-256, which all existing trees use; trees
+// created with SHA-384 or SHA-512 must be served with the matching hasher or
+// every proof verification will fail.
+func LogHasher(algorithm string) (*rfc6962.Hasher, error) {
+	switch LogHasherName(algorithm) {
+	case "sha256":
+		return rfc6962.DefaultHasher, nil
+	case "sha384":
+		return rfc6962.New(crypto.SHA384), nil
+	case "sha512":
+		return rfc6962.New(crypto.SHA512), nil
+	default:
+		return nil, fmt.Errorf("unsupported log hash algorithm %q (supported: sha256, sha384, sha512)", algorithm)
+	}
+}
+
+// LogHasherName normalizes a hash algorithm name as used in config and in the
+// loginfo response; the empty string maps to the sha256 default.
+func LogHasherName(algorithm string) string {
+	if algorithm == "" {
+		return "sha256"
+	}
+	return strings.ToLower(algorithm)
+}
@@ -0,0 +1,95 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+)
+
+// InclusionPromise is the log's commitment to integrate a queued entry within
+// a bounded delay, mirroring Certificate Transparency's SCT model: the log has
+// witnessed the leaf but not yet sequenced it.
+type InclusionPromise struct {
+	// LogID is the SHA-256 hash of the log's DER-encoded public key, in hex.
+	LogID string `json:"logID"`
+	// LeafHash is the hex-encoded merkle leaf hash of the queued entry; this
+	// doubles as the entry UUID once the entry has been integrated.
+	LeafHash string `json:"leafHash"`
+	// Timestamp is the unix time at which the log accepted the entry.
+	Timestamp int64 `json:"timestamp"`
+	// MaxMergeDelay is the maximum number of seconds after Timestamp by which
+	// the log promises the entry will be integrated.
+	MaxMergeDelay int64 `json:"maxMergeDelay"`
+}
+
+// SignedInclusionPromise binds an InclusionPromise to the log's signing key.
+type SignedInclusionPromise struct {
+	InclusionPromise InclusionPromise `json:"inclusionPromise"`
+	// Signature is a base64-encoded signature over the JCS canonicalization
+	// of the inclusionPromise value.
+	Signature string `json:"signature"`
+}
+
+// Canonicalize returns the canonical JSON encoding of the promise, which is
+// the payload its signature covers.
+func (p InclusionPromise) Canonicalize() ([]byte, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return canonical.Transform(payload)
+}
+
+// Sign signs the canonicalized promise with the log's signing key.
+func (p InclusionPromise) Sign(ctx context.Context, signer signature.Signer) (*SignedInclusionPromise, error) {
+	canonicalized, err := p.Canonicalize()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.SignMessage(bytes.NewReader(canonicalized), options.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("signing inclusion promise: %w", err)
+	}
+	return &SignedInclusionPromise{
+		InclusionPromise: p,
+		Signature:        base64.StdEncoding.EncodeToString(sig),
+	}, nil
+}
+
+// Verify checks the signature over the promise with the given verifier.
+func (s SignedInclusionPromise) Verify(verifier signature.Verifier) error {
+	canonicalized, err := s.InclusionPromise.Canonicalize()
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return fmt.Errorf("decoding promise signature: %w", err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(canonicalized)); err != nil {
+		return fmt.Errorf("verifying inclusion promise: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+func TestInclusionPromiseSignVerify(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	signer, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("loading signer: %v", err)
+	}
+
+	promise := InclusionPromise{
+		LogID:         "1234",
+		LeafHash:      "deadbeef",
+		Timestamp:     time.Now().Unix(),
+		MaxMergeDelay: 300,
+	}
+	signed, err := promise.Sign(context.Background(), signer)
+	if err != nil {
+		t.Fatalf("signing promise: %v", err)
+	}
+	if err := signed.Verify(signer); err != nil {
+		t.Errorf("expected promise to verify: %v", err)
+	}
+
+	tampered := *signed
+	tampered.InclusionPromise.LeafHash = "feedface"
+	if err := tampered.Verify(signer); err == nil {
+		t.Error("expected verification failure for tampered promise")
+	}
+}
@@ -17,7 +17,9 @@ package util
 
 import (
 	"context"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"errors"
 
 	"github.com/sigstore/rekor/pkg/generated/client"
@@ -25,7 +27,7 @@ import (
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
-func PublicKey(ctx context.Context, c *client.Rekor) (*ecdsa.PublicKey, error) {
+func PublicKey(ctx context.Context, c *client.Rekor) (crypto.PublicKey, error) {
 	resp, err := c.Pubkey.GetPublicKey(&pubkey.GetPublicKeyParams{Context: ctx})
 	if err != nil {
 		return nil, err
@@ -36,9 +38,9 @@ func PublicKey(ctx context.Context, c *client.Rekor) (*ecdsa.PublicKey, error) {
 	if err != nil {
 		return nil, err
 	}
-	ed, ok := pubKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("public key retrieved from Rekor is not an ECDSA key")
+	switch pubKey.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return pubKey, nil
 	}
-	return ed, nil
+	return nil, errors.New("public key retrieved from Rekor is not an ECDSA or Ed25519 key")
 }
@@ -0,0 +1,145 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+)
+
+// TrustBundleMediaType identifies the trust bundle format exported for
+// air-gapped verification.
+const TrustBundleMediaType = "application/vnd.dev.sigstore.trustbundle+json;version=0.1"
+
+// TrustBundleCheckpoint pairs a shard with its signed checkpoint, carried
+// verbatim as served by the log so any witness cosignatures on the note are
+// preserved.
+type TrustBundleCheckpoint struct {
+	TreeID     string `json:"treeID"`
+	Checkpoint string `json:"checkpoint"`
+}
+
+// TrustBundle packages everything needed to verify log entries without
+// network access: the signed trusted root carrying log keys and the shard
+// table, plus a signed checkpoint per shard captured at export time.
+type TrustBundle struct {
+	MediaType string `json:"mediaType"`
+	// CreatedAt records when the bundle was exported, in RFC 3339 form.
+	CreatedAt         string                  `json:"createdAt,omitempty"`
+	SignedTrustedRoot SignedTrustedRoot       `json:"signedTrustedRoot"`
+	Checkpoints       []TrustBundleCheckpoint `json:"checkpoints"`
+}
+
+// LoadTrustBundle reads a trust bundle from disk.
+func LoadTrustBundle(path string) (*TrustBundle, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading trust bundle: %w", err)
+	}
+	return ParseTrustBundle(contents)
+}
+
+// ParseTrustBundle parses a trust bundle from raw bytes.
+func ParseTrustBundle(contents []byte) (*TrustBundle, error) {
+	var b TrustBundle
+	if err := json.Unmarshal(contents, &b); err != nil {
+		return nil, fmt.Errorf("parsing trust bundle: %w", err)
+	}
+	if len(b.SignedTrustedRoot.TrustedRoot.Logs) == 0 {
+		return nil, errors.New("trust bundle contains no logs")
+	}
+	return &b, nil
+}
+
+// Verify checks the internal consistency of the bundle: the trusted root
+// signature must verify with the active shard's key, and each checkpoint's
+// log signature must verify with the key of the shard it belongs to. Witness
+// cosignatures on the checkpoints are preserved but not evaluated here; that
+// is up to the caller's witness policy.
+func (b *TrustBundle) Verify() error {
+	tr := b.SignedTrustedRoot.TrustedRoot
+
+	active, err := tr.ActiveLog()
+	if err != nil {
+		return err
+	}
+	activeKey, err := active.ParsedPublicKey()
+	if err != nil {
+		return err
+	}
+	activeVerifier, err := signature.LoadVerifier(activeKey, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(tr)
+	if err != nil {
+		return err
+	}
+	canonicalized, err := canonical.Transform(payload)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.SignedTrustedRoot.Signature)
+	if err != nil {
+		return fmt.Errorf("decoding trusted root signature: %w", err)
+	}
+	if err := activeVerifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(canonicalized)); err != nil {
+		return fmt.Errorf("verifying trusted root signature: %w", err)
+	}
+
+	for _, cp := range b.Checkpoints {
+		shard, err := tr.LogByTreeID(cp.TreeID)
+		if err != nil {
+			return err
+		}
+		pub, err := shard.ParsedPublicKey()
+		if err != nil {
+			return err
+		}
+		verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+		if err != nil {
+			return err
+		}
+		sc := SignedCheckpoint{}
+		if err := sc.UnmarshalText([]byte(cp.Checkpoint)); err != nil {
+			return fmt.Errorf("parsing checkpoint for tree %s: %w", cp.TreeID, err)
+		}
+		if !sc.Verify(verifier) {
+			return fmt.Errorf("signature on checkpoint for tree %s did not verify", cp.TreeID)
+		}
+	}
+	return nil
+}
+
+// CheckpointForTree returns the bundled checkpoint for the given decimal tree
+// ID.
+func (b *TrustBundle) CheckpointForTree(treeID string) (*TrustBundleCheckpoint, error) {
+	for i, cp := range b.Checkpoints {
+		if cp.TreeID == treeID {
+			return &b.Checkpoints[i], nil
+		}
+	}
+	return nil, fmt.Errorf("tree ID %s not present in trust bundle", treeID)
+}
@@ -0,0 +1,162 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+
+	"github.com/sigstore/rekor/pkg/canonical"
+)
+
+// buildTestBundle assembles a self-consistent trust bundle signed by a fresh
+// key, returning the bundle and the signing key for tamper tests.
+func buildTestBundle(t *testing.T) (*TrustBundle, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	keyHash := sha256.Sum256(der)
+
+	tr := TrustedRoot{
+		MediaType: TrustedRootMediaType,
+		Logs: []TrustedRootLog{
+			{
+				TreeID:    "1234",
+				LogID:     hex.EncodeToString(keyHash[:]),
+				PublicKey: pemKey,
+				Status:    "active",
+			},
+		},
+	}
+
+	signer, err := signature.LoadSigner(key, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalized, err := canonical.Transform(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.SignMessage(bytes.NewReader(canonicalized))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sth, err := CreateSignedCheckpoint(Checkpoint{
+		Origin: "test - 1234",
+		Size:   42,
+		Hash:   []byte("roothashroothashroothashroothash"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sth.Sign("test", signer, options.WithCryptoSignerOpts(crypto.SHA256)); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := sth.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &TrustBundle{
+		MediaType: TrustBundleMediaType,
+		SignedTrustedRoot: SignedTrustedRoot{
+			TrustedRoot: tr,
+			Signature:   base64.StdEncoding.EncodeToString(sig),
+		},
+		Checkpoints: []TrustBundleCheckpoint{
+			{TreeID: "1234", Checkpoint: string(checkpoint)},
+		},
+	}, key
+}
+
+func TestTrustBundleVerify(t *testing.T) {
+	bundle, _ := buildTestBundle(t)
+	if err := bundle.Verify(); err != nil {
+		t.Errorf("expected bundle to verify: %v", err)
+	}
+
+	// tampering with the shard table must invalidate the root signature
+	tampered, _ := buildTestBundle(t)
+	tampered.SignedTrustedRoot.TrustedRoot.Logs[0].TreeID = "5678"
+	tampered.Checkpoints[0].TreeID = "5678"
+	if err := tampered.Verify(); err == nil {
+		t.Error("expected verification failure for tampered trusted root")
+	}
+
+	// a checkpoint signed by a different key must be rejected
+	mixed, _ := buildTestBundle(t)
+	other, _ := buildTestBundle(t)
+	mixed.Checkpoints[0].Checkpoint = other.Checkpoints[0].Checkpoint
+	if err := mixed.Verify(); err == nil {
+		t.Error("expected verification failure for foreign checkpoint")
+	}
+}
+
+func TestParseTrustBundle(t *testing.T) {
+	bundle, _ := buildTestBundle(t)
+	contents, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseTrustBundle(contents)
+	if err != nil {
+		t.Fatalf("parsing round-tripped bundle: %v", err)
+	}
+	if err := parsed.Verify(); err != nil {
+		t.Errorf("expected round-tripped bundle to verify: %v", err)
+	}
+
+	if _, err := ParseTrustBundle([]byte("not json")); err == nil {
+		t.Error("expected error for malformed bundle")
+	}
+	if _, err := ParseTrustBundle([]byte(`{"mediaType": "x"}`)); err == nil {
+		t.Error("expected error for bundle without logs")
+	}
+
+	cp, err := parsed.CheckpointForTree("1234")
+	if err != nil || cp.Checkpoint == "" {
+		t.Errorf("expected bundled checkpoint for tree 1234, got %v, %v", cp, err)
+	}
+	if _, err := parsed.CheckpointForTree("9999"); err == nil {
+		t.Error("expected error for unknown tree ID")
+	}
+}
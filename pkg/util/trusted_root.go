@@ -0,0 +1,147 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// TrustedRootMediaType identifies the trusted root document format served by
+// the log and consumed by verifiers.
+const TrustedRootMediaType = "application/vnd.dev.sigstore.trustedroot+json;version=0.1"
+
+// TrustedRootLog describes one shard of the log: its tree ID, the key it
+// signs with, and when it was (or is) accepting entries.
+type TrustedRootLog struct {
+	// TreeID is the Trillian tree ID of the shard, in decimal.
+	TreeID string `json:"treeID"`
+	// LogID is the SHA-256 hash of the shard's DER-encoded public key, in hex.
+	LogID string `json:"logID,omitempty"`
+	// PublicKey is the PEM-encoded public key entries in this shard were
+	// signed with.
+	PublicKey string `json:"publicKey"`
+	// Status is "active" for the shard currently accepting entries and
+	// "inactive" for frozen shards.
+	Status string `json:"status"`
+	// TreeLength is the final size of an inactive shard; omitted for the
+	// active shard, which is still growing.
+	TreeLength int64 `json:"treeLength,omitempty"`
+	// ValidFor bounds the window in which this shard accepted entries, when
+	// the sharding config records it.
+	ValidFor *ValidityWindow `json:"validFor,omitempty"`
+}
+
+// ValidityWindow is a half-open time range in RFC 3339 form; either bound may
+// be omitted when unknown or unbounded.
+type ValidityWindow struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// TrustedRoot is the payload of the trusted root document: every shard of the
+// log, oldest first, ending with the active shard.
+type TrustedRoot struct {
+	MediaType string           `json:"mediaType"`
+	Logs      []TrustedRootLog `json:"logs"`
+}
+
+// SignedTrustedRoot wraps a TrustedRoot with a signature from the log's
+// signing key so verifiers can authenticate the trust material they bootstrap
+// from.
+type SignedTrustedRoot struct {
+	TrustedRoot TrustedRoot `json:"trustedRoot"`
+	// Signature is a base64-encoded signature over the JCS canonicalization
+	// of the trustedRoot value, made with the active shard's key.
+	Signature string `json:"signature"`
+}
+
+// LoadTrustedRoot reads a trusted root document from disk. Both the signed
+// envelope served by the log and a bare document are accepted, so operators
+// can save the endpoint response directly.
+func LoadTrustedRoot(path string) (*TrustedRoot, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading trusted root: %w", err)
+	}
+	return ParseTrustedRoot(contents)
+}
+
+// ParseTrustedRoot parses a trusted root document, in either its signed or
+// bare form, from raw bytes.
+func ParseTrustedRoot(contents []byte) (*TrustedRoot, error) {
+	var signed SignedTrustedRoot
+	if err := json.Unmarshal(contents, &signed); err == nil && len(signed.TrustedRoot.Logs) > 0 {
+		return &signed.TrustedRoot, nil
+	}
+	var tr TrustedRoot
+	if err := json.Unmarshal(contents, &tr); err != nil {
+		return nil, fmt.Errorf("parsing trusted root: %w", err)
+	}
+	if len(tr.Logs) == 0 {
+		return nil, errors.New("trusted root document contains no logs")
+	}
+	return &tr, nil
+}
+
+// ActiveLog returns the shard currently accepting entries.
+func (tr *TrustedRoot) ActiveLog() (*TrustedRootLog, error) {
+	for i, l := range tr.Logs {
+		if l.Status == "active" {
+			return &tr.Logs[i], nil
+		}
+	}
+	return nil, errors.New("trusted root document has no active log")
+}
+
+// LogByTreeID returns the shard with the given decimal tree ID; an empty
+// treeID selects the active shard.
+func (tr *TrustedRoot) LogByTreeID(treeID string) (*TrustedRootLog, error) {
+	if treeID == "" {
+		return tr.ActiveLog()
+	}
+	for i, l := range tr.Logs {
+		if l.TreeID == treeID {
+			return &tr.Logs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("tree ID %s not present in trusted root document", treeID)
+}
+
+// LogByLogID returns the shard whose public key hashes to the given hex log
+// ID, as reported in the logID field of log entries.
+func (tr *TrustedRoot) LogByLogID(logID string) (*TrustedRootLog, error) {
+	for i, l := range tr.Logs {
+		if l.LogID == logID {
+			return &tr.Logs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("log ID %s not present in trusted root document", logID)
+}
+
+// ParsedPublicKey parses the shard's PEM-encoded public key.
+func (l *TrustedRootLog) ParsedPublicKey() (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(l.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key for tree %s", l.TreeID)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
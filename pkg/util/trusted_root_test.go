@@ -0,0 +1,111 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func testTrustedRoot(t *testing.T) TrustedRoot {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return TrustedRoot{
+		MediaType: TrustedRootMediaType,
+		Logs: []TrustedRootLog{
+			{TreeID: "1111", LogID: "aaaa", PublicKey: string(pemKey), Status: "inactive", TreeLength: 100},
+			{TreeID: "2222", LogID: "bbbb", PublicKey: string(pemKey), Status: "active"},
+		},
+	}
+}
+
+func TestLoadTrustedRoot(t *testing.T) {
+	tr := testTrustedRoot(t)
+	dir := t.TempDir()
+
+	// a bare document and the signed envelope should both load
+	bare, _ := json.Marshal(tr)
+	signed, _ := json.Marshal(SignedTrustedRoot{TrustedRoot: tr, Signature: "c2ln"})
+	for name, contents := range map[string][]byte{"bare.json": bare, "signed.json": signed} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+		loaded, err := LoadTrustedRoot(path)
+		if err != nil {
+			t.Fatalf("loading %s: %v", name, err)
+		}
+		if len(loaded.Logs) != 2 {
+			t.Errorf("%s: expected 2 logs, got %d", name, len(loaded.Logs))
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	if err := ioutil.WriteFile(empty, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrustedRoot(empty); err == nil {
+		t.Error("expected error for document with no logs")
+	}
+}
+
+func TestTrustedRootLookups(t *testing.T) {
+	tr := testTrustedRoot(t)
+
+	active, err := tr.ActiveLog()
+	if err != nil {
+		t.Fatalf("active log: %v", err)
+	}
+	if active.TreeID != "2222" {
+		t.Errorf("expected active tree 2222, got %s", active.TreeID)
+	}
+
+	if shard, err := tr.LogByTreeID(""); err != nil || shard.TreeID != "2222" {
+		t.Errorf("empty tree ID should select active shard, got %v %v", shard, err)
+	}
+	if shard, err := tr.LogByTreeID("1111"); err != nil || shard.TreeLength != 100 {
+		t.Errorf("expected inactive shard with length 100, got %v %v", shard, err)
+	}
+	if _, err := tr.LogByTreeID("9999"); err == nil {
+		t.Error("expected error for unknown tree ID")
+	}
+
+	if shard, err := tr.LogByLogID("aaaa"); err != nil || shard.TreeID != "1111" {
+		t.Errorf("expected shard 1111 for log ID aaaa, got %v %v", shard, err)
+	}
+	if _, err := tr.LogByLogID("ffff"); err == nil {
+		t.Error("expected error for unknown log ID")
+	}
+
+	if _, err := active.ParsedPublicKey(); err != nil {
+		t.Errorf("parsing public key: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verify exposes the Merkle tree verification primitives used by the
+// Rekor server, so external verifiers run exactly the checks the server runs.
+// All functions accept a nil hasher, which selects the RFC 6962 SHA-256
+// default used by production Rekor logs.
+package verify
+
+import (
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+func hasherOrDefault(hasher merkle.LogHasher) merkle.LogHasher {
+	if hasher == nil {
+		return rfc6962.DefaultHasher
+	}
+	return hasher
+}
+
+// ComputeLeafHash returns the Merkle leaf hash of a canonicalized entry,
+// which is also the entry's UUID within its shard.
+func ComputeLeafHash(hasher merkle.LogHasher, leaf []byte) []byte {
+	return hasherOrDefault(hasher).HashLeaf(leaf)
+}
+
+// VerifyInclusion verifies that the leaf hash at the given index is included
+// in the tree of the given size rooted at rootHash, using the supplied proof
+// hashes ordered from leaf to root.
+func VerifyInclusion(hasher merkle.LogHasher, index, treeSize uint64, leafHash []byte, proofHashes [][]byte, rootHash []byte) error {
+	return proof.VerifyInclusion(hasherOrDefault(hasher), index, treeSize, leafHash, proofHashes, rootHash)
+}
+
+// VerifyConsistency verifies that the tree of newSize rooted at newRoot is an
+// append-only extension of the tree of oldSize rooted at oldRoot, using the
+// supplied consistency proof hashes.
+func VerifyConsistency(hasher merkle.LogHasher, oldSize, newSize uint64, proofHashes [][]byte, oldRoot, newRoot []byte) error {
+	return proof.VerifyConsistency(hasherOrDefault(hasher), oldSize, newSize, proofHashes, oldRoot, newRoot)
+}
@@ -0,0 +1,74 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+)
+
+func buildTree(t *testing.T, size int) *testonly.Tree {
+	t.Helper()
+	tree := testonly.New(rfc6962.DefaultHasher)
+	for i := 0; i < size; i++ {
+		tree.AppendData([]byte(fmt.Sprintf("leaf-%d", i)))
+	}
+	return tree
+}
+
+func TestComputeLeafHash(t *testing.T) {
+	leaf := []byte("some canonicalized entry")
+	if got, want := ComputeLeafHash(nil, leaf), rfc6962.DefaultHasher.HashLeaf(leaf); !bytes.Equal(got, want) {
+		t.Errorf("ComputeLeafHash(nil, ...) = %x, want %x", got, want)
+	}
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	tree := buildTree(t, 8)
+	const index = 3
+	proofHashes, err := tree.InclusionProof(index, tree.Size())
+	if err != nil {
+		t.Fatalf("generating inclusion proof: %v", err)
+	}
+	leafHash := ComputeLeafHash(nil, []byte(fmt.Sprintf("leaf-%d", index)))
+
+	if err := VerifyInclusion(nil, index, tree.Size(), leafHash, proofHashes, tree.Hash()); err != nil {
+		t.Errorf("valid inclusion proof rejected: %v", err)
+	}
+	if err := VerifyInclusion(nil, index+1, tree.Size(), leafHash, proofHashes, tree.Hash()); err == nil {
+		t.Error("inclusion proof accepted for wrong index")
+	}
+}
+
+func TestVerifyConsistency(t *testing.T) {
+	tree := buildTree(t, 8)
+	oldRoot := tree.HashAt(5)
+	proofHashes, err := tree.ConsistencyProof(5, tree.Size())
+	if err != nil {
+		t.Fatalf("generating consistency proof: %v", err)
+	}
+
+	if err := VerifyConsistency(nil, 5, tree.Size(), proofHashes, oldRoot, tree.Hash()); err != nil {
+		t.Errorf("valid consistency proof rejected: %v", err)
+	}
+	if err := VerifyConsistency(nil, 5, tree.Size(), proofHashes, tree.Hash(), tree.Hash()); err == nil {
+		t.Error("consistency proof accepted against wrong old root")
+	}
+}
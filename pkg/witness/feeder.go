@@ -0,0 +1,215 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigstore/rekor/pkg/log"
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// feederRequestTimeout bounds one HTTP exchange with a witness.
+const feederRequestTimeout = 30 * time.Second
+
+// Feeder runs the witness feeder protocol as a background job: it submits
+// each new checkpoint to the configured witnesses, collects their
+// cosignatures, and hands the combined checkpoint back to the server once the
+// policy threshold is met. A witness that has fallen behind is caught up with
+// a consistency proof from its last known size.
+type Feeder struct {
+	// Policy is the cosignature threshold the combined checkpoint must meet.
+	Policy *Policy
+	// Witnesses are the base URLs of the witnesses to feed.
+	Witnesses []string
+	// Interval is how often a fresh checkpoint is fed.
+	Interval time.Duration
+	// Latest returns a freshly signed checkpoint over the current root.
+	Latest func(ctx context.Context) (*util.SignedCheckpoint, error)
+	// ConsistencyProof returns the proof hashes between two tree sizes.
+	ConsistencyProof func(ctx context.Context, first, last uint64) ([][]byte, error)
+	// Submit hands a checkpoint that satisfied the policy back to the server.
+	Submit func(raw string) error
+
+	client *http.Client
+	mu     sync.Mutex
+	// knownSizes tracks the latest tree size each witness is known to have
+	// accepted, so consistency proofs start from the right place
+	knownSizes map[string]uint64
+}
+
+// Run feeds checkpoints to the witnesses until the context is cancelled.
+func (f *Feeder) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.Interval)
+	defer ticker.Stop()
+	for {
+		if err := f.feedOnce(ctx); err != nil {
+			log.Logger.Warnf("witness feeder: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// feedOnce signs the current checkpoint, gathers cosignatures from every
+// reachable witness, and submits the combined checkpoint if it satisfies the
+// policy. Individual witness failures are logged but do not abort the round;
+// the policy decides whether enough witnesses responded.
+func (f *Feeder) feedOnce(ctx context.Context) error {
+	sc, err := f.Latest(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching checkpoint to feed: %w", err)
+	}
+	scBytes, err := sc.SignedNote.MarshalText()
+	if err != nil {
+		return fmt.Errorf("marshalling checkpoint: %w", err)
+	}
+	raw := string(scBytes)
+
+	combined := strings.Builder{}
+	combined.WriteString(raw)
+	cosigned := 0
+	for _, witnessURL := range f.Witnesses {
+		sigs, err := f.feedWitness(ctx, witnessURL, sc.Size, raw)
+		if err != nil {
+			log.Logger.Warnf("witness feeder: feeding %s: %v", witnessURL, err)
+			continue
+		}
+		combined.WriteString(sigs)
+		cosigned++
+	}
+	if cosigned == 0 {
+		return fmt.Errorf("no witnesses returned a cosignature for size %d", sc.Size)
+	}
+
+	witnessed := &util.SignedCheckpoint{}
+	if err := witnessed.UnmarshalText([]byte(combined.String())); err != nil {
+		return fmt.Errorf("parsing combined checkpoint: %w", err)
+	}
+	if err := f.Policy.Satisfied(witnessed); err != nil {
+		return fmt.Errorf("checkpoint at size %d: %w", sc.Size, err)
+	}
+	return f.Submit(combined.String())
+}
+
+// feedWitness submits one checkpoint to one witness and returns the
+// cosignature lines it responded with. The request carries a consistency
+// proof from the witness's last known size; a conflict response updates that
+// size and the submission is retried once with a corrected proof.
+func (f *Feeder) feedWitness(ctx context.Context, witnessURL string, size uint64, raw string) (string, error) {
+	old := f.knownSize(witnessURL)
+	for attempt := 0; attempt < 2; attempt++ {
+		if old > size {
+			return "", fmt.Errorf("witness is at size %d, ahead of the log at size %d", old, size)
+		}
+		body, err := f.buildRequest(ctx, old, size, raw)
+		if err != nil {
+			return "", err
+		}
+		status, respBody, err := f.post(ctx, strings.TrimSuffix(witnessURL, "/")+"/add-checkpoint", body)
+		if err != nil {
+			return "", err
+		}
+		switch status {
+		case http.StatusOK:
+			f.setKnownSize(witnessURL, size)
+			return respBody, nil
+		case http.StatusConflict:
+			// the witness's view of the log differs from our last record of
+			// it; resync and retry with a proof from its actual size
+			actual, err := strconv.ParseUint(strings.TrimSpace(respBody), 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("parsing witness size from conflict response: %w", err)
+			}
+			f.setKnownSize(witnessURL, actual)
+			old = actual
+		default:
+			return "", fmt.Errorf("witness returned status %d: %s", status, strings.TrimSpace(respBody))
+		}
+	}
+	return "", fmt.Errorf("witness still reports a conflicting size after resync")
+}
+
+// buildRequest assembles an add-checkpoint request body: the witness's last
+// known size, the consistency proof hashes from that size, a blank line, then
+// the checkpoint itself.
+func (f *Feeder) buildRequest(ctx context.Context, old, size uint64, raw string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "old %d\n", old)
+	if old > 0 && old < size {
+		hashes, err := f.ConsistencyProof(ctx, old, size)
+		if err != nil {
+			return "", fmt.Errorf("fetching consistency proof from %d to %d: %w", old, size, err)
+		}
+		for _, h := range hashes {
+			b.WriteString(base64.StdEncoding.EncodeToString(h))
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(raw)
+	return b.String(), nil
+}
+
+func (f *Feeder) post(ctx context.Context, url, body string) (int, string, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, feederRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	client := f.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 65536))
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+func (f *Feeder) knownSize(witnessURL string) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.knownSizes[witnessURL]
+}
+
+func (f *Feeder) setKnownSize(witnessURL string, size uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.knownSizes == nil {
+		f.knownSizes = make(map[string]uint64)
+	}
+	f.knownSizes[witnessURL] = size
+}
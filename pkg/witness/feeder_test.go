@@ -0,0 +1,207 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// fakeWitness implements enough of the witness protocol for feeder tests: it
+// tracks a tree size, answers conflicting submissions with 409 and its size,
+// and cosigns checkpoints that carry a proof from that size.
+type fakeWitness struct {
+	signer       testSigner
+	size         uint64
+	sawProof     bool
+	failRequests bool
+}
+
+func (fw *fakeWitness) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/add-checkpoint" {
+			http.NotFound(w, r)
+			return
+		}
+		if fw.failRequests {
+			http.Error(w, "witness unavailable", http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(string(body), "\n")
+		old, err := strconv.ParseUint(strings.TrimPrefix(lines[0], "old "), 10, 64)
+		if err != nil {
+			http.Error(w, "malformed old line", http.StatusBadRequest)
+			return
+		}
+		if old != fw.size {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintf(w, "%d", fw.size)
+			return
+		}
+		// proof hashes run from after the old line to the first blank line
+		i := 1
+		for ; i < len(lines) && lines[i] != ""; i++ {
+			fw.sawProof = true
+		}
+		raw := strings.Join(lines[i+1:], "\n")
+
+		sc := &util.SignedCheckpoint{}
+		if err := sc.UnmarshalText([]byte(raw)); err != nil {
+			http.Error(w, "malformed checkpoint", http.StatusBadRequest)
+			return
+		}
+		before, err := sc.SignedNote.MarshalText()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := sc.Sign("witness.example.com", fw.signer.signer, options.NoOpOptionImpl{}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		after, err := sc.SignedNote.MarshalText()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fw.size = sc.Size
+		fmt.Fprint(w, strings.TrimPrefix(string(after), string(before)))
+	}
+}
+
+func feederForTest(t *testing.T, policy *Policy, logSigner testSigner, size uint64, witnesses ...string) (*Feeder, *[]string) {
+	t.Helper()
+	submitted := &[]string{}
+	return &Feeder{
+		Policy:    policy,
+		Witnesses: witnesses,
+		Interval:  time.Minute,
+		Latest: func(_ context.Context) (*util.SignedCheckpoint, error) {
+			hash := sha256.Sum256([]byte("root"))
+			sc, err := util.CreateSignedCheckpoint(util.Checkpoint{Origin: "Rekor", Size: size, Hash: hash[:]})
+			if err != nil {
+				return nil, err
+			}
+			if _, err := sc.Sign("rekor.example.com", logSigner.signer, options.NoOpOptionImpl{}); err != nil {
+				return nil, err
+			}
+			return sc, nil
+		},
+		ConsistencyProof: func(_ context.Context, _, _ uint64) ([][]byte, error) {
+			h := sha256.Sum256([]byte("proof"))
+			return [][]byte{h[:]}, nil
+		},
+		Submit: func(raw string) error {
+			*submitted = append(*submitted, raw)
+			return nil
+		},
+	}, submitted
+}
+
+func TestFeederCollectsCosignatures(t *testing.T) {
+	logSigner := newECDSASigner(t)
+	w1 := &fakeWitness{signer: newECDSASigner(t)}
+	w2 := &fakeWitness{signer: newEd25519Signer(t)}
+	srv1 := httptest.NewServer(w1.handler(t))
+	defer srv1.Close()
+	srv2 := httptest.NewServer(w2.handler(t))
+	defer srv2.Close()
+
+	policy, err := NewPolicy(2, []crypto.PublicKey{w1.signer.pub, w2.signer.pub})
+	if err != nil {
+		t.Fatalf("creating policy: %v", err)
+	}
+	feeder, submitted := feederForTest(t, policy, logSigner, 5, srv1.URL, srv2.URL)
+
+	if err := feeder.feedOnce(context.Background()); err != nil {
+		t.Fatalf("feedOnce: %v", err)
+	}
+	if len(*submitted) != 1 {
+		t.Fatalf("expected one submitted checkpoint, got %d", len(*submitted))
+	}
+	sc := &util.SignedCheckpoint{}
+	if err := sc.UnmarshalText([]byte((*submitted)[0])); err != nil {
+		t.Fatalf("parsing submitted checkpoint: %v", err)
+	}
+	if err := policy.Satisfied(sc); err != nil {
+		t.Errorf("submitted checkpoint does not satisfy the policy: %v", err)
+	}
+	if !SignedBy(sc.SignedNote, logSigner.pub) {
+		t.Error("submitted checkpoint lost the log's own signature")
+	}
+}
+
+func TestFeederCatchesUpBehindWitness(t *testing.T) {
+	logSigner := newECDSASigner(t)
+	w := &fakeWitness{signer: newECDSASigner(t), size: 3}
+	srv := httptest.NewServer(w.handler(t))
+	defer srv.Close()
+
+	policy, err := NewPolicy(1, []crypto.PublicKey{w.signer.pub})
+	if err != nil {
+		t.Fatalf("creating policy: %v", err)
+	}
+	feeder, submitted := feederForTest(t, policy, logSigner, 5, srv.URL)
+
+	if err := feeder.feedOnce(context.Background()); err != nil {
+		t.Fatalf("feedOnce: %v", err)
+	}
+	if len(*submitted) != 1 {
+		t.Fatalf("expected one submitted checkpoint, got %d", len(*submitted))
+	}
+	if !w.sawProof {
+		t.Error("expected the retry to carry a consistency proof from the witness's size")
+	}
+	if got := feeder.knownSize(srv.URL); got != 5 {
+		t.Errorf("expected known size 5 after feeding, got %d", got)
+	}
+}
+
+func TestFeederBelowThreshold(t *testing.T) {
+	logSigner := newECDSASigner(t)
+	w1 := &fakeWitness{signer: newECDSASigner(t)}
+	w2 := &fakeWitness{signer: newECDSASigner(t), failRequests: true}
+	srv1 := httptest.NewServer(w1.handler(t))
+	defer srv1.Close()
+	srv2 := httptest.NewServer(w2.handler(t))
+	defer srv2.Close()
+
+	policy, err := NewPolicy(2, []crypto.PublicKey{w1.signer.pub, w2.signer.pub})
+	if err != nil {
+		t.Fatalf("creating policy: %v", err)
+	}
+	feeder, submitted := feederForTest(t, policy, logSigner, 5, srv1.URL, srv2.URL)
+
+	if err := feeder.feedOnce(context.Background()); err == nil {
+		t.Error("expected feedOnce to fail below the cosignature threshold")
+	}
+	if len(*submitted) != 0 {
+		t.Errorf("expected no submitted checkpoint, got %d", len(*submitted))
+	}
+}
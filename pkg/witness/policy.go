@@ -0,0 +1,144 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package witness implements checkpoint cosignature policies. A policy
+// requires a threshold of configured witnesses to have cosigned a checkpoint
+// before the server treats it as current, limiting the log's ability to
+// present different views to different clients.
+package witness
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/spf13/viper"
+
+	"github.com/sigstore/rekor/pkg/util"
+)
+
+// Policy is an N-of-M witness cosignature requirement on checkpoints.
+type Policy struct {
+	threshold int
+	witnesses []crypto.PublicKey
+}
+
+// LoadPolicy builds a policy from the server configuration; it returns nil if
+// no cosignatures are required.
+func LoadPolicy() (*Policy, error) {
+	threshold := viper.GetInt("rekor_server.witness.required_cosignatures")
+	if threshold <= 0 {
+		return nil, nil
+	}
+	paths := viper.GetStringSlice("rekor_server.witness.pubkey_paths")
+	pubs := make([]crypto.PublicKey, 0, len(paths))
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading witness key %s: %w", path, err)
+		}
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey(contents)
+		if err != nil {
+			return nil, fmt.Errorf("parsing witness key %s: %w", path, err)
+		}
+		pubs = append(pubs, pub)
+	}
+	return NewPolicy(threshold, pubs)
+}
+
+// NewPolicy returns a policy requiring threshold cosignatures from the given
+// witness keys.
+func NewPolicy(threshold int, witnesses []crypto.PublicKey) (*Policy, error) {
+	if threshold <= 0 {
+		return nil, errors.New("witness policy threshold must be positive")
+	}
+	if threshold > len(witnesses) {
+		return nil, fmt.Errorf("witness policy requires %d cosignatures but only %d witness keys are configured", threshold, len(witnesses))
+	}
+	return &Policy{threshold: threshold, witnesses: witnesses}, nil
+}
+
+// Threshold returns the number of required cosignatures.
+func (p *Policy) Threshold() int {
+	return p.threshold
+}
+
+// Satisfied reports whether the checkpoint carries verifying cosignatures
+// from at least the required number of distinct configured witnesses.
+func (p *Policy) Satisfied(sc *util.SignedCheckpoint) error {
+	count := 0
+	for _, pub := range p.witnesses {
+		if SignedBy(sc.SignedNote, pub) {
+			count++
+		}
+	}
+	if count < p.threshold {
+		return fmt.Errorf("checkpoint has %d of %d required witness cosignatures", count, p.threshold)
+	}
+	return nil
+}
+
+// SignedBy reports whether the note carries a signature by the given key.
+// Candidate signatures are selected by the note format's key hash hint and
+// then cryptographically verified.
+func SignedBy(sn util.SignedNote, pub crypto.PublicKey) bool {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return false
+	}
+	pkSha := sha256.Sum256(der)
+	keyHash := binary.BigEndian.Uint32(pkSha[:])
+
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return false
+	}
+	msg := []byte(sn.Note)
+	digest := sha256.Sum256(msg)
+	opts := []signature.VerifyOption{}
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		opts = append(opts, options.WithDigest(digest[:]))
+	case ed25519.PublicKey:
+	default:
+		return false
+	}
+
+	for _, sig := range sn.Signatures {
+		if sig.Hash != keyHash {
+			continue
+		}
+		sigBytes, err := base64.StdEncoding.DecodeString(sig.Base64)
+		if err != nil {
+			continue
+		}
+		if err := verifier.VerifySignature(bytes.NewReader(sigBytes), bytes.NewReader(msg), opts...); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+//
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+type testSigner struct {
+	signer signature.Signer
+	pub    crypto.PublicKey
+}
+
+func newECDSASigner(t *testing.T) testSigner {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	signer, err := signature.LoadSigner(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("loading signer: %v", err)
+	}
+	return testSigner{signer: signer, pub: priv.Public()}
+}
+
+func newEd25519Signer(t *testing.T) testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	signer, err := signature.LoadSigner(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("loading signer: %v", err)
+	}
+	return testSigner{signer: signer, pub: pub}
+}
+
+func testCheckpoint(t *testing.T, signers ...testSigner) *util.SignedCheckpoint {
+	t.Helper()
+	hash := sha256.Sum256([]byte("test"))
+	sc, err := util.CreateSignedCheckpoint(util.Checkpoint{
+		Origin: "Rekor",
+		Size:   42,
+		Hash:   hash[:],
+	})
+	if err != nil {
+		t.Fatalf("creating checkpoint: %v", err)
+	}
+	for i, s := range signers {
+		if _, err := sc.Sign(string(rune('a'+i))+".example.com", s.signer, options.NoOpOptionImpl{}); err != nil {
+			t.Fatalf("signing checkpoint: %v", err)
+		}
+	}
+	return sc
+}
+
+func TestPolicySatisfied(t *testing.T) {
+	logSigner := newECDSASigner(t)
+	w1 := newECDSASigner(t)
+	w2 := newEd25519Signer(t)
+	w3 := newECDSASigner(t)
+
+	policy, err := NewPolicy(2, []crypto.PublicKey{w1.pub, w2.pub, w3.pub})
+	if err != nil {
+		t.Fatalf("creating policy: %v", err)
+	}
+
+	t.Run("threshold met", func(t *testing.T) {
+		sc := testCheckpoint(t, logSigner, w1, w2)
+		if err := policy.Satisfied(sc); err != nil {
+			t.Errorf("expected policy to be satisfied: %v", err)
+		}
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		sc := testCheckpoint(t, logSigner, w1)
+		if err := policy.Satisfied(sc); err == nil {
+			t.Error("expected policy failure with one cosignature")
+		}
+	})
+
+	t.Run("unknown cosigners do not count", func(t *testing.T) {
+		sc := testCheckpoint(t, logSigner, newECDSASigner(t), newECDSASigner(t))
+		if err := policy.Satisfied(sc); err == nil {
+			t.Error("expected policy failure with unknown cosigners")
+		}
+	})
+
+	t.Run("duplicate witness counts once", func(t *testing.T) {
+		sc := testCheckpoint(t, logSigner, w1, w1)
+		if err := policy.Satisfied(sc); err == nil {
+			t.Error("expected policy failure when one witness signs twice")
+		}
+	})
+}
+
+func TestNewPolicyValidation(t *testing.T) {
+	w := newECDSASigner(t)
+	if _, err := NewPolicy(0, []crypto.PublicKey{w.pub}); err == nil {
+		t.Error("expected error for zero threshold")
+	}
+	if _, err := NewPolicy(2, []crypto.PublicKey{w.pub}); err == nil {
+		t.Error("expected error when threshold exceeds key count")
+	}
+}
+
+func TestSignedBy(t *testing.T) {
+	logSigner := newECDSASigner(t)
+	w1 := newEd25519Signer(t)
+	sc := testCheckpoint(t, logSigner, w1)
+
+	if !SignedBy(sc.SignedNote, logSigner.pub) {
+		t.Error("expected note to verify against the log key")
+	}
+	if !SignedBy(sc.SignedNote, w1.pub) {
+		t.Error("expected note to verify against the witness key")
+	}
+	if SignedBy(sc.SignedNote, newECDSASigner(t).pub) {
+		t.Error("expected no signature by an unrelated key")
+	}
+}